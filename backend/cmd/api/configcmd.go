@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+)
+
+const configUsage = `usage: api config print
+
+Prints the effective configuration, one setting per line with where it
+came from (default, env, env file or config file). Secrets are redacted.
+Problems found by validation are listed after it.
+`
+
+// runConfig implements the "config" subcommand. loadErr is what
+// config.Load returned; the config is printed whenever there is one.
+func runConfig(cfg *config.Config, loadErr error, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return errors.New("want: config print")
+	}
+	// an unreadable config file leaves nothing to print
+	if cfg == nil {
+		return loadErr
+	}
+	printConfig(os.Stdout, cfg)
+	if err := errors.Join(loadErr, cfg.Validate()); err != nil {
+		fmt.Fprintf(os.Stderr, "\ninvalid config:\n%v\n", err)
+		return errors.New("config has problems")
+	}
+	return nil
+}
+
+func printConfig(w io.Writer, cfg *config.Config) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range cfg.Settings() {
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", s.Key, s.Display(), s.Source)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+)
+
+func TestRunConfigMissingFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	cfg, err := config.Load()
+	if err == nil {
+		t.Fatal("loaded a missing config file")
+	}
+	if err := runConfig(cfg, err, []string{"print"}); err == nil || !strings.Contains(err.Error(), "config file") {
+		t.Fatalf("runConfig = %v", err)
+	}
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"gofuckbiz/snimayprosto-rent-easy/internal/app"
 	"gofuckbiz/snimayprosto-rent-easy/internal/config"
-	"gofuckbiz/snimayprosto-rent-easy/internal/core"
 	"gofuckbiz/snimayprosto-rent-easy/internal/database"
-	handlers "gofuckbiz/snimayprosto-rent-easy/internal/http/handlers"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
+	"gofuckbiz/snimayprosto-rent-easy/internal/logging"
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
 )
 
 func ensureUploadsDir(dir string) error {
@@ -22,18 +27,45 @@ func ensureUploadsDir(dir string) error {
 }
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
 
-	r := gin.Default()
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(cfg, err, os.Args[2:]); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
 
-	// ✅ CORS middleware (через gin-contrib/cors)
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://127.0.0.1:5173", "http://localhost:8081", "http://127.0.0.1:8081"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
+	// структурные логи; log.Printf тоже уходит в slog
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("migrate: %v", err)
+			}
+			return
+		case "seed":
+			if err := runSeed(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("seed: %v", err)
+			}
+			return
+		case "serve":
+		default:
+			log.Fatalf("unknown command %q (want serve, migrate, seed or config)", os.Args[1])
+		}
+	}
 
 	// подключение к БД
 	db, err := database.OpenPostgres(cfg)
@@ -42,7 +74,7 @@ func main() {
 	}
 
 	// миграции
-	if err := db.AutoMigrate(&core.User{}, &core.Property{}, &core.PropertyImage{}, &core.Favorite{}, &core.Conversation{}, &core.Message{}, &core.UserPlan{}, &core.PropertyPromotion{}); err != nil {
+	if err := ensureSchema(cfg, db); err != nil {
 		log.Fatalf("migrate: %v", err)
 	}
 
@@ -51,51 +83,49 @@ func main() {
 		log.Fatalf("uploads dir: %v", err)
 	}
 
-	// health-check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"ok": true})
-	})
-
-	// auth
-	auth := handlers.NewAuthHandler(db, cfg)
-	r.POST("/auth/register", auth.Register)
-	r.POST("/auth/login", auth.Login)
-	r.POST("/auth/refresh", auth.Refresh)
-	r.POST("/auth/logout", auth.Logout)
-	r.GET("/auth/me", handlers.AuthMiddleware(cfg), auth.Me)
-	r.PUT("/auth/role", handlers.AuthMiddleware(cfg), auth.UpdateRole)
-
-	// properties
-	props := handlers.NewPropertiesHandler(db, cfg)
-	r.POST("/properties", handlers.AuthMiddleware(cfg), props.Create)
-	r.GET("/properties", props.List)
-	r.GET("/properties/:id", props.Get)
-	r.POST("/properties/:id/images", handlers.AuthMiddleware(cfg), props.UploadImages)
-	r.GET("/properties/my", handlers.AuthMiddleware(cfg), props.MyListings)
-	r.POST("/properties/:id/promote", handlers.AuthMiddleware(cfg), props.PromoteProperty)
-
-	// plans
-	plans := handlers.NewPlansHandler(db, cfg)
-	r.GET("/plans/my", handlers.AuthMiddleware(cfg), plans.GetMyPlan)
-	r.POST("/plans/upgrade", handlers.AuthMiddleware(cfg), plans.UpgradePlan)
-
-	// chat
-	chat := handlers.NewChatHandler(db, cfg)
-	r.POST("/chat/start/:propertyId", handlers.AuthMiddleware(cfg), chat.StartConversation)
-	r.GET("/chat/:conversationId/messages", handlers.AuthMiddleware(cfg), chat.ListMessages)
-	r.GET("/chat/conversations", handlers.AuthMiddleware(cfg), chat.ListConversations)
-	r.GET("/ws/chat/:conversationId", chat.Socket) // WebSocket route, token in query param
-
-	// stats
-	stats := handlers.NewStatsHandler(db, cfg)
-	r.GET("/stats", stats.GetStats)
-
-	// serve uploaded files
-	r.Static("/uploads", cfg.Uploads.Dir)
+	// SIGINT/SIGTERM начинают остановку
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// трейсинг; по умолчанию спаны никуда не отправляются
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg)
+	if err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
+
+	a := app.New(cfg, db)
+	a.Start(ctx)
 
 	// запуск сервера
-	addr := fmt.Sprintf(":%s", cfg.HTTPPort)
-	if err := r.Run(addr); err != nil {
-		log.Fatal(err)
+	srv := a.Server(fmt.Sprintf(":%s", cfg.HTTPPort))
+	slog.Info("listening", "addr", srv.Addr, "env", cfg.AppEnv)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("http server failed", "err", err)
+		}
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	}
+	stop()
+
+	// /readyz падает сразу, балансировщик успевает снять трафик
+	a.Health.ShutDown()
+	time.Sleep(cfg.HTTP.DrainDelay)
+
+	// порядок: HTTP (запросы, сокеты, SSE), фоновые задачи, БД
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("http shutdown", "err", err)
+	}
+	if err := a.Stop(shutdownCtx); err != nil {
+		slog.Error("shutdown", "err", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown", "err", err)
 	}
 }
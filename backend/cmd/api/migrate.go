@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/database"
+	"gofuckbiz/snimayprosto-rent-easy/internal/migrate"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up [n]         apply all (or the next n) pending migrations
+  down [n]       roll back the last n migrations (default 1)
+  status         list migrations and when they were applied
+  create <name>  write an empty migration pair (-dir, default internal/migrate/sql)
+`
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("dir", "internal/migrate/sql", "directory for new migrations")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing command")
+	}
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+
+	if cmd == "create" {
+		if len(rest) != 1 {
+			return fmt.Errorf("create needs a name")
+		}
+		up, down, err := migrate.Create(*dir, rest[0], time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Println(up)
+		fmt.Println(down)
+		return nil
+	}
+
+	n := 0
+	if len(rest) > 0 {
+		v, err := strconv.Atoi(rest[0])
+		if err != nil || v < 1 {
+			return fmt.Errorf("invalid count %q", rest[0])
+		}
+		n = v
+	}
+
+	db, err := database.OpenPostgres(cfg)
+	if err != nil {
+		return err
+	}
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch cmd {
+	case "up":
+		done, err := m.Up(ctx, n)
+		for _, mig := range done {
+			fmt.Printf("applied %d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("nothing to apply")
+		}
+		return err
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err := m.Down(ctx, n)
+		for _, mig := range done {
+			fmt.Printf("rolled back %d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range st {
+			at := "pending"
+			if s.AppliedAt != nil {
+				at = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, at)
+		}
+		return w.Flush()
+	}
+	fs.Usage()
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+func newMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB)
+}
+
+// ensureSchema applies pending migrations when DB_MIGRATE_ON_START is set,
+// otherwise refuses to start against an outdated schema.
+func ensureSchema(cfg *config.Config, db *gorm.DB) error {
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if cfg.DB.MigrateOnStart {
+		done, err := m.Up(ctx, 0)
+		for _, mig := range done {
+			slog.Info("migrate: applied", "version", mig.Version, "name", mig.Name)
+		}
+		return err
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, run `api migrate up` first", len(pending))
+	}
+	return nil
+}
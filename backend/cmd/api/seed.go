@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/database"
+	"gofuckbiz/snimayprosto-rent-easy/internal/seed"
+)
+
+const seedUsage = `usage: api seed [flags]
+
+Loads a demo dataset. Every seeded account ends in @` + seed.Domain + ` and
+uses the password ` + seed.Password + `; log in as ` + seed.AdminEmail + `,
+` + seed.LandlordEmail + ` or ` + seed.TenantEmail + `.
+
+Seeding twice is a no-op; pass -reset to replace the seeded data.
+
+flags:
+`
+
+// runSeed implements the "seed" subcommand.
+func runSeed(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	def := seed.DefaultOptions()
+	opts := seed.Options{}
+	fs.Int64Var(&opts.Seed, "seed", def.Seed, "random seed; equal seeds give equal datasets")
+	fs.IntVar(&opts.Landlords, "landlords", def.Landlords, "number of landlords")
+	fs.IntVar(&opts.Tenants, "tenants", def.Tenants, "number of tenants")
+	reset := fs.Bool("reset", false, "delete previously seeded data first")
+	resetOnly := fs.Bool("reset-only", false, "delete previously seeded data and stop")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, seedUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := database.OpenPostgres(cfg)
+	if err != nil {
+		return err
+	}
+	if err := ensureSchema(cfg, db); err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if *reset || *resetOnly {
+		if err := seed.Reset(ctx, db, cfg.Uploads.Dir); err != nil {
+			return err
+		}
+		fmt.Println("removed seeded data")
+		if *resetOnly {
+			return nil
+		}
+	}
+
+	sum, err := seed.Apply(ctx, db, seed.Generate(opts), cfg.Uploads.Dir, time.Now())
+	if errors.Is(err, seed.ErrAlreadySeeded) {
+		fmt.Println("already seeded, nothing to do (use -reset to reseed)")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("seeded %s\n", sum)
+	return nil
+}
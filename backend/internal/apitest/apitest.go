@@ -0,0 +1,336 @@
+// Package apitest runs the whole HTTP API against a throwaway Postgres
+// schema (see testdb) and drives it like the web app does: cookies,
+// bearer tokens, CSRF header and the chat WebSocket.
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/app"
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/router"
+	"gofuckbiz/snimayprosto-rent-easy/internal/seed"
+	"gofuckbiz/snimayprosto-rent-easy/internal/testdb"
+)
+
+// Password is used for every account the harness registers.
+const Password = "secret123"
+
+// Origin is sent on every request, as a browser on the dev server would.
+const Origin = "http://localhost:5173"
+
+type Server struct {
+	*httptest.Server
+	App *app.App
+	t   testing.TB
+}
+
+// New starts the API on a fresh schema. The test is skipped when no test
+// database is configured, and fails when a response does not match the
+// OpenAPI document.
+func New(t testing.TB) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := testdb.Open(t)
+
+	cfg := config.Defaults()
+	cfg.AppEnv = "test"
+	cfg.Uploads.Dir = t.TempDir()
+
+	a := app.New(cfg, db)
+	// every test is also a contract test
+	a.Routes.Contract.Report = func(c *gin.Context, err error) { t.Errorf("openapi: %v", err) }
+	srv := httptest.NewServer(a.Engine)
+	t.Cleanup(srv.Close)
+	return &Server{Server: srv, App: a, t: t}
+}
+
+// Seed loads the default seed dataset, the one demos use.
+func (s *Server) Seed() seed.Summary {
+	s.t.Helper()
+	sum, err := seed.Apply(context.Background(), s.App.DB, seed.Generate(seed.DefaultOptions()), s.App.Cfg.Uploads.Dir, time.Now())
+	if err != nil {
+		s.t.Fatalf("seed: %v", err)
+	}
+	return sum
+}
+
+// User is the account as returned by the auth endpoints.
+type User struct {
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+}
+
+// Client is one browser session.
+type Client struct {
+	s     *Server
+	HTTP  *http.Client
+	Token string // access token, sent as a bearer token when set
+	CSRF  string // sent in the CSRF header when set
+	User  User
+}
+
+// Client returns an anonymous session that has fetched its CSRF token,
+// like the web app does on load.
+func (s *Server) Client() *Client {
+	s.t.Helper()
+	jar, _ := cookiejar.New(nil)
+	c := &Client{s: s, HTTP: &http.Client{Jar: jar, Timeout: 10 * time.Second}}
+	var resp struct {
+		CSRFToken string `json:"csrfToken"`
+	}
+	c.Do("GET", "/auth/csrf", nil).Status(http.StatusOK).JSON(&resp)
+	c.CSRF = resp.CSRFToken
+	return c
+}
+
+type authResponse struct {
+	User        User   `json:"user"`
+	AccessToken string `json:"accessToken"`
+}
+
+// Register signs up a new account and returns its session.
+func (s *Server) Register(email, firstName, lastName string) *Client {
+	s.t.Helper()
+	c := s.Client()
+	var resp authResponse
+	c.Do("POST", "/auth/register", map[string]string{
+		"email":     email,
+		"password":  Password,
+		"firstName": firstName,
+		"lastName":  lastName,
+	}).Status(http.StatusCreated).JSON(&resp)
+	c.Token, c.User = resp.AccessToken, resp.User
+	return c
+}
+
+// Login opens a new session for an existing account.
+func (s *Server) Login(email, password string) *Client {
+	s.t.Helper()
+	c := s.Client()
+	var resp authResponse
+	c.Do("POST", "/auth/login", map[string]string{"email": email, "password": password}).
+		Status(http.StatusOK).JSON(&resp)
+	c.Token, c.User = resp.AccessToken, resp.User
+	return c
+}
+
+// Landlord registers an account and switches it to the landlord role.
+func (s *Server) Landlord(email, firstName, lastName string) *Client {
+	s.t.Helper()
+	c := s.Register(email, firstName, lastName)
+	c.Do("PUT", "/auth/role", map[string]string{"role": "landlord"}).Status(http.StatusOK)
+	c.User.Role = "landlord"
+	return c
+}
+
+// Cookie returns the session cookie with the given name, or "".
+func (c *Client) Cookie(name string) string {
+	u, _ := url.Parse(c.s.URL)
+	for _, ck := range c.HTTP.Jar.Cookies(u) {
+		if ck.Name == name {
+			return ck.Value
+		}
+	}
+	return ""
+}
+
+// Do sends a JSON request to an API path (relative to router.Prefix).
+// body may be nil.
+func (c *Client) Do(method, path string, body interface{}) *Response {
+	c.s.t.Helper()
+	return c.DoRaw(method, router.Prefix+path, body)
+}
+
+// DoRaw is Do for paths outside the API, like /uploads.
+func (c *Client) DoRaw(method, path string, body interface{}) *Response {
+	c.s.t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			c.s.t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.s.URL+path, r)
+	if err != nil {
+		c.s.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req)
+}
+
+// Upload posts files as a multipart form under field to an API path.
+func (c *Client) Upload(path, field string, files map[string][]byte) *Response {
+	c.s.t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, data := range files {
+		fw, err := w.CreateFormFile(field, name)
+		if err != nil {
+			c.s.t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	w.Close()
+	req, err := http.NewRequest("POST", c.s.URL+router.Prefix+path, &buf)
+	if err != nil {
+		c.s.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return c.send(req)
+}
+
+// send adds the headers the web app sends: origin, bearer token and CSRF
+// token.
+func (c *Client) send(req *http.Request) *Response {
+	c.s.t.Helper()
+	req.Header.Set("Origin", Origin)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.CSRF != "" {
+		req.Header.Set("X-CSRF-Token", c.CSRF)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		c.s.t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.s.t.Fatal(err)
+	}
+	return &Response{Response: resp, Body: body, t: c.s.t}
+}
+
+type Response struct {
+	*http.Response
+	Body []byte
+	t    testing.TB
+}
+
+// Status fails the test unless the response has the given status.
+func (r *Response) Status(want int) *Response {
+	r.t.Helper()
+	if r.StatusCode != want {
+		r.t.Fatalf("%s %s: status %d, want %d: %s", r.Request.Method, r.Request.URL.Path, r.StatusCode, want, r.Body)
+	}
+	return r
+}
+
+// JSON decodes the body into v.
+func (r *Response) JSON(v interface{}) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("%s %s: decode %s: %v", r.Request.Method, r.Request.URL.Path, r.Body, err)
+	}
+	return r
+}
+
+// Error returns the "error" code of an error response.
+func (r *Response) Error() string {
+	var e struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(r.Body, &e)
+	return e.Error
+}
+
+// ExpectError fails the test unless the response has the given status and
+// error code.
+func (r *Response) ExpectError(status int, code string) {
+	r.t.Helper()
+	r.Status(status)
+	if got := r.Error(); got != code {
+		r.t.Fatalf("%s %s: error %q, want %q", r.Request.Method, r.Request.URL.Path, got, code)
+	}
+}
+
+// ChatConn is an open chat WebSocket.
+type ChatConn struct {
+	*websocket.Conn
+	t testing.TB
+}
+
+// DialChat opens the chat socket of a conversation and waits until the
+// server has registered it, so broadcasts right after are not missed.
+func (c *Client) DialChat(conversationID uint) *ChatConn {
+	c.s.t.Helper()
+	u := "ws" + strings.TrimPrefix(c.s.URL, "http") + fmt.Sprintf("%s/ws/chat/%d?token=%s", router.Prefix, conversationID, url.QueryEscape(c.Token))
+	h := http.Header{"Origin": {Origin}}
+	conn, resp, err := websocket.DefaultDialer.Dial(u, h)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.s.t.Fatalf("dial chat %d: %v (status %d)", conversationID, err, status)
+	}
+	c.s.t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !c.s.App.Chat.IsOnline(c.User.ID) {
+		if time.Now().After(deadline) {
+			c.s.t.Fatalf("chat socket of user %d never registered", c.User.ID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return &ChatConn{Conn: conn, t: c.s.t}
+}
+
+// Send writes a text message.
+func (cc *ChatConn) Send(content string) {
+	cc.t.Helper()
+	if err := cc.WriteJSON(map[string]string{"type": "text", "content": content}); err != nil {
+		cc.t.Fatalf("chat send: %v", err)
+	}
+}
+
+// Event is a payload pushed over the chat socket.
+type Event struct {
+	Event          string     `json:"event"`
+	ID             uint       `json:"id"`
+	ConversationID uint       `json:"conversationId"`
+	SenderID       uint       `json:"senderId"`
+	Content        string     `json:"content"`
+	Reason         string     `json:"reason"`
+	EditedAt       *time.Time `json:"editedAt"`
+	DeletedAt      *time.Time `json:"deletedAt"`
+}
+
+// Next reads events until one named event arrives, failing after a few
+// seconds.
+func (cc *ChatConn) Next(event string) Event {
+	cc.t.Helper()
+	cc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var ev Event
+		if err := cc.ReadJSON(&ev); err != nil {
+			cc.t.Fatalf("waiting for %s: %v", event, err)
+		}
+		if ev.Event == event {
+			return ev
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package apitest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/apitest"
+)
+
+func TestAuth(t *testing.T) {
+	s := apitest.New(t)
+
+	c := s.Register("anna@example.com", "Анна", "Петрова")
+	if c.Token == "" || c.User.Email != "anna@example.com" || c.User.Name != "Анна Петрова" {
+		t.Fatalf("register: %+v", c.User)
+	}
+
+	s.Client().Do("POST", "/auth/register", map[string]string{
+		"email": "anna@example.com", "password": apitest.Password, "firstName": "A", "lastName": "B",
+	}).ExpectError(http.StatusConflict, "email_taken")
+	s.Client().Do("POST", "/auth/login", map[string]string{"email": "anna@example.com", "password": "wrong-password"}).
+		ExpectError(http.StatusUnauthorized, "invalid_credentials")
+
+	var me apitest.User
+	s.Login("anna@example.com", apitest.Password).Do("GET", "/auth/me", nil).Status(http.StatusOK).JSON(&me)
+	if me.ID != c.User.ID {
+		t.Fatalf("me = %+v, want id %d", me, c.User.ID)
+	}
+	s.Client().Do("GET", "/auth/me", nil).ExpectError(http.StatusUnauthorized, "missing_authorization")
+
+	c.Do("PUT", "/auth/role", map[string]string{"role": "landlord"}).Status(http.StatusOK)
+	c.Do("PUT", "/auth/role", map[string]string{"role": "admin"}).ExpectError(http.StatusBadRequest, "invalid_request")
+	c.Do("GET", "/auth/me", nil).Status(http.StatusOK).JSON(&me)
+	if me.Role != "landlord" {
+		t.Fatalf("role = %q, want landlord", me.Role)
+	}
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	s := apitest.New(t)
+	c := s.Register("ivan@example.com", "Иван", "Иванов")
+
+	var refreshed struct {
+		AccessToken string `json:"accessToken"`
+	}
+	c.Do("POST", "/auth/refresh", nil).Status(http.StatusOK).JSON(&refreshed)
+	if refreshed.AccessToken == "" {
+		t.Fatal("refresh returned no access token")
+	}
+	c.Token = refreshed.AccessToken
+	c.Do("GET", "/auth/me", nil).Status(http.StatusOK)
+
+	// the refresh cookie alone is not enough
+	token := c.CSRF
+	c.CSRF = ""
+	c.Do("POST", "/auth/refresh", nil).ExpectError(http.StatusForbidden, "csrf_token_missing")
+	c.Do("POST", "/auth/logout", nil).ExpectError(http.StatusForbidden, "csrf_token_missing")
+	c.CSRF = token
+
+	c.Do("POST", "/auth/logout", nil).Status(http.StatusOK)
+	c.Do("POST", "/auth/refresh", nil).ExpectError(http.StatusUnauthorized, "refresh_token_missing")
+	s.Client().Do("POST", "/auth/refresh", nil).ExpectError(http.StatusUnauthorized, "refresh_token_missing")
+}
@@ -0,0 +1,139 @@
+package apitest_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/apitest"
+	"gofuckbiz/snimayprosto-rent-easy/internal/chatfilter"
+)
+
+type conversation struct {
+	ID          uint `json:"id"`
+	InitiatorID uint `json:"initiatorId"`
+	RecipientID uint `json:"recipientId"`
+}
+
+func startChat(t *testing.T) (s *apitest.Server, landlord, tenant *apitest.Client, conv conversation) {
+	t.Helper()
+	s = apitest.New(t)
+	landlord = s.Landlord("owner@example.com", "Олег", "Смирнов")
+	tenant = s.Register("tenant@example.com", "Мария", "Попова")
+	l := createListing(t, landlord, "Квартира")
+	tenant.Do("POST", fmt.Sprintf("/chat/start/%d", l.ID), nil).Status(http.StatusOK).JSON(&conv)
+	if conv.InitiatorID != tenant.User.ID || conv.RecipientID != landlord.User.ID {
+		t.Fatalf("conversation = %+v", conv)
+	}
+	return s, landlord, tenant, conv
+}
+
+func TestChatOverWebSocket(t *testing.T) {
+	_, landlord, tenant, conv := startChat(t)
+	ts := tenant.DialChat(conv.ID)
+	ls := landlord.DialChat(conv.ID)
+
+	ts.Send("Здравствуйте! Квартира свободна?")
+	got := ls.Next("message.created")
+	if got.SenderID != tenant.User.ID || got.Content != "Здравствуйте! Квартира свободна?" {
+		t.Fatalf("landlord got %+v", got)
+	}
+	if echo := ts.Next("message.created"); echo.ID != got.ID {
+		t.Fatalf("sender echo %+v, want message %d", echo, got.ID)
+	}
+
+	ls.Send("Да, звоните: +7 999 123-45-67")
+	masked := ts.Next("message.created")
+	if strings.Contains(masked.Content, "123-45-67") || !strings.Contains(masked.Content, chatfilter.MaskText) {
+		t.Fatalf("phone not masked: %q", masked.Content)
+	}
+
+	var history struct {
+		Items []struct {
+			ID      uint   `json:"id"`
+			Content string `json:"content"`
+		} `json:"items"`
+		HasMore bool `json:"hasMore"`
+	}
+	landlord.Do("GET", fmt.Sprintf("/chat/%d/messages", conv.ID), nil).Status(http.StatusOK).JSON(&history)
+	if len(history.Items) != 2 || history.Items[0].ID != masked.ID || history.Items[0].Content != masked.Content {
+		t.Fatalf("history = %+v", history.Items)
+	}
+
+	var inbox struct {
+		Conversations []struct {
+			ID          uint `json:"id"`
+			UnreadCount int  `json:"unreadCount"`
+		} `json:"conversations"`
+	}
+	landlord.Do("GET", "/chat/conversations", nil).Status(http.StatusOK).JSON(&inbox)
+	if len(inbox.Conversations) != 1 || inbox.Conversations[0].ID != conv.ID {
+		t.Fatalf("inbox = %+v", inbox.Conversations)
+	}
+}
+
+func TestChatEditAndDelete(t *testing.T) {
+	_, landlord, tenant, conv := startChat(t)
+	ts := tenant.DialChat(conv.ID)
+	ls := landlord.DialChat(conv.ID)
+	ts.Send("Можно посмотреть в субботу?")
+	msg := ls.Next("message.created")
+	path := fmt.Sprintf("/chat/%d/messages/%d", conv.ID, msg.ID)
+
+	landlord.Do("PUT", path, map[string]string{"content": "чужое"}).ExpectError(http.StatusForbidden, "not_sender")
+	tenant.Do("PUT", path, map[string]string{"content": "Можно посмотреть в воскресенье?"}).Status(http.StatusOK)
+	edited := ls.Next("message.updated")
+	if edited.Content != "Можно посмотреть в воскресенье?" || edited.EditedAt == nil {
+		t.Fatalf("edit event = %+v", edited)
+	}
+
+	tenant.Do("DELETE", path, nil).Status(http.StatusOK)
+	deleted := ls.Next("message.deleted")
+	if deleted.Content != "" || deleted.DeletedAt == nil {
+		t.Fatalf("delete event = %+v", deleted)
+	}
+	tenant.Do("PUT", path, map[string]string{"content": "снова"}).ExpectError(http.StatusConflict, "message_deleted")
+}
+
+func TestChatBlock(t *testing.T) {
+	s, landlord, tenant, conv := startChat(t)
+	stranger := s.Register("stranger@example.com", "Иван", "Волков")
+	stranger.Do("GET", fmt.Sprintf("/chat/%d/messages", conv.ID), nil).ExpectError(http.StatusForbidden, "not_participant")
+
+	landlord.Do("POST", fmt.Sprintf("/chat/blocks/%d", tenant.User.ID), nil).Status(http.StatusOK)
+	landlord.Do("POST", fmt.Sprintf("/chat/blocks/%d", landlord.User.ID), nil).ExpectError(http.StatusBadRequest, "cannot_block_self")
+
+	ts := tenant.DialChat(conv.ID)
+	ts.Send("Ответьте, пожалуйста")
+	if ev := ts.Next("message.rejected"); ev.Reason != "blocked" {
+		t.Fatalf("rejected = %+v", ev)
+	}
+
+	var inbox struct {
+		Conversations []struct{ ID uint } `json:"conversations"`
+	}
+	landlord.Do("GET", "/chat/conversations", nil).Status(http.StatusOK).JSON(&inbox)
+	if len(inbox.Conversations) != 0 {
+		t.Fatalf("blocked initiator still in inbox: %+v", inbox.Conversations)
+	}
+
+	landlord.Do("DELETE", fmt.Sprintf("/chat/blocks/%d", tenant.User.ID), nil).Status(http.StatusOK)
+	ts.Send("Ответьте, пожалуйста")
+	ts.Next("message.created")
+}
+
+func TestShutdownClosesSockets(t *testing.T) {
+	s, _, tenant, conv := startChat(t)
+	ts := tenant.DialChat(conv.ID)
+
+	s.App.Chat.CloseSockets()
+	ts.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := ts.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("read after shutdown: %v, want close 1001", err)
+	}
+}
@@ -0,0 +1,43 @@
+package apitest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/apitest"
+	"gofuckbiz/snimayprosto-rent-easy/internal/health"
+)
+
+func TestReadiness(t *testing.T) {
+	s := apitest.New(t)
+	c := s.Client()
+	c.DoRaw("GET", "/healthz", nil).Status(http.StatusOK)
+
+	var report health.Report
+	c.DoRaw("GET", "/readyz", nil).Status(http.StatusServiceUnavailable).JSON(&report)
+	if report.Checks["workers"].Status != health.StatusFail || report.Checks["database"].Status != health.StatusOK {
+		t.Fatalf("before Start: %+v", report)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.App.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		s.App.Jobs.Stop(context.Background())
+	})
+	report = health.Report{}
+	c.DoRaw("GET", "/readyz", nil).Status(http.StatusOK).JSON(&report)
+	for _, name := range []string{"database", "migrations", "storage", "workers"} {
+		if report.Checks[name].Status != health.StatusOK {
+			t.Fatalf("check %s: %+v", name, report.Checks[name])
+		}
+	}
+
+	s.App.Health.ShutDown()
+	report = health.Report{}
+	c.DoRaw("GET", "/readyz", nil).Status(http.StatusServiceUnavailable).JSON(&report)
+	if report.Checks["shutdown"].Status != health.StatusFail {
+		t.Fatalf("while shutting down: %+v", report)
+	}
+}
@@ -0,0 +1,44 @@
+package apitest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/apitest"
+)
+
+type myPlan struct {
+	Plan struct {
+		PlanType    string     `json:"planType"`
+		MaxListings int        `json:"maxListings"`
+		ExpiresAt   *time.Time `json:"expiresAt"`
+	} `json:"plan"`
+	ActiveListings int64 `json:"activeListings"`
+	CanCreateMore  bool  `json:"canCreateMore"`
+}
+
+func TestPlans(t *testing.T) {
+	s := apitest.New(t)
+	c := s.Landlord("owner@example.com", "Олег", "Смирнов")
+
+	var p myPlan
+	c.Do("GET", "/plans/my", nil).Status(http.StatusOK).JSON(&p)
+	if p.Plan.PlanType != "free" || p.Plan.MaxListings != 3 || p.ActiveListings != 0 || !p.CanCreateMore {
+		t.Fatalf("default plan = %+v", p)
+	}
+
+	createListing(t, c, "Квартира")
+	c.Do("POST", "/plans/upgrade", map[string]string{"planType": "gold"}).ExpectError(http.StatusBadRequest, "invalid_request")
+	c.Do("POST", "/plans/upgrade", map[string]string{"planType": "premium"}).Status(http.StatusOK)
+
+	c.Do("GET", "/plans/my", nil).Status(http.StatusOK).JSON(&p)
+	if p.Plan.PlanType != "premium" || p.Plan.MaxListings != 10 || p.ActiveListings != 1 {
+		t.Fatalf("upgraded plan = %+v", p)
+	}
+	if p.Plan.ExpiresAt == nil || p.Plan.ExpiresAt.Before(time.Now().Add(27*24*time.Hour)) {
+		t.Fatalf("premium expires at %v", p.Plan.ExpiresAt)
+	}
+
+	s.Client().Do("GET", "/plans/my", nil).Status(http.StatusUnauthorized)
+}
@@ -0,0 +1,206 @@
+package apitest_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/apitest"
+)
+
+type listing struct {
+	ID         uint   `json:"id"`
+	OwnerID    uint   `json:"ownerId"`
+	Title      string `json:"title"`
+	Address    string `json:"address"`
+	IsPromoted bool   `json:"isPromoted"`
+	Images     []struct {
+		URL string `json:"url"`
+	} `json:"images"`
+}
+
+func newListing(title string) map[string]interface{} {
+	return map[string]interface{}{
+		"title":        title,
+		"description":  "Светлая квартира",
+		"address":      "Казань, ул. Баумана, 10",
+		"propertyType": "apartment",
+		"rooms":        2,
+		"area":         54,
+		"price":        30000,
+		"priceType":    "month",
+		"phone":        "+79990001122",
+		"visibility":   "public",
+		"latitude":     55.79,
+		"longitude":    49.12,
+	}
+}
+
+func createListing(t *testing.T, c *apitest.Client, title string) listing {
+	t.Helper()
+	var l listing
+	c.Do("POST", "/properties", newListing(title)).Status(http.StatusCreated).JSON(&l)
+	return l
+}
+
+func TestListingLifecycle(t *testing.T) {
+	s := apitest.New(t)
+	owner := s.Landlord("owner@example.com", "Олег", "Смирнов")
+	other := s.Landlord("other@example.com", "Ольга", "Смирнова")
+
+	a := createListing(t, owner, "Первая")
+	b := createListing(t, owner, "Вторая")
+	if a.OwnerID != owner.User.ID || a.IsPromoted {
+		t.Fatalf("created %+v", a)
+	}
+	s.Client().Do("POST", "/properties", newListing("Аноним")).Status(http.StatusUnauthorized)
+
+	var got listing
+	s.Client().Do("GET", fmt.Sprintf("/properties/%d", a.ID), nil).Status(http.StatusOK).JSON(&got)
+	if got.Title != "Первая" {
+		t.Fatalf("get = %+v", got)
+	}
+	s.Client().Do("GET", "/properties/999999", nil).ExpectError(http.StatusNotFound, "Property not found")
+
+	other.Do("POST", fmt.Sprintf("/properties/%d/promote", a.ID), nil).
+		ExpectError(http.StatusNotFound, "property_not_found_or_not_owned")
+	var first, second struct{ ExpiresAt time.Time }
+	owner.Do("POST", fmt.Sprintf("/properties/%d/promote", b.ID), nil).Status(http.StatusOK).JSON(&first)
+	// buying again extends the running promotion
+	owner.Do("POST", fmt.Sprintf("/properties/%d/promote", b.ID), nil).Status(http.StatusOK).JSON(&second)
+	if !second.ExpiresAt.Equal(first.ExpiresAt.AddDate(0, 0, 7)) {
+		t.Fatalf("extended to %v, first ends %v", second.ExpiresAt, first.ExpiresAt)
+	}
+	owner.Do("POST", fmt.Sprintf("/properties/%d/promote", b.ID), map[string]any{
+		"product": "top_city_3d", "startsAt": time.Now().Add(time.Hour),
+	}).ExpectError(http.StatusConflict, "already_promoted")
+	owner.Do("POST", fmt.Sprintf("/properties/%d/promote", b.ID), map[string]any{"product": "gold"}).
+		ExpectError(http.StatusBadRequest, "invalid_promotion_product")
+
+	var feed struct{ Items []listing }
+	s.Client().Do("GET", "/properties?city=Казань", nil).Status(http.StatusOK).JSON(&feed)
+	if len(feed.Items) != 2 || feed.Items[0].ID != b.ID {
+		t.Fatalf("feed = %+v, want promoted %d first", feed.Items, b.ID)
+	}
+	s.Client().Do("GET", "/properties?city=Сочи", nil).Status(http.StatusOK).JSON(&feed)
+	if len(feed.Items) != 0 {
+		t.Fatalf("feed for another city = %+v", feed.Items)
+	}
+
+	var mine struct{ Items []listing }
+	owner.Do("GET", "/properties/my", nil).Status(http.StatusOK).JSON(&mine)
+	promoted := map[uint]bool{}
+	for _, l := range mine.Items {
+		promoted[l.ID] = l.IsPromoted
+	}
+	if len(mine.Items) != 2 || !promoted[b.ID] || promoted[a.ID] {
+		t.Fatalf("my listings = %+v", mine.Items)
+	}
+}
+
+func TestAmenities(t *testing.T) {
+	s := apitest.New(t)
+	owner := s.Landlord("owner@example.com", "Олег", "Смирнов")
+
+	var catalog struct {
+		Items []struct{ Code, Label, Icon string }
+	}
+	s.Client().Do("GET", "/amenities", nil).Status(http.StatusOK).JSON(&catalog)
+	if len(catalog.Items) < 5 || catalog.Items[0].Code != "internet" || catalog.Items[0].Label != "Интернет" {
+		t.Fatalf("catalog = %+v", catalog.Items)
+	}
+
+	body := newListing("С удобствами")
+	body["amenities"] = []string{"tv", "internet", "tv"}
+	body["petsAllowed"] = true
+	body["floor"] = 4
+	body["totalFloors"] = 9
+	var withAll struct {
+		listing
+		Amenities   []string `json:"amenities"`
+		PetsAllowed *bool    `json:"petsAllowed"`
+		Furnished   *bool    `json:"furnished"`
+		Floor       *int     `json:"floor"`
+	}
+	owner.Do("POST", "/properties", body).Status(http.StatusCreated)
+	plain := createListing(t, owner, "Без удобств")
+
+	var feed struct{ Items []listing }
+	s.Client().Do("GET", "/properties?amenities=internet,tv&pets=true", nil).Status(http.StatusOK).JSON(&feed)
+	if len(feed.Items) != 1 || feed.Items[0].ID == plain.ID {
+		t.Fatalf("filtered feed = %+v", feed.Items)
+	}
+	s.Client().Do("GET", fmt.Sprintf("/properties/%d", feed.Items[0].ID), nil).Status(http.StatusOK).JSON(&withAll)
+	if fmt.Sprint(withAll.Amenities) != "[internet tv]" || withAll.PetsAllowed == nil || !*withAll.PetsAllowed ||
+		withAll.Furnished != nil || withAll.Floor == nil || *withAll.Floor != 4 {
+		t.Fatalf("listing = %+v", withAll)
+	}
+	// repeated codes count once
+	s.Client().Do("GET", "/properties?amenities=tv,internet,tv", nil).Status(http.StatusOK).JSON(&feed)
+	if len(feed.Items) != 1 || feed.Items[0].ID != withAll.ID {
+		t.Fatalf("feed with a repeated amenity = %+v", feed.Items)
+	}
+	s.Client().Do("GET", "/properties?amenities=internet,elevator", nil).Status(http.StatusOK).JSON(&feed)
+	if len(feed.Items) != 0 {
+		t.Fatalf("feed with a missing amenity = %+v", feed.Items)
+	}
+
+	owner.Do("DELETE", fmt.Sprintf("/properties/%d", withAll.ID), nil).Status(http.StatusOK)
+}
+
+func TestListingLimit(t *testing.T) {
+	s := apitest.New(t)
+	owner := s.Landlord("owner@example.com", "Олег", "Смирнов")
+
+	for i := 0; i < 3; i++ {
+		createListing(t, owner, fmt.Sprintf("Квартира %d", i))
+	}
+	var limit struct {
+		Error          string `json:"error"`
+		CurrentPlan    string `json:"currentPlan"`
+		MaxListings    int    `json:"maxListings"`
+		ActiveListings int64  `json:"activeListings"`
+	}
+	owner.Do("POST", "/properties", newListing("Лишняя")).Status(http.StatusForbidden).JSON(&limit)
+	if limit.Error != "listing_limit_exceeded" || limit.CurrentPlan != "free" || limit.MaxListings != 3 || limit.ActiveListings != 3 {
+		t.Fatalf("limit error = %+v", limit)
+	}
+
+	owner.Do("POST", "/plans/upgrade", map[string]string{"planType": "premium"}).Status(http.StatusOK)
+	body := newListing("С продвижением")
+	body["promote"] = true
+	var l listing
+	owner.Do("POST", "/properties", body).Status(http.StatusCreated).JSON(&l)
+	if !l.IsPromoted {
+		t.Fatalf("created with promote = %+v", l)
+	}
+}
+
+func TestUploadImages(t *testing.T) {
+	s := apitest.New(t)
+	owner := s.Landlord("owner@example.com", "Олег", "Смирнов")
+	other := s.Landlord("other@example.com", "Ольга", "Смирнова")
+	l := createListing(t, owner, "С фото")
+	path := fmt.Sprintf("/properties/%d/images", l.ID)
+	photo := map[string][]byte{"flat.jpg": []byte("not really a jpeg")}
+
+	other.Upload(path, "images", photo).ExpectError(http.StatusForbidden, "not_owner")
+	owner.Upload(path, "images", nil).ExpectError(http.StatusBadRequest, "no_images")
+
+	var up struct{ Images []struct{ URL string } }
+	owner.Upload(path, "images", photo).Status(http.StatusOK).JSON(&up)
+	if len(up.Images) != 1 {
+		t.Fatalf("uploaded = %+v", up.Images)
+	}
+	r := s.Client().DoRaw("GET", up.Images[0].URL, nil).Status(http.StatusOK)
+	if string(r.Body) != "not really a jpeg" {
+		t.Fatalf("served %q", r.Body)
+	}
+
+	var got listing
+	s.Client().Do("GET", fmt.Sprintf("/properties/%d", l.ID), nil).Status(http.StatusOK).JSON(&got)
+	if len(got.Images) != 1 || got.Images[0].URL != up.Images[0].URL {
+		t.Fatalf("images = %+v", got.Images)
+	}
+}
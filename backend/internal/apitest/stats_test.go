@@ -0,0 +1,33 @@
+package apitest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/apitest"
+	"gofuckbiz/snimayprosto-rent-easy/internal/seed"
+)
+
+func TestStatsOnSeededData(t *testing.T) {
+	s := apitest.New(t)
+	sum := s.Seed()
+
+	var stats struct {
+		Properties int `json:"properties"`
+		Users      int `json:"users"`
+	}
+	s.Client().Do("GET", "/stats", nil).Status(http.StatusOK).JSON(&stats)
+	if stats.Properties != sum.Properties || stats.Users != sum.Users {
+		t.Fatalf("stats = %+v, seeded %s", stats, sum)
+	}
+
+	// the well-known demo accounts can log in
+	landlord := s.Login(seed.LandlordEmail, seed.Password)
+	var mine struct{ Items []listing }
+	landlord.Do("GET", "/properties/my", nil).Status(http.StatusOK).JSON(&mine)
+	if len(mine.Items) == 0 {
+		t.Fatal("seeded landlord has no listings")
+	}
+	tenant := s.Login(seed.TenantEmail, seed.Password)
+	tenant.Do("GET", "/auth/me", nil).Status(http.StatusOK)
+}
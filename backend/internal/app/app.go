@@ -0,0 +1,144 @@
+// Package app is the dependency container of the HTTP API: it builds the
+// services, handlers and background jobs from config and a database handle,
+// and hands them to the router. cmd/api and the integration tests build it
+// the same way.
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/health"
+	handlers "gofuckbiz/snimayprosto-rent-easy/internal/http/handlers"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/openapi"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/router"
+	"gofuckbiz/snimayprosto-rent-easy/internal/lifecycle"
+	"gofuckbiz/snimayprosto-rent-easy/internal/migrate"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/postgres"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type App struct {
+	Cfg      *config.Config
+	DB       *gorm.DB
+	Services *service.Services
+	Routes   router.Deps
+	Engine   *gin.Engine
+
+	Chat     *handlers.ChatHandler
+	Center   *notify.Center
+	Notifier *notify.Dispatcher
+	Expiry   *notify.ExpiryReminder
+	Jobs     *lifecycle.Manager
+	Health   *health.Checker
+}
+
+// New wires everything up. Background jobs are not running until Start.
+func New(cfg *config.Config, db *gorm.DB) *App {
+	// сервисы поверх репозиториев Postgres
+	repos := postgres.NewRepositories(db)
+	a := &App{
+		Cfg:      cfg,
+		DB:       db,
+		Services: service.New(repos, cfg),
+		Center:   notify.NewCenter(repos.Notifications),
+	}
+	a.Expiry = &notify.ExpiryReminder{Repos: repos, Center: a.Center, Lead: cfg.Notify.ExpiryLead, Interval: cfg.Notify.ExpiryInterval}
+
+	chat := handlers.NewChatHandler(a.Services.Chat, cfg)
+	chat.Notifier = notify.NewDispatcher(repos, cfg, chat)
+	chat.Center = a.Center
+	a.Chat, a.Notifier = chat, chat.Notifier
+
+	a.Health = a.readiness()
+
+	notifications := handlers.NewNotificationsHandler(a.Services.Notifications, cfg)
+	notifications.Center = a.Center
+	properties := handlers.NewPropertiesHandler(a.Services.Properties, cfg)
+	properties.Center = a.Center
+
+	a.Routes = router.Deps{
+		Cfg:           cfg,
+		Services:      a.Services,
+		Auth:          handlers.NewAuthHandler(a.Services.Users, cfg),
+		Properties:    properties,
+		Plans:         handlers.NewPlansHandler(a.Services.Plans, cfg),
+		Chat:          chat,
+		Notifications: notifications,
+		Stats:         handlers.NewStatsHandler(a.Services, cfg),
+		Audit:         handlers.NewAuditHandler(a.Services.Audit, cfg),
+		Analytics:     handlers.NewAnalyticsHandler(a.Services.Analytics, cfg),
+		Health:        handlers.NewHealthHandler(a.Health),
+	}
+	if cfg.OpenAPI.Validate {
+		a.Routes.Contract = openapi.NewValidator(openapi.MustLoad())
+	}
+	a.Engine = router.New(a.Routes)
+	return a
+}
+
+// readiness registers what /readyz checks: the database and its schema,
+// the uploads directory and the background jobs.
+func (a *App) readiness() *health.Checker {
+	c := health.New(a.Cfg.Health.CheckTimeout)
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		c.Add("database", func(context.Context) (map[string]interface{}, error) { return nil, err })
+		return c
+	}
+	c.Add("database", health.Database(sqlDB))
+	if m, err := migrate.New(sqlDB); err != nil {
+		c.Add("migrations", func(context.Context) (map[string]interface{}, error) { return nil, err })
+	} else {
+		c.Add("migrations", health.Migrations(m))
+	}
+	c.Add("storage", health.Writable(a.Cfg.Uploads.Dir))
+	c.Add("workers", health.Workers(func() *lifecycle.Manager { return a.Jobs }))
+	return c
+}
+
+// Start runs the background jobs until ctx is done or Stop is called.
+func (a *App) Start(ctx context.Context) {
+	a.Jobs = lifecycle.New(ctx)
+	a.Jobs.Go("expiry reminder", a.Expiry.Run)
+	a.Jobs.Go("notification digests", a.Notifier.Run)
+}
+
+// Server returns an http.Server for the engine with the configured
+// timeouts. Its Shutdown also ends the connections it cannot drain on its
+// own: chat sockets get a close frame and notification streams end.
+func (a *App) Server(addr string) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           a.Engine,
+		ReadHeaderTimeout: a.Cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       a.Cfg.HTTP.ReadTimeout,
+		WriteTimeout:      a.Cfg.HTTP.WriteTimeout,
+		IdleTimeout:       a.Cfg.HTTP.IdleTimeout,
+	}
+	srv.RegisterOnShutdown(a.Chat.CloseSockets)
+	srv.RegisterOnShutdown(a.Center.Close)
+	return srv
+}
+
+// Stop runs after the server has shut down: it stops the background jobs
+// and then closes the database pool, which they may still be using.
+func (a *App) Stop(ctx context.Context) error {
+	a.Health.ShutDown()
+	var errs []error
+	if a.Jobs != nil {
+		errs = append(errs, a.Jobs.Stop(ctx))
+	}
+	if sqlDB, err := a.DB.DB(); err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, sqlDB.Close())
+	}
+	return errors.Join(errs...)
+}
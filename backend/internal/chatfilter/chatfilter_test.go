@@ -0,0 +1,163 @@
+package chatfilter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPatternDetectors(t *testing.T) {
+	cases := []struct {
+		detector Detector
+		in       string
+		masked   string // "" when the detector must not fire
+	}{
+		{NewPhoneDetector(), "звоните +7 999 123-45-67 вечером", "звоните *** вечером"},
+		{NewPhoneDetector(), "8 (999) 123-45-67", "***"},
+		{NewPhoneDetector(), "8.999.123.45.67 или 89991234567", "*** или ***"},
+		{NewPhoneDetector(), "9 9 9 1 2 3 4 5 6 7", "***"},
+		{NewPhoneDetector(), "цена 45000, залог 2 месяца", ""},
+		{NewPhoneDetector(), "этаж 3/9, 54 м²", ""},
+
+		{NewLinkDetector(), "смотрите https://example.com/flat?id=1", "смотрите ***"},
+		{NewLinkDetector(), "пишите на owner.flat@mail.ru", "пишите на ***"},
+		{NewLinkDetector(), "фото на www.photos.net", "фото на ***"},
+		{NewLinkDetector(), "сайт avito.ru/moskva", "сайт ***"},
+		{NewLinkDetector(), "адрес ул. Ленина, д. 5", ""},
+
+		{NewMessengerDetector(), "мой t.me/owner_flat", "мой ***"},
+		{NewMessengerDetector(), "напишите @flat_owner", "напишите ***"},
+		{NewMessengerDetector(), "лучше в WhatsApp", "лучше в ***"},
+		{NewMessengerDetector(), "есть телега?", "есть ***?"},
+		{NewMessengerDetector(), "добавьте в вотсапе", "добавьте в ***"},
+		{NewMessengerDetector(), "когда можно посмотреть?", ""},
+	}
+	for _, tc := range cases {
+		f := tc.detector.Inspect(Message{Content: tc.in})
+		if tc.masked == "" {
+			if f != nil {
+				t.Errorf("%s(%q) fired on %v", tc.detector.Name(), tc.in, f.Spans)
+			}
+			continue
+		}
+		if f == nil {
+			t.Errorf("%s(%q) did not fire", tc.detector.Name(), tc.in)
+			continue
+		}
+		if got := maskSpans(tc.in, f.Spans); got != tc.masked {
+			t.Errorf("%s(%q) masked to %q, want %q", tc.detector.Name(), tc.in, got, tc.masked)
+		}
+	}
+}
+
+func TestMaskSpans(t *testing.T) {
+	cases := []struct {
+		in    string
+		spans [][2]int
+		want  string
+	}{
+		{"abcdef", nil, "abcdef"},
+		{"abcdef", [][2]int{{4, 6}, {0, 2}}, "***cd***"},
+		{"abcdef", [][2]int{{1, 4}, {2, 5}}, "a***f"}, // overlapping
+		{"abcdef", [][2]int{{1, 5}, {2, 3}}, "a***f"}, // nested
+	}
+	for _, tc := range cases {
+		if got := maskSpans(tc.in, tc.spans); got != tc.want {
+			t.Errorf("maskSpans(%q, %v) = %q, want %q", tc.in, tc.spans, got, tc.want)
+		}
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline(
+		Rule{Detector: NewPhoneDetector(), Action: Mask},
+		Rule{Detector: NewLinkDetector(), Action: Hold},
+		Rule{Detector: NewMessengerDetector(), Action: Allow},
+	)
+	res := p.Run(Message{Content: "+79991234567, telegram"})
+	if res.Action != Mask || res.Content != "***, telegram" || res.Detectors() != "phone" {
+		t.Fatalf("mask: %+v", res)
+	}
+	res = p.Run(Message{Content: "+79991234567 и example.com"})
+	if res.Action != Hold || res.Detectors() != "phone,link" {
+		t.Fatalf("hold wins: %+v", res)
+	}
+
+	// a detector without spans cannot be masked and holds instead
+	spam := NewRateLimiter(1, time.Minute)
+	p = NewPipeline(Rule{Detector: spam, Action: Mask})
+	now := time.Now()
+	p.Run(Message{SenderID: 1, SentAt: now})
+	if res := p.Run(Message{SenderID: 1, SentAt: now}); res.Action != Hold {
+		t.Fatalf("rate limit masked: %+v", res)
+	}
+	if res := (*Pipeline)(nil).Run(Message{Content: "x"}); res.Action != Allow || res.Content != "x" {
+		t.Fatalf("nil pipeline: %+v", res)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	r := NewRateLimiter(2, time.Minute)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	send := func(sender uint, at time.Duration) *Finding {
+		return r.Inspect(Message{SenderID: sender, SentAt: now.Add(at)})
+	}
+
+	if send(1, 0) != nil || send(1, 10*time.Second) != nil {
+		t.Fatal("within the limit")
+	}
+	if send(1, 20*time.Second) == nil {
+		t.Fatal("third message in a minute passed")
+	}
+	if send(2, 20*time.Second) != nil {
+		t.Fatal("another sender was limited")
+	}
+	// the first message leaves the window
+	if send(1, 61*time.Second) != nil {
+		t.Fatal("limited after the window moved")
+	}
+
+	// senders who went quiet are forgotten
+	send(3, 5*time.Minute)
+	if len(r.sent) != 1 {
+		t.Fatalf("remembers %d senders, want 1", len(r.sent))
+	}
+	if (&RateLimiter{}).Inspect(Message{SenderID: 1}) != nil {
+		t.Fatal("zero limit fired")
+	}
+}
+
+func TestDuplicateDetector(t *testing.T) {
+	d := NewDuplicateDetector(3, time.Hour)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	text := "Сдаю квартиру дешевле, пишите мне напрямую"
+	send := func(sender, conv uint, content string, at time.Duration) *Finding {
+		return d.Inspect(Message{SenderID: sender, ConversationID: conv, Content: content, SentAt: now.Add(at)})
+	}
+
+	if send(1, 1, text, 0) != nil || send(1, 2, text, time.Minute) != nil {
+		t.Fatal("two conversations are fine")
+	}
+	if send(1, 2, text, 2*time.Minute) != nil {
+		t.Fatal("the same conversation counted twice")
+	}
+	if send(2, 3, text, 2*time.Minute) != nil {
+		t.Fatal("another sender counted")
+	}
+	// case and spacing do not matter
+	if send(1, 3, "  сдаю КВАРТИРУ дешевле,  пишите мне напрямую", 3*time.Minute) == nil {
+		t.Fatal("third conversation passed")
+	}
+	if send(1, 4, "ok", 3*time.Minute) != nil || send(1, 5, "ok", 3*time.Minute) != nil || send(1, 6, "ok", 3*time.Minute) != nil {
+		t.Fatal("short messages counted")
+	}
+
+	// an hour later the first two conversations no longer count
+	if send(1, 7, text, 62*time.Minute) != nil {
+		t.Fatal("counted conversations from outside the window")
+	}
+	// a sweep forgets the texts nobody repeated
+	send(3, 8, "совсем другой текст для проверки", 3*time.Hour)
+	if len(d.seen) != 1 {
+		t.Fatalf("remembers %d texts, want 1", len(d.seen))
+	}
+}
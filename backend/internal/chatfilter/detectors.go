@@ -0,0 +1,198 @@
+package chatfilter
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// patternDetector flags every match of a regular expression.
+type patternDetector struct {
+	name   string
+	reason string
+	re     *regexp.Regexp
+}
+
+func (d *patternDetector) Name() string { return d.name }
+
+func (d *patternDetector) Inspect(msg Message) *Finding {
+	idx := d.re.FindAllStringIndex(msg.Content, -1)
+	if len(idx) == 0 {
+		return nil
+	}
+	spans := make([][2]int, 0, len(idx))
+	for _, m := range idx {
+		spans = append(spans, [2]int{m[0], m[1]})
+	}
+	return &Finding{Reason: d.reason, Spans: spans}
+}
+
+// NewPhoneDetector matches phone numbers: ten or more digits, optionally
+// starting with + and separated by spaces, dashes, dots or brackets.
+func NewPhoneDetector() Detector {
+	return &patternDetector{
+		name:   "phone",
+		reason: "phone number",
+		re:     regexp.MustCompile(`\+?\d(?:[\s\-().]*\d){9,14}`),
+	}
+}
+
+// NewLinkDetector matches URLs, bare domains and e-mail addresses.
+func NewLinkDetector() Detector {
+	return &patternDetector{
+		name:   "link",
+		reason: "external link",
+		re: regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9\-]+(?:\.[a-z0-9\-]+)*\.[a-z]{2,}` +
+			`|(?:https?://|www\.)\S+` +
+			`|\b[a-z0-9][a-z0-9\-]*(?:\.[a-z0-9\-]+)*\.(?:ru|su|com|net|org|info|biz|io|me|ly|link|site|online|xyz|pro|cc)\b(?:/\S*)?`),
+	}
+}
+
+// NewMessengerDetector matches messenger links, @handles and the names of
+// popular messengers (Latin and Cyrillic spellings).
+func NewMessengerDetector() Detector {
+	return &patternDetector{
+		name:   "messenger",
+		reason: "messenger handle",
+		re: regexp.MustCompile(`(?i)(?:t\.me|wa\.me|telegram\.me|vk\.com|viber://)\S*` +
+			`|@[a-z0-9_]{4,32}\b` +
+			`|\b(?:telegram|whatsapp|viber|signal|tg)\b` +
+			`|телег[а-яё]*|ватсап[а-яё]*|вотсап[а-яё]*|вацап[а-яё]*|вайбер[а-яё]*|телеграм[а-яё]*`),
+	}
+}
+
+// RateLimiter rejects senders that post more than Limit messages in Window.
+type RateLimiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu    sync.Mutex
+	sent  map[uint][]time.Time
+	swept time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{Limit: limit, Window: window, sent: make(map[uint][]time.Time)}
+}
+
+func (r *RateLimiter) Name() string { return "rate_limit" }
+
+func (r *RateLimiter) Inspect(msg Message) *Finding {
+	if r.Limit <= 0 {
+		return nil
+	}
+	now := msg.SentAt
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// forget senders who went quiet so the map does not grow forever
+	if now.Sub(r.swept) > r.Window {
+		for sender := range r.sent {
+			if len(r.recent(sender, now)) == 0 {
+				delete(r.sent, sender)
+			}
+		}
+		r.swept = now
+	}
+
+	recent := r.recent(msg.SenderID, now)
+	if len(recent) >= r.Limit {
+		r.sent[msg.SenderID] = recent
+		return &Finding{Reason: fmt.Sprintf("more than %d messages per %s", r.Limit, r.Window)}
+	}
+	r.sent[msg.SenderID] = append(recent, now)
+	return nil
+}
+
+// recent drops the sender's messages that fell out of the window and
+// returns the rest; callers hold mu.
+func (r *RateLimiter) recent(sender uint, now time.Time) []time.Time {
+	recent := r.sent[sender][:0]
+	for _, t := range r.sent[sender] {
+		if now.Sub(t) < r.Window {
+			recent = append(recent, t)
+		}
+	}
+	r.sent[sender] = recent
+	return recent
+}
+
+// DuplicateDetector flags a sender who posts the same text into Threshold
+// or more different conversations within Window.
+type DuplicateDetector struct {
+	Threshold int
+	Window    time.Duration
+	MinLength int // shorter messages ("hello", "ok") are ignored
+
+	mu    sync.Mutex
+	seen  map[duplicateKey]map[uint]time.Time
+	swept time.Time
+}
+
+type duplicateKey struct {
+	sender uint
+	sum    [sha256.Size]byte
+}
+
+func NewDuplicateDetector(threshold int, window time.Duration) *DuplicateDetector {
+	return &DuplicateDetector{
+		Threshold: threshold,
+		Window:    window,
+		MinLength: 20,
+		seen:      make(map[duplicateKey]map[uint]time.Time),
+	}
+}
+
+func (d *DuplicateDetector) Name() string { return "duplicate" }
+
+func (d *DuplicateDetector) Inspect(msg Message) *Finding {
+	if d.Threshold <= 0 {
+		return nil
+	}
+	text := strings.Join(strings.Fields(strings.ToLower(msg.Content)), " ")
+	if len([]rune(text)) < d.MinLength {
+		return nil
+	}
+	key := duplicateKey{sender: msg.SenderID, sum: sha256.Sum256([]byte(text))}
+	now := msg.SentAt
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if now.Sub(d.swept) > d.Window {
+		d.prune(now)
+		d.swept = now
+	}
+
+	convs := d.seen[key]
+	if convs == nil {
+		convs = make(map[uint]time.Time)
+		d.seen[key] = convs
+	}
+	for conv, t := range convs {
+		if now.Sub(t) >= d.Window {
+			delete(convs, conv)
+		}
+	}
+	convs[msg.ConversationID] = now
+	if len(convs) >= d.Threshold {
+		return &Finding{Reason: fmt.Sprintf("same text sent to %d conversations", len(convs))}
+	}
+	return nil
+}
+
+// prune forgets texts last seen a window ago; callers hold mu.
+func (d *DuplicateDetector) prune(now time.Time) {
+	for key, convs := range d.seen {
+		for conv, t := range convs {
+			if now.Sub(t) >= d.Window {
+				delete(convs, conv)
+			}
+		}
+		if len(convs) == 0 {
+			delete(d.seen, key)
+		}
+	}
+}
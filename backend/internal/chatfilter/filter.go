@@ -0,0 +1,177 @@
+// Package chatfilter inspects chat messages before they are stored and
+// decides whether they go through, get masked, wait for a moderator or are
+// rejected outright.
+package chatfilter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Action is what the pipeline does with a message. Higher values win when
+// several detectors fire.
+type Action int
+
+const (
+	Allow Action = iota
+	Mask
+	Hold
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Mask:
+		return "mask"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	}
+	return "allow"
+}
+
+// ParseAction accepts the names used in configuration.
+func ParseAction(s string) (Action, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "allow", "":
+		return Allow, nil
+	case "mask":
+		return Mask, nil
+	case "hold":
+		return Hold, nil
+	case "reject":
+		return Reject, nil
+	}
+	return Allow, fmt.Errorf("chatfilter: unknown action %q", s)
+}
+
+// MaskText replaces each masked fragment of a message.
+const MaskText = "***"
+
+// Message is the part of a chat message detectors look at.
+type Message struct {
+	SenderID       uint
+	ConversationID uint
+	Content        string
+	SentAt         time.Time
+}
+
+// Finding is reported by a detector that matched. Spans are byte ranges in
+// Content that Mask replaces; detectors without spans (rate limits,
+// duplicates) cannot be masked and escalate to Hold instead.
+type Finding struct {
+	Reason string
+	Spans  [][2]int
+}
+
+// Detector looks for one kind of abuse.
+type Detector interface {
+	Name() string
+	Inspect(msg Message) *Finding
+}
+
+// Rule binds a detector to the action taken when it fires.
+type Rule struct {
+	Detector Detector
+	Action   Action
+}
+
+// Hit records a detector that fired.
+type Hit struct {
+	Detector string `json:"detector"`
+	Reason   string `json:"reason"`
+	Action   string `json:"action"`
+}
+
+// Result is the outcome of running a message through the pipeline.
+type Result struct {
+	Action  Action
+	Content string // possibly masked
+	Hits    []Hit
+}
+
+// Detectors returns the names of the detectors that fired.
+func (r Result) Detectors() string {
+	names := make([]string, 0, len(r.Hits))
+	for _, h := range r.Hits {
+		names = append(names, h.Detector)
+	}
+	return strings.Join(names, ",")
+}
+
+// Reason returns a short human-readable summary of the hits.
+func (r Result) Reason() string {
+	reasons := make([]string, 0, len(r.Hits))
+	for _, h := range r.Hits {
+		reasons = append(reasons, h.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Pipeline runs rules in order. Masking rewrites the content seen by later
+// rules; Reject stops the run.
+type Pipeline struct {
+	rules []Rule
+}
+
+func NewPipeline(rules ...Rule) *Pipeline {
+	return &Pipeline{rules: rules}
+}
+
+func (p *Pipeline) Run(msg Message) Result {
+	res := Result{Action: Allow, Content: msg.Content}
+	if p == nil {
+		return res
+	}
+	for _, rule := range p.rules {
+		if rule.Action == Allow {
+			continue
+		}
+		msg.Content = res.Content
+		f := rule.Detector.Inspect(msg)
+		if f == nil {
+			continue
+		}
+		action := rule.Action
+		if action == Mask {
+			if len(f.Spans) == 0 {
+				action = Hold
+			} else {
+				res.Content = maskSpans(res.Content, f.Spans)
+			}
+		}
+		res.Hits = append(res.Hits, Hit{Detector: rule.Detector.Name(), Reason: f.Reason, Action: action.String()})
+		if action > res.Action {
+			res.Action = action
+		}
+		if res.Action == Reject {
+			break
+		}
+	}
+	return res
+}
+
+func maskSpans(s string, spans [][2]int) string {
+	sorted := append([][2]int(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i][0] < sorted[j][0] })
+
+	var b strings.Builder
+	pos := 0
+	for _, sp := range sorted {
+		if sp[0] < pos {
+			// overlapping match, extend the previous mask
+			if sp[1] > pos {
+				pos = sp[1]
+			}
+			continue
+		}
+		b.WriteString(s[pos:sp[0]])
+		b.WriteString(MaskText)
+		pos = sp[1]
+	}
+	b.WriteString(s[pos:])
+	return b.String()
+}
@@ -1,15 +1,38 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Development secrets. Validate refuses them in production.
+const (
+	DevAccessSecret  = "super_secret_access_key_that_is_long_and_random_1234567890"
+	DevRefreshSecret = "dev_refresh_secret_that_differs_from_the_access_one_0987654321"
+	DevCSRFSecret    = "dev_csrf_secret_used_to_sign_double_submit_tokens_1357924680"
+)
+
 type Config struct {
 	AppEnv   string
 	HTTPPort string
 
+	HTTP struct {
+		ReadHeaderTimeout time.Duration
+		ReadTimeout       time.Duration
+		WriteTimeout      time.Duration // streams (SSE, WebSocket) lift it
+		IdleTimeout       time.Duration
+		ShutdownTimeout   time.Duration // how long SIGTERM waits for requests and jobs
+		DrainDelay        time.Duration // after SIGTERM /readyz fails this long before the server stops accepting
+	}
+
+	Health struct {
+		CheckTimeout time.Duration // per readiness check
+	}
+
 	DB struct {
 		Host    string
 		Port    int
@@ -18,6 +41,11 @@ type Config struct {
 		Name    string
 		SSLMode string
 		DSN     string // optional full DSN override
+
+		MigrateOnStart bool // apply pending migrations at startup instead of refusing to start
+
+		MaxOpenConns int // 0 means unlimited; /readyz reports saturation against it
+		MaxIdleConns int
 	}
 
 	JWT struct {
@@ -30,55 +58,346 @@ type Config struct {
 	Uploads struct {
 		Dir string
 	}
+
+	Log struct {
+		Level     string        // debug, info, warn or error
+		Format    string        // json or text
+		DBLevel   string        // SQL is logged at debug, slow queries at warn, failures at error
+		SlowQuery time.Duration // queries slower than this are logged at warn, 0 disables
+	}
+
+	Metrics struct {
+		Enabled bool
+		Token   string // when set, /metrics wants "Authorization: Bearer <token>"
+	}
+
+	Tracing struct {
+		Exporter    string  // none, stdout or otlp (OTEL_EXPORTER_OTLP_* configure it)
+		SampleRatio float64 // share of new traces recorded
+		ServiceName string
+	}
+
+	OpenAPI struct {
+		Validate bool // check requests and responses against the OpenAPI document
+	}
+
+	CSRF struct {
+		Secret string        // signs the double-submit tokens
+		TTL    time.Duration // lifetime of the token cookie
+	}
+
+	// CORS and WebSocket origin allowlist
+	CORS struct {
+		Origins []string
+	}
+
+	// Response headers; empty values are not sent
+	Security struct {
+		HSTSMaxAge     time.Duration // 0 disables Strict-Transport-Security
+		CSP            string
+		FrameOptions   string
+		ReferrerPolicy string
+	}
+
+	// Requests per client IP and Window, 0 disables
+	RateLimit struct {
+		Requests     int // whole API
+		AuthRequests int // register, login and refresh
+		Window       time.Duration
+
+		// Contact reveals per user and RevealWindow, 0 disables
+		Reveals      int
+		RevealWindow time.Duration
+	}
+
+	Chat struct {
+		EditWindow time.Duration // how long a sender may edit or delete a message
+		PageSize   int           // default page size for message history
+		MaxPage    int           // upper bound for ?limit= on history and sync
+
+		// Filter actions: allow, mask, hold or reject
+		Filter struct {
+			Phones       string
+			Links        string
+			Messengers   string
+			RateLimit    int // messages per RateWindow, 0 disables
+			RateWindow   time.Duration
+			RateAction   string
+			DupThreshold int // identical texts across this many conversations, 0 disables
+			DupWindow    time.Duration
+			DupAction    string
+		}
+	}
+
+	Notify struct {
+		AppURL      string        // base URL of the web app, used in links
+		DigestDelay time.Duration // wait this long to batch messages for an offline user
+		Throttle    time.Duration // at most one digest per user in this interval
+
+		ExpiryLead     time.Duration // remind about plans and promotions ending within this window
+		ExpiryInterval time.Duration // how often the expiry reminder runs
+
+		SMTP struct {
+			Host string // empty logs e-mails instead of sending them
+			Port int
+			User string
+			Pass string
+			From string
+		}
+
+		VAPID struct {
+			PublicKey  string // empty disables Web Push
+			PrivateKey string
+			Subject    string // mailto: or https: contact for push services
+		}
+	}
+
+	settings []Setting
+}
+
+// Each setting is looked up in the environment, then in the config file
+// (CONFIG_FILE), then falls back to its default. Secrets can also be read
+// from a file named by KEY_FILE, e.g. JWT_ACCESS_SECRET_FILE.
+type loader struct {
+	env  func(string) (string, bool) // nil ignores the environment
+	file map[string]string
+
+	settings []Setting
+	errs     []error
 }
 
-func getEnv(key, def string) string {
-	if v, ok := os.LookupEnv(key); ok && v != "" {
-		return v
+func (l *loader) lookup(key string, secret bool) (string, string) {
+	if l.env != nil {
+		if v, ok := l.env(key); ok && v != "" {
+			return v, SourceEnv
+		}
+		if path, ok := l.env(key + "_FILE"); ok && path != "" && secret {
+			return l.readSecret(key, path), SourceEnvFile
+		}
 	}
-	return def
+	if v, ok := l.file[key]; ok && v != "" {
+		return v, SourceFile
+	}
+	if path, ok := l.file[key+"_FILE"]; ok && path != "" && secret {
+		return l.readSecret(key, path), SourceFile
+	}
+	return "", SourceDefault
+}
+
+func (l *loader) readSecret(key, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s_FILE: %w", key, err))
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (l *loader) get(key, def string, secret bool) (string, bool) {
+	v, src := l.lookup(key, secret)
+	if src == SourceDefault {
+		v = def
+	}
+	l.settings = append(l.settings, Setting{Key: key, Value: v, Source: src, Secret: secret})
+	return v, src != SourceDefault
+}
+
+// invalid reports a value that does not parse; the default is used.
+func (l *loader) invalid(key, v string, err error) {
+	l.errs = append(l.errs, fmt.Errorf("%s=%q: %w", key, v, err))
 }
 
-func getEnvInt(key string, def int) int {
-	if v, ok := os.LookupEnv(key); ok && v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
+func (l *loader) getEnv(key, def string) string {
+	v, _ := l.get(key, def, false)
+	return v
+}
+
+func (l *loader) getSecret(key, def string) string {
+	v, _ := l.get(key, def, true)
+	return v
+}
+
+func (l *loader) getEnvInt(key string, def int) int {
+	if v, ok := l.get(key, strconv.Itoa(def), false); ok {
+		n, err := strconv.Atoi(v)
+		if err == nil {
 			return n
 		}
+		l.invalid(key, v, err)
+	}
+	return def
+}
+
+func (l *loader) getEnvBool(key string, def bool) bool {
+	if v, ok := l.get(key, strconv.FormatBool(def), false); ok {
+		b, err := strconv.ParseBool(v)
+		if err == nil {
+			return b
+		}
+		l.invalid(key, v, err)
+	}
+	return def
+}
+
+func (l *loader) getEnvFloat(key string, def float64) float64 {
+	if v, ok := l.get(key, strconv.FormatFloat(def, 'g', -1, 64), false); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			return f
+		}
+		l.invalid(key, v, err)
 	}
 	return def
 }
 
-func getEnvDuration(key string, def time.Duration) time.Duration {
-	if v, ok := os.LookupEnv(key); ok && v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
+func (l *loader) getEnvDuration(key string, def time.Duration) time.Duration {
+	if v, ok := l.get(key, def.String(), false); ok {
+		d, err := time.ParseDuration(v)
+		if err == nil {
 			return d
 		}
+		l.invalid(key, v, err)
 	}
 	return def
 }
 
-func Load() *Config {
+// getEnvList reads a comma-separated list.
+func (l *loader) getEnvList(key string, def []string) []string {
+	v, ok := l.get(key, strings.Join(def, ","), false)
+	if !ok {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Load reads the config from the environment and the optional file named
+// by CONFIG_FILE. Values that cannot be read or parsed are errors; use
+// Validate to check the result makes sense.
+func Load() (*Config, error) {
+	l := &loader{env: os.LookupEnv}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		l.file = file
+	}
+	c := l.build()
+	return c, errors.Join(l.errs...)
+}
+
+// Defaults is the config with every setting at its default, ignoring the
+// environment. Tests start from it.
+func Defaults() *Config {
+	return (&loader{}).build()
+}
+
+func (l *loader) build() *Config {
 	c := &Config{}
 
-	c.AppEnv = getEnv("APP_ENV", "dev")
-	c.HTTPPort = getEnv("HTTP_PORT", "8080")
+	c.AppEnv = l.getEnv("APP_ENV", "dev")
+	c.HTTPPort = l.getEnv("HTTP_PORT", "8080")
+	c.HTTP.ReadHeaderTimeout = l.getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second)
+	c.HTTP.ReadTimeout = l.getEnvDuration("HTTP_READ_TIMEOUT", 30*time.Second) // image uploads
+	c.HTTP.WriteTimeout = l.getEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second)
+	c.HTTP.IdleTimeout = l.getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute)
+	c.HTTP.ShutdownTimeout = l.getEnvDuration("HTTP_SHUTDOWN_TIMEOUT", 20*time.Second)
+	var drain time.Duration
+	if c.AppEnv == "prod" || c.AppEnv == "production" {
+		drain = 5 * time.Second // a couple of readiness probe periods
+	}
+	c.HTTP.DrainDelay = l.getEnvDuration("HTTP_DRAIN_DELAY", drain)
 
-	c.DB.Host = getEnv("DB_HOST", "127.0.0.1")
-	c.DB.Port = getEnvInt("DB_PORT", 5432)
-	c.DB.User = getEnv("DB_USER", "postgres")
-	c.DB.Pass = getEnv("DB_PASS", "postgres")
-	c.DB.Name = getEnv("DB_NAME", "rent")
-	c.DB.SSLMode = getEnv("DB_SSLMODE", "disable")
-	c.DB.DSN = getEnv("DB_DSN", "")
+	c.Health.CheckTimeout = l.getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second)
 
-	c.JWT.AccessSecret = getEnv("JWT_ACCESS_SECRET", "super_secret_access_key_that_is_long_and_random_1234567890")
-	c.JWT.RefreshSecret = getEnv("JWT_REFRESH_SECRET", "super_secret_access_key_that_is_long_and_random_1234567890")
-	c.JWT.AccessTTL = getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute)  // Short-lived access tokens
-	c.JWT.RefreshTTL = getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour) // 7 days refresh tokens
+	c.DB.Host = l.getEnv("DB_HOST", "127.0.0.1")
+	c.DB.Port = l.getEnvInt("DB_PORT", 5432)
+	c.DB.User = l.getEnv("DB_USER", "postgres")
+	c.DB.Pass = l.getSecret("DB_PASS", "postgres")
+	c.DB.Name = l.getEnv("DB_NAME", "rent")
+	c.DB.SSLMode = l.getEnv("DB_SSLMODE", "disable")
+	c.DB.DSN = l.getSecret("DB_DSN", "")
+	c.DB.MigrateOnStart = l.getEnvBool("DB_MIGRATE_ON_START", c.AppEnv == "dev")
+	c.DB.MaxOpenConns = l.getEnvInt("DB_MAX_OPEN_CONNS", 25)
+	c.DB.MaxIdleConns = l.getEnvInt("DB_MAX_IDLE_CONNS", 10)
 
-	c.Uploads.Dir = getEnv("UPLOADS_DIR", "uploads")
+	c.JWT.AccessSecret = l.getSecret("JWT_ACCESS_SECRET", DevAccessSecret)
+	c.JWT.RefreshSecret = l.getSecret("JWT_REFRESH_SECRET", DevRefreshSecret)
+	c.JWT.AccessTTL = l.getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute)   // Short-lived access tokens
+	c.JWT.RefreshTTL = l.getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour) // 7 days refresh tokens
 
-	return c
-}
+	c.Uploads.Dir = l.getEnv("UPLOADS_DIR", "uploads")
+
+	c.Log.Level = l.getEnv("LOG_LEVEL", "info")
+	c.Log.Format = l.getEnv("LOG_FORMAT", "json")
+	c.Log.DBLevel = l.getEnv("LOG_DB_LEVEL", "warn")
+	c.Log.SlowQuery = l.getEnvDuration("LOG_DB_SLOW", 200*time.Millisecond)
+
+	c.Metrics.Enabled = l.getEnvBool("METRICS_ENABLED", true)
+	c.Metrics.Token = l.getSecret("METRICS_TOKEN", "")
 
+	c.Tracing.Exporter = l.getEnv("TRACING_EXPORTER", "none")
+	c.Tracing.SampleRatio = l.getEnvFloat("TRACING_SAMPLE_RATIO", 1)
+	c.Tracing.ServiceName = l.getEnv("TRACING_SERVICE_NAME", "snimayprosto-api")
 
+	c.OpenAPI.Validate = l.getEnvBool("OPENAPI_VALIDATE", c.AppEnv != "prod" && c.AppEnv != "production")
+
+	c.CSRF.Secret = l.getSecret("CSRF_SECRET", DevCSRFSecret)
+	c.CSRF.TTL = l.getEnvDuration("CSRF_TTL", 24*time.Hour)
+
+	c.CORS.Origins = l.getEnvList("CORS_ORIGINS", []string{
+		"http://localhost:5173", "http://127.0.0.1:5173", "http://localhost:8081", "http://127.0.0.1:8081",
+	})
+
+	var hsts time.Duration
+	if c.AppEnv == "prod" || c.AppEnv == "production" {
+		hsts = 180 * 24 * time.Hour
+	}
+	c.Security.HSTSMaxAge = l.getEnvDuration("SECURITY_HSTS_MAX_AGE", hsts)
+	c.Security.CSP = l.getEnv("SECURITY_CSP", "default-src 'none'; frame-ancestors 'none'") // the API serves no pages
+	c.Security.FrameOptions = l.getEnv("SECURITY_FRAME_OPTIONS", "DENY")
+	c.Security.ReferrerPolicy = l.getEnv("SECURITY_REFERRER_POLICY", "strict-origin-when-cross-origin")
+
+	c.RateLimit.Requests = l.getEnvInt("RATE_LIMIT_REQUESTS", 600)
+	c.RateLimit.AuthRequests = l.getEnvInt("RATE_LIMIT_AUTH_REQUESTS", 30)
+	c.RateLimit.Window = l.getEnvDuration("RATE_LIMIT_WINDOW", time.Minute)
+	c.RateLimit.Reveals = l.getEnvInt("RATE_LIMIT_REVEALS", 30)
+	c.RateLimit.RevealWindow = l.getEnvDuration("RATE_LIMIT_REVEAL_WINDOW", time.Hour)
+
+	c.Chat.EditWindow = l.getEnvDuration("CHAT_EDIT_WINDOW", 15*time.Minute)
+	c.Chat.PageSize = l.getEnvInt("CHAT_PAGE_SIZE", 50)
+	c.Chat.MaxPage = l.getEnvInt("CHAT_MAX_PAGE", 200)
+
+	c.Chat.Filter.Phones = l.getEnv("CHAT_FILTER_PHONES", "mask")
+	c.Chat.Filter.Links = l.getEnv("CHAT_FILTER_LINKS", "mask")
+	c.Chat.Filter.Messengers = l.getEnv("CHAT_FILTER_MESSENGERS", "mask")
+	c.Chat.Filter.RateLimit = l.getEnvInt("CHAT_FILTER_RATE_LIMIT", 20)
+	c.Chat.Filter.RateWindow = l.getEnvDuration("CHAT_FILTER_RATE_WINDOW", time.Minute)
+	c.Chat.Filter.RateAction = l.getEnv("CHAT_FILTER_RATE_ACTION", "reject")
+	c.Chat.Filter.DupThreshold = l.getEnvInt("CHAT_FILTER_DUP_THRESHOLD", 5)
+	c.Chat.Filter.DupWindow = l.getEnvDuration("CHAT_FILTER_DUP_WINDOW", time.Hour)
+	c.Chat.Filter.DupAction = l.getEnv("CHAT_FILTER_DUP_ACTION", "hold")
+
+	c.Notify.AppURL = l.getEnv("APP_URL", "http://localhost:5173")
+	c.Notify.DigestDelay = l.getEnvDuration("NOTIFY_DIGEST_DELAY", 5*time.Minute)
+	c.Notify.Throttle = l.getEnvDuration("NOTIFY_THROTTLE", 30*time.Minute)
+	c.Notify.ExpiryLead = l.getEnvDuration("NOTIFY_EXPIRY_LEAD", 24*time.Hour)
+	c.Notify.ExpiryInterval = l.getEnvDuration("NOTIFY_EXPIRY_INTERVAL", time.Hour)
+	c.Notify.SMTP.Host = l.getEnv("SMTP_HOST", "")
+	c.Notify.SMTP.Port = l.getEnvInt("SMTP_PORT", 587)
+	c.Notify.SMTP.User = l.getEnv("SMTP_USER", "")
+	c.Notify.SMTP.Pass = l.getSecret("SMTP_PASS", "")
+	c.Notify.SMTP.From = l.getEnv("SMTP_FROM", "no-reply@snimayprosto.ru")
+	c.Notify.VAPID.PublicKey = l.getEnv("VAPID_PUBLIC_KEY", "")
+	c.Notify.VAPID.PrivateKey = l.getSecret("VAPID_PRIVATE_KEY", "")
+	c.Notify.VAPID.Subject = l.getEnv("VAPID_SUBJECT", "mailto:support@snimayprosto.ru")
+
+	c.settings = l.settings
+	return c
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func setting(c *Config, key string) Setting {
+	for _, s := range c.Settings() {
+		if s.Key == key {
+			return s
+		}
+	}
+	return Setting{}
+}
+
+func TestLayering(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "http_port: 9000\njwt:\n  access_ttl: 30m\nchat:\n  filter:\n    phones: hold\n",
+		"config.toml": "http_port = \"9000\"\n[jwt]\naccess_ttl = \"30m\"\n[chat.filter]\nphones = \"hold\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", writeFile(t, name, content))
+			t.Setenv("HTTP_PORT", "") // empty counts as unset
+			t.Setenv("CHAT_FILTER_PHONES", "reject")
+
+			c, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.HTTPPort != "9000" || c.JWT.AccessTTL != 30*time.Minute || c.Chat.Filter.Phones != "reject" {
+				t.Fatalf("port %s, ttl %s, phones %s", c.HTTPPort, c.JWT.AccessTTL, c.Chat.Filter.Phones)
+			}
+			if c.DB.Host != "127.0.0.1" {
+				t.Fatalf("unset key: %s", c.DB.Host)
+			}
+			for key, src := range map[string]string{"HTTP_PORT": SourceFile, "CHAT_FILTER_PHONES": SourceEnv, "DB_HOST": SourceDefault} {
+				if got := setting(c, key).Source; got != src {
+					t.Errorf("%s from %s, want %s", key, got, src)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretFiles(t *testing.T) {
+	secret := strings.Repeat("s", 40)
+	t.Setenv("JWT_ACCESS_SECRET_FILE", writeFile(t, "access", secret+"\n"))
+	t.Setenv("DB_HOST_FILE", writeFile(t, "host", "db.internal")) // not a secret, ignored
+
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.JWT.AccessSecret != secret || c.DB.Host != "127.0.0.1" {
+		t.Fatalf("access secret %q, db host %q", c.JWT.AccessSecret, c.DB.Host)
+	}
+	s := setting(c, "JWT_ACCESS_SECRET")
+	if s.Source != SourceEnvFile || s.Display() != "<redacted>" {
+		t.Fatalf("setting %+v displays %q", s, s.Display())
+	}
+	if d := setting(c, "JWT_REFRESH_SECRET").Display(); d != "<redacted: development default>" {
+		t.Fatalf("default secret displays %q", d)
+	}
+
+	t.Setenv("JWT_ACCESS_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "JWT_ACCESS_SECRET_FILE") {
+		t.Fatalf("missing secret file: %v", err)
+	}
+}
+
+func TestInvalidValues(t *testing.T) {
+	t.Setenv("DB_PORT", "five")
+	t.Setenv("JWT_ACCESS_TTL", "soon")
+	c, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "DB_PORT") || !strings.Contains(err.Error(), "JWT_ACCESS_TTL") {
+		t.Fatalf("Load = %v", err)
+	}
+	if c.DB.Port != 5432 {
+		t.Fatalf("port %d, want the default", c.DB.Port)
+	}
+
+	t.Setenv("DB_PORT", "")
+	t.Setenv("JWT_ACCESS_TTL", "")
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.json", "{}"))
+	if _, err := Load(); err == nil {
+		t.Fatal("unknown config file format accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Defaults()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("defaults outside production: %v", err)
+	}
+
+	c.Notify.ExpiryInterval = 0
+	c.Notify.Throttle = -time.Minute
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "NOTIFY_EXPIRY_INTERVAL must be positive") || !strings.Contains(err.Error(), "NOTIFY_THROTTLE") {
+		t.Fatalf("zero notify intervals: %v", err)
+	}
+	c.Notify.ExpiryInterval, c.Notify.Throttle = time.Hour, time.Minute
+
+	c.AppEnv = "production"
+	err = c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "JWT_ACCESS_SECRET is a development default") {
+		t.Fatalf("production with default secrets: %v", err)
+	}
+
+	c.JWT.AccessSecret = strings.Repeat("a", 40)
+	c.JWT.RefreshSecret = c.JWT.AccessSecret
+	c.DB.Pass = "strong"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "must differ") {
+		t.Fatalf("identical secrets: %v", err)
+	}
+
+	c.JWT.RefreshSecret = "short"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "shorter") {
+		t.Fatalf("short secret: %v", err)
+	}
+
+	c.JWT.RefreshSecret = strings.Repeat("r", 40)
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "CSRF_SECRET") {
+		t.Fatalf("default CSRF secret: %v", err)
+	}
+
+	c.CSRF.Secret = strings.Repeat("c", 40)
+	if err := c.Validate(); err != nil {
+		t.Fatalf("good production config: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile parses a YAML (.yaml, .yml) or TOML (.toml) config file into
+// settings keyed like the environment variables. Nesting joins keys with
+// an underscore, so
+//
+//	jwt:
+//	  access_ttl: 30m
+//
+// sets JWT_ACCESS_TTL. Lists are joined with commas.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &tree)
+	case ".toml":
+		err = toml.Unmarshal(b, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unknown format %q (want .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	out := make(map[string]string)
+	if err := flatten(out, "", tree); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return out, nil
+}
+
+func flatten(out map[string]string, prefix string, tree map[string]interface{}) error {
+	for k, v := range tree {
+		key := strings.ToUpper(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(out, key, v); err != nil {
+				return err
+			}
+		case []interface{}: // lists become comma-separated
+			items := make([]string, len(v))
+			for i, item := range v {
+				if _, nested := item.(map[string]interface{}); nested {
+					return fmt.Errorf("%s: lists of tables are not supported", key)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+		default:
+			if _, dup := out[key]; dup {
+				return fmt.Errorf("%s is set twice", key)
+			}
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
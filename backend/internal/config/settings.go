@@ -0,0 +1,37 @@
+package config
+
+// Where a setting came from.
+const (
+	SourceDefault = "default"
+	SourceEnv     = "env"
+	SourceEnvFile = "env file" // KEY_FILE in the environment
+	SourceFile    = "config file"
+)
+
+// Setting is one resolved config value.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// Display is the value safe to print: secrets are redacted, but it shows
+// whether a secret is empty or still the development default.
+func (s Setting) Display() string {
+	switch {
+	case !s.Secret:
+		return s.Value
+	case s.Value == "":
+		return ""
+	case s.Value == DevAccessSecret || s.Value == DevRefreshSecret || s.Value == DevCSRFSecret:
+		return "<redacted: development default>"
+	}
+	return "<redacted>"
+}
+
+// Settings lists every setting in load order. Configs not made by Load or
+// Defaults have none.
+func (c *Config) Settings() []Setting {
+	return c.settings
+}
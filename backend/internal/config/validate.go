@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// minSecretLen is the shortest JWT secret accepted in production (256 bits).
+const minSecretLen = 32
+
+// Production reports whether the config is for a production deployment.
+func (c *Config) Production() bool {
+	return c.AppEnv == "prod" || c.AppEnv == "production"
+}
+
+// Validate checks the settings make sense together. In production it also
+// refuses development or weak secrets.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.JWT.AccessSecret != "", "JWT_ACCESS_SECRET is empty")
+	check(c.JWT.RefreshSecret != "", "JWT_REFRESH_SECRET is empty")
+	// a refresh token must never pass as an access token
+	check(c.JWT.AccessSecret != c.JWT.RefreshSecret, "JWT_ACCESS_SECRET and JWT_REFRESH_SECRET must differ")
+	check(c.JWT.AccessTTL > 0 && c.JWT.RefreshTTL > c.JWT.AccessTTL, "JWT_REFRESH_TTL must be longer than JWT_ACCESS_TTL")
+	check(c.CSRF.Secret != "", "CSRF_SECRET is empty")
+	check(c.CSRF.TTL > 0, "CSRF_TTL must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "HTTP_SHUTDOWN_TIMEOUT must be positive")
+	check(c.HTTP.DrainDelay >= 0, "HTTP_DRAIN_DELAY must not be negative")
+	check(c.Health.CheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive")
+	check(c.DB.MaxOpenConns >= 0 && c.DB.MaxIdleConns >= 0, "DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative")
+	check(c.RateLimit.Window > 0 || (c.RateLimit.Requests <= 0 && c.RateLimit.AuthRequests <= 0), "RATE_LIMIT_WINDOW must be positive")
+	check(c.RateLimit.RevealWindow > 0 || c.RateLimit.Reveals <= 0, "RATE_LIMIT_REVEAL_WINDOW must be positive")
+	check(len(c.CORS.Origins) > 0, "CORS_ORIGINS is empty")
+	for _, o := range c.CORS.Origins {
+		// credentials are allowed, so a wildcard would let any site act as the user
+		check(o != "*", "CORS_ORIGINS must list origins, not *")
+	}
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "LOG_LEVEL must be debug, info, warn or error")
+	check(level.UnmarshalText([]byte(c.Log.DBLevel)) == nil, "LOG_DB_LEVEL must be debug, info, warn or error")
+	check(c.Log.Format == "json" || c.Log.Format == "text", "LOG_FORMAT must be json or text")
+	check(c.Tracing.Exporter == "none" || c.Tracing.Exporter == "stdout" || c.Tracing.Exporter == "otlp",
+		"TRACING_EXPORTER must be none, stdout or otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	check(c.Chat.PageSize > 0 && c.Chat.MaxPage >= c.Chat.PageSize, "CHAT_MAX_PAGE must be at least CHAT_PAGE_SIZE")
+	check(c.Notify.DigestDelay > 0, "NOTIFY_DIGEST_DELAY must be positive")
+	check(c.Notify.Throttle > 0, "NOTIFY_THROTTLE must be positive")
+	check(c.Notify.ExpiryLead > 0, "NOTIFY_EXPIRY_LEAD must be positive")
+	check(c.Notify.ExpiryInterval > 0, "NOTIFY_EXPIRY_INTERVAL must be positive")
+
+	if c.Production() {
+		check(c.JWT.AccessSecret != DevAccessSecret && c.JWT.AccessSecret != DevRefreshSecret,
+			"JWT_ACCESS_SECRET is a development default")
+		check(c.JWT.RefreshSecret != DevAccessSecret && c.JWT.RefreshSecret != DevRefreshSecret,
+			"JWT_REFRESH_SECRET is a development default")
+		check(len(c.JWT.AccessSecret) >= minSecretLen, "JWT_ACCESS_SECRET is shorter than %d bytes", minSecretLen)
+		check(len(c.JWT.RefreshSecret) >= minSecretLen, "JWT_REFRESH_SECRET is shorter than %d bytes", minSecretLen)
+		check(c.CSRF.Secret != DevCSRFSecret, "CSRF_SECRET is a development default")
+		check(len(c.CSRF.Secret) >= minSecretLen, "CSRF_SECRET is shorter than %d bytes", minSecretLen)
+		check(c.DB.DSN != "" || c.DB.Pass != "postgres", "DB_PASS is the development default")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditLoginSuccess  = "login.success"
+	AuditLoginFailure  = "login.failure"
+	AuditRoleChange    = "user.role_changed"
+	AuditPlanChange    = "plan.changed"
+	AuditListingDelete = "listing.deleted"
+	AuditListingReview = "listing.reviewed"
+)
+
+// AuditEvent is an entry of the security audit log. Rows are only ever
+// inserted.
+type AuditEvent struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	ActorID    *uint        `gorm:"index" json:"actorId,omitempty"` // nil when nobody is signed in, e.g. a failed login
+	Action     string       `gorm:"type:varchar(40);index;not null" json:"action"`
+	TargetType string       `gorm:"type:varchar(20)" json:"targetType,omitempty"` // user, plan, property
+	TargetID   *uint        `json:"targetId,omitempty"`
+	Details    AuditDetails `gorm:"type:jsonb" json:"details,omitempty"`
+	IP         string       `json:"ip"`
+	UserAgent  string       `json:"userAgent"`
+	RequestID  string       `json:"requestId"`
+	CreatedAt  time.Time    `gorm:"index" json:"createdAt"`
+}
+
+// AuditDetails are what changed, e.g. the old and the new role. Stored as
+// a JSON object.
+type AuditDetails map[string]string
+
+func (d AuditDetails) Value() (driver.Value, error) {
+	if d == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+func (d *AuditDetails) Scan(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	}
+	return fmt.Errorf("core: cannot scan %T into AuditDetails", v)
+}
@@ -24,15 +24,110 @@ type Property struct {
 	Lng          float64   `json:"lng"`
 	Rooms        int       `json:"rooms"`
 	Area         int       `json:"area"`
-	Amenities    string    `json:"amenities"` // simple CSV for minimal start
 	PropertyType string    `json:"propertyType"`
-	ContactPhone string    `json:"phone"`
-	ContactEmail string    `json:"email"`
+	ContactPhone string    `json:"phone,omitempty"`
+	ContactEmail string    `json:"email,omitempty"`
+	// Who may see the contacts: public, on_request or hidden.
+	PhoneVisibility string `gorm:"type:varchar(20);default:on_request" json:"phoneVisibility"`
+	EmailVisibility string `gorm:"type:varchar(20);default:on_request" json:"emailVisibility"`
 	IsUrgent     bool      `json:"isUrgent"`
 	Visibility   string    `json:"visibility"`
 	CreatedAt    time.Time `json:"createdAt"`
 
-	Images []PropertyImage `json:"images"`
+	// Listings are published right away; a moderator may reject one later.
+	ModerationStatus string `gorm:"type:varchar(20);default:approved" json:"moderationStatus"`
+	ModerationReason string `json:"moderationReason,omitempty"`
+
+	// Typed attributes; nil means the landlord did not say.
+	Floor             *int     `json:"floor"`
+	TotalFloors       *int     `json:"totalFloors"`
+	Furnished         *bool    `json:"furnished"`
+	PetsAllowed       *bool    `json:"petsAllowed"`
+	Balcony           *bool    `json:"balcony"`
+	Parking           *bool    `json:"parking"`
+	Deposit           *float64 `json:"deposit"` // roubles
+	UtilitiesIncluded *bool    `json:"utilitiesIncluded"`
+
+	// Amenities are codes of the amenity catalog, kept in property_amenities.
+	Amenities []string        `gorm:"-" json:"amenities"`
+	Images    []PropertyImage `json:"images"`
+	// IsHighlighted is set while an urgent highlight runs.
+	IsHighlighted bool `gorm:"-" json:"isHighlighted"`
+}
+
+// Amenity is an entry of the amenity catalog listings pick from.
+type Amenity struct {
+	ID      uint   `gorm:"primaryKey" json:"-"`
+	Code    string `gorm:"uniqueIndex;not null" json:"code"`
+	LabelRU string `gorm:"column:label_ru" json:"labelRu"`
+	LabelEN string `gorm:"column:label_en" json:"labelEn"`
+	Icon    string `json:"icon"` // lucide icon name
+	Order   int    `json:"order"`
+}
+
+// Label returns the label in lang, "ru" or "en".
+func (a Amenity) Label(lang string) string {
+	if lang == "en" {
+		return a.LabelEN
+	}
+	return a.LabelRU
+}
+
+// PropertyAmenity links a property to an amenity of the catalog.
+type PropertyAmenity struct {
+	PropertyID uint `gorm:"primaryKey"`
+	AmenityID  uint `gorm:"primaryKey;index"`
+}
+
+// Listing types, price periods and visibilities
+const (
+	PropertyApartment = "apartment"
+	PropertyRoom      = "room"
+	PropertyHouse     = "house"
+	PropertyStudio    = "studio"
+
+	PricePerMonth = "month"
+	PricePerDay   = "day"
+
+	VisibilityPublic     = "public"
+	VisibilityRegistered = "registered" // signed-in users only
+)
+
+// Contact visibilities: shown with the listing, shown to signed-in users
+// who ask for it, or never shown (the chat still works).
+const (
+	ContactPublic    = "public"
+	ContactOnRequest = "on_request"
+	ContactHidden    = "hidden"
+)
+
+// Moderation statuses of a listing. Rejected listings leave the public
+// feed; their owners still see them.
+const (
+	ListingApproved = "approved"
+	ListingRejected = "rejected"
+)
+
+// Redacted returns a copy safe to send to anyone: contacts that are not
+// public are left out.
+func (p Property) Redacted() Property {
+	if p.PhoneVisibility != ContactPublic {
+		p.ContactPhone = ""
+	}
+	if p.EmailVisibility != ContactPublic {
+		p.ContactEmail = ""
+	}
+	return p
+}
+
+// ContactReveal records a user asking for a listing's contacts.
+type ContactReveal struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PropertyID uint      `gorm:"index;not null" json:"propertyId"`
+	ViewerID   uint      `gorm:"not null" json:"viewerId"`
+	Fields     string    `json:"fields"` // comma separated: phone, email
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
 }
 
 type PropertyImage struct {
@@ -59,6 +154,14 @@ type Conversation struct {
 	LastMessageAt *time.Time `json:"lastMessageAt"`
 }
 
+// Peer returns the other side of the conversation.
+func (c Conversation) Peer(userID uint) uint {
+	if c.RecipientID == userID {
+		return c.InitiatorID
+	}
+	return c.RecipientID
+}
+
 // Message in a conversation
 type Message struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
@@ -68,7 +171,20 @@ type Message struct {
 	Content        string    `gorm:"type:text" json:"content"`
 	AttachmentURL  string    `json:"attachmentUrl"`
 	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `gorm:"index" json:"updatedAt"`
 	ReadAt         *time.Time `json:"readAt"`
+	EditedAt       *time.Time `json:"editedAt"`
+	DeletedAt      *time.Time `json:"deletedAt"` // soft delete, content is hidden from clients
+}
+
+// Redacted returns a copy safe to send to clients: deleted messages keep
+// their metadata but lose the content.
+func (m Message) Redacted() Message {
+	if m.DeletedAt != nil {
+		m.Content = ""
+		m.AttachmentURL = ""
+	}
+	return m
 }
 
 // UserPlan represents a user's subscription plan
@@ -82,13 +198,122 @@ type UserPlan struct {
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
-// PropertyPromotion represents a promoted listing
+// PropertyPromotion is a promotion product bought for a listing. It runs
+// from StartsAt to ExpiresAt; a listing keeps all its past and scheduled
+// promotions.
 type PropertyPromotion struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
-	PropertyID uint      `gorm:"uniqueIndex;not null" json:"propertyId"`
+	PropertyID uint      `gorm:"index;not null" json:"propertyId"`
 	UserID     uint      `gorm:"index;not null" json:"userId"`
+	Product    string    `gorm:"type:varchar(40);not null" json:"product"`
+	Tier       string    `gorm:"type:varchar(20);not null;default:top_city" json:"tier"`
+	Credits    int       `gorm:"not null;default:0" json:"credits"` // plan credits it cost
+	StartsAt   time.Time `gorm:"not null" json:"startsAt"`
 	ExpiresAt  time.Time `gorm:"not null" json:"expiresAt"`
 	CreatedAt  time.Time `json:"createdAt"`
 }
 
+// Promotion tiers
+const (
+	PromotionTopCity = "top_city" // first in the feed
+	PromotionUrgent  = "urgent"   // highlighted in the feed
+)
+
+// Running reports whether p runs at now.
+func (p PropertyPromotion) Running(now time.Time) bool {
+	return !p.StartsAt.After(now) && p.ExpiresAt.After(now)
+}
+
+// ListingStat is one day of a listing's analytics. Day is a UTC date.
+type ListingStat struct {
+	PropertyID    uint      `gorm:"primaryKey" json:"propertyId"`
+	Day           time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Views         int64     `gorm:"not null;default:0" json:"views"`
+	Reveals       int64     `gorm:"not null;default:0" json:"reveals"`
+	Favorites     int64     `gorm:"not null;default:0" json:"favorites"`
+	Conversations int64     `gorm:"not null;default:0" json:"conversations"`
+}
+
+// Listing metrics, the counters of a ListingStat
+const (
+	MetricViews         = "views"
+	MetricReveals       = "reveals"
+	MetricFavorites     = "favorites"
+	MetricConversations = "conversations"
+)
+
+// ListingView marks a visitor as counted for a listing on a day, so that
+// each visitor adds at most one view per day.
+type ListingView struct {
+	PropertyID uint      `gorm:"primaryKey"`
+	Day        time.Time `gorm:"primaryKey;type:date"`
+	Viewer     string    `gorm:"primaryKey;type:varchar(80)"`
+}
+
+// FlaggedMessage is a chat message stopped by the chat filter (held for
+// review or rejected). Content is the original, unmasked text.
+type FlaggedMessage struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	ConversationID uint       `gorm:"index;not null" json:"conversationId"`
+	SenderID       uint       `gorm:"index;not null" json:"senderId"`
+	Content        string     `gorm:"type:text" json:"content"`
+	Action         string     `gorm:"type:varchar(20)" json:"action"` // hold, reject
+	Detectors      string     `json:"detectors"`                      // comma separated detector names
+	Reason         string     `json:"reason"`
+	Status         string     `gorm:"type:varchar(20);index;default:pending" json:"status"` // pending, released, dismissed, rejected
+	ReviewerID     *uint      `json:"reviewerId,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewedAt,omitempty"`
+	MessageID      *uint      `json:"messageId,omitempty"` // set once a held message is released
+	EditOf         *uint      `json:"editOf,omitempty"`    // the message a held edit changes
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// UserBlock means BlockerID no longer wants to hear from BlockedID in chat
+type UserBlock struct {
+	BlockerID uint      `gorm:"primaryKey" json:"blockerId"`
+	BlockedID uint      `gorm:"primaryKey;index" json:"blockedId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NotificationPreference holds per-channel opt-outs. Users without a row
+// get every channel enabled.
+type NotificationPreference struct {
+	UserID       uint      `gorm:"primaryKey" json:"userId"`
+	EmailEnabled bool      `json:"emailEnabled"`
+	PushEnabled  bool      `json:"pushEnabled"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// PushSubscription is a browser Web Push endpoint registered by a user
+type PushSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"userId"`
+	Endpoint  string    `gorm:"uniqueIndex;not null" json:"endpoint"`
+	P256dh    string    `json:"p256dh"`
+	Auth      string    `json:"auth"`
+	CreatedAt time.Time `json:"createdAt"`
+}
 
+// Notification kinds shown in the in-app notification center
+const (
+	NotificationNewMessage        = "message.new"
+	NotificationListingApproved   = "listing.approved"
+	NotificationListingRejected   = "listing.rejected"
+	NotificationPromotionExpiring = "promotion.expiring"
+	NotificationPlanExpiring      = "plan.expiring"
+	NotificationSavedSearchMatch  = "search.match"
+)
+
+// Notification is an entry in a user's in-app notification center
+type Notification struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"index:idx_notifications_user_created;uniqueIndex:idx_notifications_dedup;not null" json:"userId"`
+	Type   string `gorm:"type:varchar(40);not null" json:"type"`
+	Title  string `json:"title"`
+	Body   string `gorm:"type:text" json:"body"`
+	Link   string `json:"link,omitempty"` // path in the web app
+	// DedupKey makes emitting idempotent for background jobs; nil means no dedup
+	DedupKey  *string    `gorm:"uniqueIndex:idx_notifications_dedup" json:"-"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `gorm:"index:idx_notifications_user_created" json:"createdAt"`
+}
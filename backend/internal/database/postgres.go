@@ -2,9 +2,11 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/logging"
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -20,21 +22,32 @@ func OpenPostgres(cfg *config.Config) (*gorm.DB, error) {
 		)
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	level, err := logging.ParseLevel(cfg.Log.DBLevel)
 	if err != nil {
 		return nil, err
 	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: &logging.GormLogger{Logger: slog.Default(), Level: level, Slow: cfg.Log.SlowQuery},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// метрики и спаны запросов
+	if err := db.Use(telemetry.GormPlugin{}); err != nil {
+		return nil, err
+	}
 
 	// Verify connection
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DB.MaxIdleConns)
 	if err := sqlDB.Ping(); err != nil {
 		return nil, err
 	}
-	log.Println("connected to postgres")
+	slog.Info("connected to postgres", "host", cfg.DB.Host, "db", cfg.DB.Name)
 	return db, nil
 }
-
-
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/lifecycle"
+	"gofuckbiz/snimayprosto-rent-easy/internal/migrate"
+)
+
+// Database pings the pool and fails when it is saturated: every allowed
+// connection is busy and requests had to wait since the previous check.
+func Database(db *sql.DB) CheckFunc {
+	var mu sync.Mutex
+	var lastWaits int64
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return nil, err
+		}
+		st := db.Stats()
+		details := map[string]interface{}{
+			"open":    st.OpenConnections,
+			"inUse":   st.InUse,
+			"idle":    st.Idle,
+			"maxOpen": st.MaxOpenConnections,
+			"waits":   st.WaitCount,
+		}
+		mu.Lock()
+		waited := st.WaitCount > lastWaits
+		lastWaits = st.WaitCount
+		mu.Unlock()
+		if st.MaxOpenConnections > 0 && st.InUse >= st.MaxOpenConnections && waited {
+			return details, fmt.Errorf("connection pool saturated: %d of %d in use", st.InUse, st.MaxOpenConnections)
+		}
+		return details, nil
+	}
+}
+
+// Writable creates and removes a file in dir.
+func Writable(dir string) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return nil, err
+		}
+		name := f.Name()
+		_, werr := f.WriteString("ok")
+		cerr := f.Close()
+		rerr := os.Remove(name)
+		return map[string]interface{}{"dir": dir}, errors.Join(werr, cerr, rerr)
+	}
+}
+
+// Migrations fails while the database lacks migrations this binary ships.
+func Migrations(m *migrate.Migrator) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) == 0 {
+			return nil, nil
+		}
+		names := make([]string, len(pending))
+		for i, mig := range pending {
+			names[i] = fmt.Sprintf("%d_%s", mig.Version, mig.Name)
+		}
+		return map[string]interface{}{"pending": names}, fmt.Errorf("%d pending migrations", len(pending))
+	}
+}
+
+// Workers fails when a background job died or missed its heartbeat. The
+// manager is looked up on every run because jobs start after the checks
+// are registered.
+func Workers(jobs func() *lifecycle.Manager) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		m := jobs()
+		if m == nil {
+			return nil, errors.New("background jobs not started")
+		}
+		details := map[string]interface{}{}
+		var bad []string
+		for _, s := range m.Status(time.Now()) {
+			details[s.Name] = s
+			if !s.Healthy {
+				bad = append(bad, s.Name)
+			}
+		}
+		if len(bad) > 0 {
+			return details, fmt.Errorf("unhealthy jobs: %s", strings.Join(bad, ", "))
+		}
+		return details, nil
+	}
+}
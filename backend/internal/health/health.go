@@ -0,0 +1,105 @@
+// Package health implements the readiness checks behind /readyz. Each
+// check reports ok or fail with optional details; the instance is ready
+// when every check passes and it is not shutting down.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc returns details worth showing and an error when the
+// dependency is not usable.
+type CheckFunc func(ctx context.Context) (map[string]interface{}, error)
+
+type Result struct {
+	Status     string                 `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	DurationMS int64                  `json:"durationMs"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker runs the registered checks in parallel, each with Timeout.
+type Checker struct {
+	Timeout time.Duration
+
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{Timeout: timeout}
+}
+
+// Add registers a check. Call it before serving.
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name, fn})
+}
+
+// ShutDown makes every later report fail, so load balancers stop routing
+// here while in-flight requests drain.
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	r := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks)+1)}
+	if c.shuttingDown.Load() {
+		r.Checks["shutdown"] = Result{Status: StatusFail, Error: "shutting down"}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			res := run(ctx, ch.fn, c.Timeout)
+			mu.Lock()
+			r.Checks[ch.name] = res
+			mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+
+	for _, res := range r.Checks {
+		if res.Status != StatusOK {
+			r.Status = StatusFail
+		}
+	}
+	return r
+}
+
+func run(ctx context.Context, fn CheckFunc, timeout time.Duration) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	details, err := fn(ctx)
+	res := Result{Status: StatusOK, Details: details, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/lifecycle"
+)
+
+func TestReady(t *testing.T) {
+	c := New(50 * time.Millisecond)
+	c.Add("fine", func(context.Context) (map[string]interface{}, error) {
+		return map[string]interface{}{"n": 1}, nil
+	})
+	c.Add("slow", func(ctx context.Context) (map[string]interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	r := c.Ready(context.Background())
+	if r.OK() || r.Checks["fine"].Status != StatusOK || r.Checks["slow"].Status != StatusFail {
+		t.Fatalf("report = %+v", r)
+	}
+	if r.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("slow check error %q", r.Checks["slow"].Error)
+	}
+
+	ok := New(time.Second)
+	ok.Add("fine", c.checks[0].fn)
+	if r := ok.Ready(context.Background()); !r.OK() {
+		t.Fatalf("report = %+v", r)
+	}
+	ok.ShutDown()
+	if r := ok.Ready(context.Background()); r.OK() || r.Checks["shutdown"].Status != StatusFail {
+		t.Fatalf("report while shutting down = %+v", r)
+	}
+}
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Writable(dir)(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, "*")); len(left) != 0 {
+		t.Fatalf("probe files left behind: %v", left)
+	}
+	if _, err := Writable(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Fatal("missing directory reported writable")
+	}
+	if os.Getuid() != 0 { // root writes anywhere
+		ro := filepath.Join(dir, "ro")
+		os.Mkdir(ro, 0o555)
+		if _, err := Writable(ro)(context.Background()); err == nil {
+			t.Fatal("read-only directory reported writable")
+		}
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	var m *lifecycle.Manager
+	check := Workers(func() *lifecycle.Manager { return m })
+	if _, err := check(context.Background()); err == nil {
+		t.Fatal("ready before jobs started")
+	}
+	m = lifecycle.New(context.Background())
+	m.Go("dies", func(context.Context) {})
+	m.Go("lives", func(ctx context.Context) { <-ctx.Done() })
+	time.Sleep(10 * time.Millisecond)
+	_, err := check(context.Background())
+	if err == nil || err.Error() != "unhealthy jobs: dies" {
+		t.Fatalf("check = %v", err)
+	}
+	m.Stop(context.Background())
+}
@@ -0,0 +1,271 @@
+// Package apierr is the error model of the HTTP API. Handlers report an
+// *Error (or any error) with Abort; Middleware renders it as
+//
+//	{"error": "<code>", "message": "...", "fields": [...], "requestId": "..."}
+//
+// The code is stable and meant for clients; the message is for people and
+// follows Accept-Language (Russian unless English is preferred). Anything
+// that is not an *Error or a known service error becomes internal_error:
+// the cause is logged, never sent.
+package apierr
+
+import (
+	"errors"
+	"net/http"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// Code identifies an error for clients. Codes are part of the API: do not
+// rename them.
+type Code string
+
+const (
+	CodeInternal       Code = "internal_error"
+	CodeInvalidRequest Code = "invalid_request"
+	CodeNotFound       Code = "not_found"
+	CodeConflict       Code = "conflict"
+	CodeRateLimited    Code = "rate_limited"
+
+	CodeUnauthorized         Code = "unauthorized"
+	CodeMissingAuthorization Code = "missing_authorization"
+	CodeInvalidAuthorization Code = "invalid_authorization"
+	CodeMissingToken         Code = "missing_token"
+	CodeInvalidToken         Code = "invalid_token"
+	CodeRefreshTokenMissing  Code = "refresh_token_missing"
+	CodeInvalidRefreshToken  Code = "invalid_refresh_token"
+	CodeInvalidCredentials   Code = "invalid_credentials"
+	CodeInvalidMetricsToken  Code = "invalid_metrics_token"
+	CodeCSRFTokenMissing     Code = "csrf_token_missing"
+	CodeCSRFTokenInvalid     Code = "csrf_token_invalid"
+	CodeForbidden            Code = "forbidden"
+
+	CodeUserNotFound  Code = "user_not_found"
+	CodeEmailTaken    Code = "email_taken"
+	CodeInvalidUserID Code = "invalid_user_id"
+
+	CodeInvalidPlanType      Code = "invalid_plan_type"
+	CodeListingLimitExceeded Code = "listing_limit_exceeded"
+
+	CodePropertyNotFound         Code = "property_not_found"
+	CodePropertyNotFoundNotOwned Code = "property_not_found_or_not_owned"
+	CodeInvalidPropertyID        Code = "invalid_property_id"
+	CodeNotOwner                 Code = "not_owner"
+	CodeAlreadyPromoted          Code = "already_promoted"
+	CodeInvalidForm              Code = "invalid_form"
+	CodeNoImages                 Code = "no_images"
+	CodeContactsHidden           Code = "contacts_hidden"
+	CodeInvalidPeriod            Code = "invalid_period"
+	CodeInvalidPromotion         Code = "invalid_promotion_product"
+	CodePromotionLimitExceeded   Code = "promotion_limit_exceeded"
+
+	CodeConversationNotFound  Code = "conversation_not_found"
+	CodeInvalidConversationID Code = "invalid_conversation_id"
+	CodeMessageNotFound       Code = "message_not_found"
+	CodeInvalidMessageID      Code = "invalid_message_id"
+	CodeNotParticipant        Code = "not_participant"
+	CodeNotSender             Code = "not_sender"
+	CodeMessageDeleted        Code = "message_deleted"
+	CodeEditWindowExpired     Code = "edit_window_expired"
+	CodeMessageRejected       Code = "message_rejected"
+	CodeUserBlocked           Code = "user_blocked"
+	CodeCannotBlockSelf       Code = "cannot_block_self"
+	CodeAlreadyReviewed       Code = "already_reviewed"
+	CodeInvalidID             Code = "invalid_id"
+	CodeInvalidCursor         Code = "invalid_cursor"
+	CodeInvalidSince          Code = "invalid_since"
+	CodeInvalidLimit          Code = "invalid_limit"
+
+	CodeNotificationNotFound  Code = "notification_not_found"
+	CodeInvalidNotificationID Code = "invalid_notification_id"
+	CodePushDisabled          Code = "push_disabled"
+)
+
+type spec struct {
+	status int
+	ru, en string
+}
+
+var catalog = map[Code]spec{
+	CodeInternal:       {http.StatusInternalServerError, "Внутренняя ошибка сервера. Попробуйте позже", "Internal server error. Please try again later"},
+	CodeInvalidRequest: {http.StatusBadRequest, "Некорректный запрос", "Invalid request"},
+	CodeNotFound:       {http.StatusNotFound, "Не найдено", "Not found"},
+	CodeConflict:       {http.StatusConflict, "Такая запись уже существует", "Already exists"},
+	CodeRateLimited:    {http.StatusTooManyRequests, "Слишком много запросов. Попробуйте позже", "Too many requests. Please try again later"},
+
+	CodeUnauthorized:         {http.StatusUnauthorized, "Требуется вход", "Authentication required"},
+	CodeMissingAuthorization: {http.StatusUnauthorized, "Требуется вход", "Authentication required"},
+	CodeInvalidAuthorization: {http.StatusUnauthorized, "Некорректный заголовок Authorization", "Malformed Authorization header"},
+	CodeMissingToken:         {http.StatusUnauthorized, "Требуется вход", "Authentication required"},
+	CodeInvalidToken:         {http.StatusUnauthorized, "Сессия недействительна или истекла", "Session is invalid or expired"},
+	CodeRefreshTokenMissing:  {http.StatusUnauthorized, "Сессия истекла, войдите снова", "Session expired, please sign in again"},
+	CodeInvalidRefreshToken:  {http.StatusUnauthorized, "Сессия истекла, войдите снова", "Session expired, please sign in again"},
+	CodeInvalidCredentials:   {http.StatusUnauthorized, "Неверный email или пароль", "Wrong email or password"},
+	CodeInvalidMetricsToken:  {http.StatusUnauthorized, "Неверный токен метрик", "Invalid metrics token"},
+	CodeCSRFTokenMissing:     {http.StatusForbidden, "Отсутствует CSRF-токен", "CSRF token is missing"},
+	CodeCSRFTokenInvalid:     {http.StatusForbidden, "Недействительный CSRF-токен", "CSRF token is invalid"},
+	CodeForbidden:            {http.StatusForbidden, "Недостаточно прав", "Permission denied"},
+
+	CodeUserNotFound:  {http.StatusNotFound, "Пользователь не найден", "User not found"},
+	CodeEmailTaken:    {http.StatusConflict, "Этот email уже зарегистрирован", "This email is already registered"},
+	CodeInvalidUserID: {http.StatusBadRequest, "Некорректный ID пользователя", "Invalid user ID"},
+
+	CodeInvalidPlanType:      {http.StatusBadRequest, "Неизвестный тариф", "Unknown plan"},
+	CodeListingLimitExceeded: {http.StatusForbidden, "Достигнут лимит объявлений вашего тарифа", "Your plan's listing limit is reached"},
+
+	CodePropertyNotFound:         {http.StatusNotFound, "Объявление не найдено", "Listing not found"},
+	CodePropertyNotFoundNotOwned: {http.StatusNotFound, "Объявление не найдено", "Listing not found"},
+	CodeInvalidPropertyID:        {http.StatusBadRequest, "Некорректный ID объявления", "Invalid listing ID"},
+	CodeNotOwner:                 {http.StatusForbidden, "Это не ваше объявление", "This listing is not yours"},
+	CodeAlreadyPromoted:          {http.StatusConflict, "Объявление уже продвигается", "The listing is already promoted"},
+	CodeInvalidForm:              {http.StatusBadRequest, "Некорректная форма", "Invalid form"},
+	CodeNoImages:                 {http.StatusBadRequest, "Не выбраны фотографии", "No images selected"},
+	CodeContactsHidden:           {http.StatusForbidden, "Владелец скрыл контакты, напишите ему в чате", "The landlord hides the contacts; write to them in the chat"},
+	CodeInvalidPeriod:            {http.StatusBadRequest, "Некорректный период", "Invalid period"},
+	CodeInvalidPromotion:         {http.StatusBadRequest, "Неизвестный вариант продвижения", "Unknown promotion product"},
+	CodePromotionLimitExceeded:   {http.StatusForbidden, "Недостаточно кредитов продвижения на вашем тарифе", "Not enough promotion credits left on your plan"},
+
+	CodeConversationNotFound:  {http.StatusNotFound, "Диалог не найден", "Conversation not found"},
+	CodeInvalidConversationID: {http.StatusBadRequest, "Некорректный ID диалога", "Invalid conversation ID"},
+	CodeMessageNotFound:       {http.StatusNotFound, "Сообщение не найдено", "Message not found"},
+	CodeInvalidMessageID:      {http.StatusBadRequest, "Некорректный ID сообщения", "Invalid message ID"},
+	CodeNotParticipant:        {http.StatusForbidden, "Вы не участник этого диалога", "You are not in this conversation"},
+	CodeNotSender:             {http.StatusForbidden, "Это не ваше сообщение", "This message is not yours"},
+	CodeMessageDeleted:        {http.StatusConflict, "Сообщение удалено", "The message was deleted"},
+	CodeEditWindowExpired:     {http.StatusForbidden, "Время на редактирование истекло", "The message can no longer be edited"},
+	CodeMessageRejected:       {http.StatusUnprocessableEntity, "Сообщение отклонено фильтром", "The message was rejected by the filter"},
+	CodeUserBlocked:           {http.StatusForbidden, "Пользователь ограничил переписку", "The user has blocked this conversation"},
+	CodeCannotBlockSelf:       {http.StatusBadRequest, "Нельзя заблокировать себя", "You cannot block yourself"},
+	CodeAlreadyReviewed:       {http.StatusConflict, "Жалоба уже рассмотрена", "The report is already reviewed"},
+	CodeInvalidID:             {http.StatusBadRequest, "Некорректный ID", "Invalid ID"},
+	CodeInvalidCursor:         {http.StatusBadRequest, "Некорректный курсор страницы", "Invalid page cursor"},
+	CodeInvalidSince:          {http.StatusBadRequest, "Некорректная метка времени", "Invalid timestamp"},
+	CodeInvalidLimit:          {http.StatusBadRequest, "Некорректный размер страницы", "Invalid page size"},
+
+	CodeNotificationNotFound:  {http.StatusNotFound, "Уведомление не найдено", "Notification not found"},
+	CodeInvalidNotificationID: {http.StatusBadRequest, "Некорректный ID уведомления", "Invalid notification ID"},
+	CodePushDisabled:          {http.StatusNotFound, "Push-уведомления отключены", "Push notifications are disabled"},
+}
+
+// Status is the HTTP status the code is served with.
+func (c Code) Status() int {
+	if s, ok := catalog[c]; ok {
+		return s.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message is the human-readable text of the code in lang ("ru" or "en").
+func (c Code) Message(lang string) string {
+	s, ok := catalog[c]
+	if !ok {
+		s = catalog[CodeInternal]
+	}
+	if lang == LangEN {
+		return s.en
+	}
+	return s.ru
+}
+
+// Error is an error meant for API clients.
+type Error struct {
+	Code   Code
+	Status int
+	// Fields lists what was wrong with which input field.
+	Fields []FieldError
+	// Details are extra top-level keys of the response body.
+	Details map[string]any
+
+	cause error
+}
+
+// New returns the error for code with its catalog status.
+func New(code Code) *Error {
+	return &Error{Code: code, Status: code.Status()}
+}
+
+// Internal hides err behind internal_error.
+func Internal(err error) *Error {
+	return New(CodeInternal).Wrap(err)
+}
+
+// Wrap keeps err as the cause; it is logged but not rendered.
+func (e *Error) Wrap(err error) *Error {
+	e.cause = err
+	return e
+}
+
+// With adds a detail to the response body.
+func (e *Error) With(key string, v any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = v
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return string(e.Code) + ": " + e.cause.Error()
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error { return e.cause }
+
+// From turns any error into an *Error: API errors as they are, service
+// errors by their meaning, anything else into internal_error.
+func From(err error) *Error {
+	return Map(err, CodeNotFound)
+}
+
+// Map is From with the code to use when the service reports that the
+// looked-up row does not exist.
+func Map(err error, notFound Code) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var limit *service.ListingLimitError
+	var credits *service.PromotionLimitError
+	switch {
+	case errors.As(err, &limit):
+		return New(CodeListingLimitExceeded).Wrap(err).
+			With("currentPlan", limit.PlanType).
+			With("maxListings", limit.MaxListings).
+			With("activeListings", limit.ActiveListings)
+	case errors.As(err, &credits):
+		return New(CodePromotionLimitExceeded).Wrap(err).
+			With("currentPlan", credits.PlanType).
+			With("promotionCredits", credits.Credits).
+			With("promotionCreditsUsed", credits.Used).
+			With("cost", credits.Cost)
+	case errors.Is(err, service.ErrNotFound):
+		return New(notFound).Wrap(err)
+	}
+	for sentinel, code := range sentinels {
+		if errors.Is(err, sentinel) {
+			return New(code).Wrap(err)
+		}
+	}
+	return Internal(err)
+}
+
+var sentinels = map[error]Code{
+	service.ErrConflict:           CodeConflict,
+	service.ErrEmailTaken:         CodeEmailTaken,
+	service.ErrInvalidCredentials: CodeInvalidCredentials,
+	service.ErrInvalidPlan:        CodeInvalidPlanType,
+	service.ErrNotOwner:           CodeNotOwner,
+	service.ErrAlreadyPromoted:    CodeAlreadyPromoted,
+	service.ErrNotParticipant:     CodeNotParticipant,
+	service.ErrNotSender:          CodeNotSender,
+	service.ErrMessageDeleted:     CodeMessageDeleted,
+	service.ErrEditWindowExpired:  CodeEditWindowExpired,
+	service.ErrBlocked:            CodeUserBlocked,
+	service.ErrCannotBlockSelf:    CodeCannotBlockSelf,
+	service.ErrAlreadyReviewed:    CodeAlreadyReviewed,
+	service.ErrContactsHidden:     CodeContactsHidden,
+	service.ErrInvalidPeriod:      CodeInvalidPeriod,
+	service.ErrInvalidPromotion:   CodeInvalidPromotion,
+}
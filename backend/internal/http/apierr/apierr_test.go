@@ -0,0 +1,89 @@
+package apierr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/handlers"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestLang(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                        apierr.LangRU,
+		"en":                      apierr.LangEN,
+		"en-GB,en;q=0.8":          apierr.LangEN,
+		"ru-RU,ru;q=0.9,en;q=0.8": apierr.LangRU,
+		"de,en;q=0.5,ru;q=0.7":    apierr.LangRU,
+		"fr":                      apierr.LangRU,
+	} {
+		if got := apierr.Lang(in); got != want {
+			t.Errorf("Lang(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	cases := []struct {
+		err    error
+		code   apierr.Code
+		status int
+	}{
+		{fmt.Errorf("load: %w", service.ErrNotFound), apierr.CodeMessageNotFound, http.StatusNotFound},
+		{service.ErrNotParticipant, apierr.CodeNotParticipant, http.StatusForbidden},
+		{service.ErrEmailTaken, apierr.CodeEmailTaken, http.StatusConflict},
+		{apierr.New(apierr.CodeNoImages), apierr.CodeNoImages, http.StatusBadRequest},
+		{errors.New(`pq: relation "users" does not exist`), apierr.CodeInternal, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		e := apierr.Map(tc.err, apierr.CodeMessageNotFound)
+		if e.Code != tc.code || e.Status != tc.status {
+			t.Errorf("Map(%v) = %s %d, want %s %d", tc.err, e.Code, e.Status, tc.code, tc.status)
+		}
+	}
+
+	limit := apierr.From(&service.ListingLimitError{PlanType: "free", MaxListings: 1, ActiveListings: 1})
+	if limit.Code != apierr.CodeListingLimitExceeded || limit.Details["maxListings"] != 1 {
+		t.Fatalf("listing limit: %+v", limit)
+	}
+}
+
+func TestRender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("requestId", "req-1") }, handlers.Recovery(), apierr.Middleware())
+	r.GET("/db", func(c *gin.Context) {
+		apierr.Abort(c, errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("nil map") })
+	r.GET("/ok", func(c *gin.Context) {
+		_ = c.Error(errors.New("logged only"))
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	for _, path := range []string{"/db", "/panic"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusInternalServerError || body["error"] != "internal_error" || body["requestId"] != "req-1" {
+			t.Fatalf("%s: %d %s", path, w.Code, w.Body)
+		}
+		if len(body) != 3 {
+			t.Fatalf("%s: internal error leaks details: %s", path, w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+	if w.Code != http.StatusOK || w.Body.String() != `{"ok":true}` {
+		t.Fatalf("written response replaced: %d %s", w.Code, w.Body)
+	}
+}
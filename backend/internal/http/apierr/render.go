@@ -0,0 +1,80 @@
+package apierr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Languages of the messages.
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+// Lang picks the message language from Accept-Language: the first of ru
+// and en by preference, Russian when neither is there.
+func Lang(acceptLanguage string) string {
+	best, bestQ := LangRU, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if base != LangRU && base != LangEN {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// Abort stops the request with err; Middleware writes the response.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Middleware renders the last error of a request that failed without
+// writing a response. It goes right after the recovery middleware.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Writer.Written() || len(c.Errors) == 0 {
+			return
+		}
+		Render(c, c.Errors.Last().Err)
+	}
+}
+
+// Render writes err as the response.
+func Render(c *gin.Context, err error) {
+	e := From(err)
+	lang := Lang(c.GetHeader("Accept-Language"))
+	body := gin.H{}
+	for k, v := range e.Details {
+		body[k] = v
+	}
+	body["error"] = e.Code
+	body["message"] = e.Code.Message(lang)
+	if len(e.Fields) > 0 {
+		fields := make([]FieldError, len(e.Fields))
+		for i, f := range e.Fields {
+			f.Message = fieldMessage(f, lang)
+			fields[i] = f
+		}
+		body["fields"] = fields
+	}
+	if id := c.GetString("requestId"); id != "" {
+		body["requestId"] = id
+	}
+	c.Header("Content-Language", lang)
+	c.AbortWithStatusJSON(e.Status, body)
+}
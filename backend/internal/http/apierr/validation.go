@@ -0,0 +1,178 @@
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError says what is wrong with one input field.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "email" or "images[0].url".
+	Field string `json:"field"`
+	// Rule is the failed validation rule: required, email, min, oneof...
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+	// Message is filled in when the error is rendered.
+	Message string `json:"message"`
+	// Kind of the value, for wording min and max: characters, items or
+	// a number.
+	Kind reflect.Kind `json:"-"`
+}
+
+func init() {
+	// Validation errors name fields the way the client sent them.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonName)
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// Bind turns an error of c.ShouldBind* into invalid_request, with a field
+// error for every failed rule.
+func Bind(err error) *Error {
+	e := New(CodeInvalidRequest).Wrap(err)
+	var verrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &verrs):
+		for _, fe := range verrs {
+			e.Fields = append(e.Fields, FieldError{
+				Field: fieldPath(fe.Namespace()),
+				Rule:  fe.Tag(),
+				Param: fe.Param(),
+				Kind:  fe.Kind(),
+			})
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		e.Fields = append(e.Fields, FieldError{Field: typeErr.Field, Rule: "type", Param: jsonType(typeErr.Type.Kind())})
+	}
+	return e
+}
+
+// jsonType names a Go kind the way JSON Schema does.
+func jsonType(k reflect.Kind) string {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return k.String()
+}
+
+// Field is invalid_request for a single field, for checks the binding tags
+// cannot express.
+func Field(field, rule, param string) *Error {
+	e := New(CodeInvalidRequest)
+	e.Fields = []FieldError{{Field: field, Rule: rule, Param: param}}
+	return e
+}
+
+// Invalid is invalid_request with the given field errors.
+func Invalid(fields ...FieldError) *Error {
+	e := New(CodeInvalidRequest)
+	e.Fields = fields
+	return e
+}
+
+// fieldPath drops the struct name from a validator namespace:
+// "createPropertyRequest.title" -> "title".
+func fieldPath(ns string) string {
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return ns
+}
+
+// fieldMessage explains a failed rule in lang.
+func fieldMessage(f FieldError, lang string) string {
+	ru, en := fieldText(f)
+	if lang == LangEN {
+		return en
+	}
+	return ru
+}
+
+func fieldText(f FieldError) (ru, en string) {
+	p := f.Param
+	counted := f.Kind == reflect.Slice || f.Kind == reflect.Map || f.Kind == reflect.Array
+	switch f.Rule {
+	case "required", "required_if", "required_with", "required_without":
+		return "Обязательное поле", "This field is required"
+	case "email":
+		return "Некорректный email", "Must be a valid email address"
+	case "url", "http_url":
+		return "Некорректная ссылка", "Must be a valid URL"
+	case "oneof":
+		list := strings.Join(strings.Fields(p), ", ")
+		return "Допустимые значения: " + list, "Must be one of: " + list
+	case "numeric", "number":
+		return "Должно быть числом", "Must be a number"
+	case "latitude":
+		return "Некорректная широта", "Must be a valid latitude"
+	case "longitude":
+		return "Некорректная долгота", "Must be a valid longitude"
+	case "e164":
+		return "Некорректный номер телефона", "Must be a valid phone number"
+	case "type":
+		return "Неверный тип значения", fmt.Sprintf("Must be of type %s", p)
+	case "len":
+		switch {
+		case f.Kind == reflect.String:
+			return "Длина должна быть ровно " + p, "Must be exactly " + p + " characters long"
+		case counted:
+			return "Количество должно быть ровно " + p, "Must have exactly " + p + " items"
+		}
+		return "Должно быть равно " + p, "Must equal " + p
+	case "min", "gte":
+		switch {
+		case f.Kind == reflect.String:
+			return "Минимальная длина — " + p, "Must be at least " + p + " characters long"
+		case counted:
+			return "Минимальное количество — " + p, "Must have at least " + p + " items"
+		}
+		return "Должно быть не меньше " + p, "Must be at least " + p
+	case "max", "lte":
+		switch {
+		case f.Kind == reflect.String:
+			return "Максимальная длина — " + p, "Must be at most " + p + " characters long"
+		case counted:
+			return "Максимальное количество — " + p, "Must have at most " + p + " items"
+		}
+		return "Должно быть не больше " + p, "Must be at most " + p
+	case "gt":
+		return "Должно быть больше " + p, "Must be greater than " + p
+	case "lt":
+		return "Должно быть меньше " + p, "Must be less than " + p
+	case "ltefield":
+		return "Должно быть не больше поля " + p, "Must not exceed " + p
+	case "amenity":
+		return "Неизвестное удобство", "Unknown amenity"
+	}
+	return "Некорректное значение", "Invalid value"
+}
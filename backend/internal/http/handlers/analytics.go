@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// defaultAnalyticsDays is the period shown when the request names none.
+const defaultAnalyticsDays = 30
+
+type AnalyticsHandler struct {
+	Analytics *service.AnalyticsService
+	Cfg       *config.Config
+}
+
+func NewAnalyticsHandler(analytics *service.AnalyticsService, cfg *config.Config) *AnalyticsHandler {
+	return &AnalyticsHandler{Analytics: analytics, Cfg: cfg}
+}
+
+// period reads ?from= and ?to= (YYYY-MM-DD, UTC, both inclusive). To
+// defaults to today and from to 30 days up to to.
+func (h *AnalyticsHandler) period(c *gin.Context) (from, to time.Time, ok bool) {
+	to = service.Day(h.Analytics.Now())
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidPeriod).Wrap(err))
+			return from, to, false
+		}
+		to = t
+	}
+	from = to.AddDate(0, 0, 1-defaultAnalyticsDays)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidPeriod).Wrap(err))
+			return from, to, false
+		}
+		from = t
+	}
+	return from, to, true
+}
+
+// Listing serves the daily analytics of one of the caller's listings.
+func (h *AnalyticsHandler) Listing(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
+		return
+	}
+
+	from, to, ok := h.period(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.Analytics.Listing(c.Request.Context(), userID, uint(propertyID), from, to)
+	if err != nil {
+		apierr.Abort(c, notOwned(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Account serves the daily analytics of all the caller's listings.
+func (h *AnalyticsHandler) Account(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := h.period(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.Analytics.Account(c.Request.Context(), userID, from, to)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type AuditHandler struct {
+	Audit *service.AuditService
+	Cfg   *config.Config
+}
+
+func NewAuditHandler(audit *service.AuditService, cfg *config.Config) *AuditHandler {
+	return &AuditHandler{Audit: audit, Cfg: cfg}
+}
+
+// List returns audit events, newest first. Filters: ?action=, ?actorId=;
+// page with ?before=<id of the last event seen> and ?limit=.
+func (h *AuditHandler) List(c *gin.Context) {
+	f := service.AuditFilter{Action: c.Query("action")}
+	for _, p := range []struct {
+		name string
+		dst  *uint
+	}{{"actorId", &f.ActorID}, {"before", &f.Before}} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			apierr.Abort(c, apierr.Field(p.name, "number", ""))
+			return
+		}
+		*p.dst = uint(n)
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidLimit))
+			return
+		}
+		f.Limit = n
+	}
+
+	events, err := h.Audit.List(c.Request.Context(), f)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
@@ -1,26 +1,25 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
 	"gofuckbiz/snimayprosto-rent-easy/internal/auth"
 	"gofuckbiz/snimayprosto-rent-easy/internal/config"
-	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
-	DB  *gorm.DB
-	Cfg *config.Config
+	Users *service.UserService
+	Cfg   *config.Config
 }
 
-func NewAuthHandler(db *gorm.DB, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(users *service.UserService, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		DB:  db,
-		Cfg: cfg,
+		Users: users,
+		Cfg:   cfg,
 	}
 }
 
@@ -44,41 +43,24 @@ type updateRoleRequest struct {
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req registerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		apierr.Abort(c, apierr.Bind(err))
 		return
 	}
 
-	var existing core.User
-	if err := h.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "email_taken"})
-		return
-	}
-
-	hash, err := auth.HashPassword(req.Password)
+	user, err := h.Users.Register(c.Request.Context(), req.Email, req.Password, req.FirstName+" "+req.LastName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "hash_error"})
-		return
-	}
-
-	user := core.User{
-		Email:        req.Email,
-		PasswordHash: hash,
-		Name:         req.FirstName + " " + req.LastName,
-		Role:         "user",
-	}
-	if err := h.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db_error"})
+		apierr.Abort(c, err)
 		return
 	}
 
 	access, err := auth.GenerateToken(user.ID, h.Cfg.JWT.AccessSecret, h.Cfg.JWT.AccessTTL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		apierr.Abort(c, apierr.Internal(err))
 		return
 	}
 	refresh, err := auth.GenerateToken(user.ID, h.Cfg.JWT.RefreshSecret, h.Cfg.JWT.RefreshTTL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		apierr.Abort(c, apierr.Internal(err))
 		return
 	}
 
@@ -94,26 +76,22 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req loginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
-		return
-	}
-	var user core.User
-	if err := h.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_credentials"})
+		apierr.Abort(c, apierr.Bind(err))
 		return
 	}
-	if err := auth.CheckPassword(user.PasswordHash, req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_credentials"})
+	user, err := h.Users.Authenticate(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		apierr.Abort(c, err)
 		return
 	}
 	access, err := auth.GenerateToken(user.ID, h.Cfg.JWT.AccessSecret, h.Cfg.JWT.AccessTTL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		apierr.Abort(c, apierr.Internal(err))
 		return
 	}
 	refresh, err := auth.GenerateToken(user.ID, h.Cfg.JWT.RefreshSecret, h.Cfg.JWT.RefreshTTL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		apierr.Abort(c, apierr.Internal(err))
 		return
 	}
 
@@ -127,14 +105,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 func (h *AuthHandler) Me(c *gin.Context) {
-	userIDVal, ok := c.Get("userId")
+	userID, ok := requireUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	var user core.User
-	if err := h.DB.First(&user, userIDVal).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	user, err := h.Users.Get(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeUserNotFound))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"id": user.ID, "email": user.Email, "name": user.Name, "role": user.Role})
@@ -144,28 +121,28 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	// Get refresh token from cookie
 	refreshToken, err := c.Cookie("refresh_token")
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh_token_missing"})
+		apierr.Abort(c, apierr.New(apierr.CodeRefreshTokenMissing))
 		return
 	}
 
 	// Parse and validate refresh token
 	claims, err := auth.ParseToken(refreshToken, h.Cfg.JWT.RefreshSecret)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_refresh_token"})
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidRefreshToken).Wrap(err))
 		return
 	}
 
 	// Generate new access token
 	access, err := auth.GenerateToken(claims.UserID, h.Cfg.JWT.AccessSecret, h.Cfg.JWT.AccessTTL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		apierr.Abort(c, apierr.Internal(err))
 		return
 	}
 
 	// Generate new refresh token (token rotation)
 	refresh, err := auth.GenerateToken(claims.UserID, h.Cfg.JWT.RefreshSecret, h.Cfg.JWT.RefreshTTL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		apierr.Abort(c, apierr.Internal(err))
 		return
 	}
 
@@ -184,41 +161,22 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 }
 
 func (h *AuthHandler) UpdateRole(c *gin.Context) {
-	userIDVal, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_not_found"})
-		return
-	}
-
-	var userID uint
-	switch v := userIDVal.(type) {
-	case float64:
-		userID = uint(v)
-	case uint:
-		userID = v
-	case int:
-		userID = uint(v)
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid_user_id_type"})
+	userID, ok := requireUser(c)
+	if !ok {
 		return
 	}
 
 	var req updateRoleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		apierr.Abort(c, apierr.Bind(err))
 		return
 	}
 
-	fmt.Printf("Updating user %d role to: %s\n", userID, req.Role)
-
-	if err := h.DB.Model(&core.User{}).Where("id = ?", userID).Update("role", req.Role).Error; err != nil {
-		fmt.Printf("Error updating role: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+	if err := h.Users.UpdateRole(c.Request.Context(), userID, req.Role); err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeUserNotFound))
 		return
 	}
 
-	fmt.Printf("Successfully updated user %d role to: %s\n", userID, req.Role)
-
 	c.JSON(http.StatusOK, gin.H{"message": "role_updated"})
 }
 
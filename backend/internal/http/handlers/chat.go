@@ -1,217 +1,305 @@
 package handlers
 
 import (
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"gorm.io/gorm"
 
+	"gofuckbiz/snimayprosto-rent-easy/internal/chatfilter"
 	"gofuckbiz/snimayprosto-rent-easy/internal/config"
 	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
 	"gofuckbiz/snimayprosto-rent-easy/internal/auth"
 )
 
 type ChatHandler struct {
-	DB  *gorm.DB
-	Cfg *config.Config
+	Chat *service.ChatService
+	Cfg  *config.Config
+
+	// Notifier, when set, is told about messages for offline recipients
+	Notifier *notify.Dispatcher
+	// Center, when set, gets an in-app notification for every message the
+	// recipient is not watching live
+	Center *notify.Center
+
+	hub      *chatHub
+	upgrader websocket.Upgrader
+}
+
+func NewChatHandler(chat *service.ChatService, cfg *config.Config) *ChatHandler {
+	return &ChatHandler{
+		Chat:     chat,
+		Cfg:      cfg,
+		hub:      newChatHub(),
+		upgrader: websocket.Upgrader{CheckOrigin: CheckOrigin(cfg.CORS.Origins)},
+	}
+}
+
+// CloseSockets closes every chat socket with a "going away" close frame so
+// clients reconnect to another instance. New sockets are refused after.
+func (h *ChatHandler) CloseSockets() {
+	h.hub.close(websocket.CloseGoingAway, "server shutting down")
+}
+
+// IsOnline reports whether the user has a chat socket open.
+func (h *ChatHandler) IsOnline(userID uint) bool {
+	return h.hub.online(userID)
 }
 
-func NewChatHandler(db *gorm.DB, cfg *config.Config) *ChatHandler {
-	return &ChatHandler{DB: db, Cfg: cfg}
+func (h *ChatHandler) pageLimit(c *gin.Context) int {
+	limit := h.Cfg.Chat.PageSize
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > h.Cfg.Chat.MaxPage {
+		limit = h.Cfg.Chat.MaxPage
+	}
+	return limit
+}
+
+// messageEvent is the payload pushed over the socket for message changes.
+func messageEvent(event string, msg core.Message) gin.H {
+	msg = msg.Redacted()
+	return gin.H{
+		"event":          event,
+		"id":             msg.ID,
+		"conversationId": msg.ConversationID,
+		"senderId":       msg.SenderID,
+		"type":           msg.Type,
+		"content":        msg.Content,
+		"attachmentUrl":  msg.AttachmentURL,
+		"createdAt":      msg.CreatedAt,
+		"updatedAt":      msg.UpdatedAt,
+		"editedAt":       msg.EditedAt,
+		"deletedAt":      msg.DeletedAt,
+	}
 }
 
 // Create or get conversation between current user and property owner
 func (h *ChatHandler) StartConversation(c *gin.Context) {
-	userIDVal, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_not_found"})
+	userID, ok := requireUser(c)
+	if !ok {
 		return
 	}
 
-	var userID uint
-	switch v := userIDVal.(type) {
-	case float64:
-		userID = uint(v)
-	case uint:
-		userID = v
-	case int:
-		userID = uint(v)
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid_user_id_type"})
+	propertyID, err := strconv.Atoi(c.Param("propertyId"))
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
 		return
 	}
 
-	propertyID, err := strconv.Atoi(c.Param("propertyId"))
+	conv, err := h.Chat.Start(c.Request.Context(), userID, uint(propertyID))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_property_id"})
+		apierr.Abort(c, apierr.Map(err, apierr.CodePropertyNotFound))
 		return
 	}
 
-	var property core.Property
-	if err := h.DB.First(&property, propertyID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "property_not_found"})
+	c.JSON(http.StatusOK, conv)
+}
+
+// List messages in a conversation, newest page first.
+// Use ?before=<messageId> to walk back through history.
+func (h *ChatHandler) ListMessages(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
 		return
 	}
-
-	var conv core.Conversation
-	// try existing conversation between same pair bound to property
-	if err := h.DB.Where("(initiator_id = ? AND recipient_id = ? OR initiator_id = ? AND recipient_id = ?) AND property_id = ?",
-		userID, property.OwnerID, property.OwnerID, userID, property.ID).
-		First(&conv).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			conv = core.Conversation{
-				PropertyID:  &property.ID,
-				InitiatorID: userID,
-				RecipientID: property.OwnerID,
-			}
-			if err := h.DB.Create(&conv).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "create_conversation_failed"})
-				return
-			}
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "conversation_lookup_failed"})
+	convID, err := strconv.ParseUint(c.Param("conversationId"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidConversationID))
+		return
+	}
+	var before uint64
+	if v := c.Query("before"); v != "" {
+		if before, err = strconv.ParseUint(v, 10, 64); err != nil {
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidCursor))
 			return
 		}
 	}
 
-	c.JSON(http.StatusOK, conv)
+	msgs, hasMore, err := h.Chat.History(c.Request.Context(), userID, uint(convID), uint(before), h.pageLimit(c))
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeConversationNotFound))
+		return
+	}
+
+	var nextBefore *uint
+	if hasMore && len(msgs) > 0 {
+		nextBefore = &msgs[0].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"items": msgs, "hasMore": hasMore, "nextBefore": nextBefore})
 }
 
-// List messages in a conversation
-func (h *ChatHandler) ListMessages(c *gin.Context) {
-	convID, err := strconv.Atoi(c.Param("conversationId"))
+// SyncMessages returns messages created, edited or deleted after ?since=
+// (RFC 3339). Reconnecting clients pass back the serverTime of the
+// previous response.
+func (h *ChatHandler) SyncMessages(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	convID, err := strconv.ParseUint(c.Param("conversationId"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidConversationID))
+		return
+	}
+	since, err := time.Parse(time.RFC3339Nano, c.Query("since"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_conversation_id"})
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidSince))
 		return
 	}
 
-	var msgs []core.Message
-	if err := h.DB.Where("conversation_id = ?", convID).Order("created_at asc").Find(&msgs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+	msgs, hasMore, serverTime, err := h.Chat.Changes(c.Request.Context(), userID, uint(convID), since, h.pageLimit(c))
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeConversationNotFound))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"items": msgs})
+	c.JSON(http.StatusOK, gin.H{"items": msgs, "hasMore": hasMore, "serverTime": serverTime})
 }
 
-// List conversations for a landlord
-func (h *ChatHandler) ListConversations(c *gin.Context) {
-	userIDVal, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_not_found"})
-		return
+type editMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// messageParams reads the user and the conversation/message IDs from the
+// request.
+func messageParams(c *gin.Context) (userID, convID, msgID uint, ok bool) {
+	userID, ok = requireUser(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	conv, err := strconv.ParseUint(c.Param("conversationId"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidConversationID))
+		return 0, 0, 0, false
 	}
+	msg, err := strconv.ParseUint(c.Param("messageId"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidMessageID))
+		return 0, 0, 0, false
+	}
+	return userID, uint(conv), uint(msg), true
+}
 
-	var userID uint
-	switch v := userIDVal.(type) {
-	case float64:
-		userID = uint(v)
-	case uint:
-		userID = v
-	case int:
-		userID = uint(v)
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid_user_id_type"})
+func (h *ChatHandler) EditMessage(c *gin.Context) {
+	userID, convID, msgID, ok := messageParams(c)
+	if !ok {
+		return
+	}
+	var req editMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Bind(err))
 		return
 	}
 
-	// Get all conversations where user is the owner
-	var conversations []core.Conversation
-	if err := h.DB.Where("recipient_id = ?", userID).Find(&conversations).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed_to_fetch_conversations"})
+	res, err := h.Chat.Edit(c.Request.Context(), userID, convID, msgID, req.Content)
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeMessageNotFound))
+		return
+	}
+	switch res.Verdict.Action {
+	case chatfilter.Reject:
+		apierr.Abort(c, apierr.New(apierr.CodeMessageRejected).With("reason", res.Verdict.Reason()))
+		return
+	case chatfilter.Hold:
+		// the message stays as it was until a moderator releases the edit
+		c.JSON(http.StatusAccepted, gin.H{"message": "message_held", "reason": res.Verdict.Reason()})
 		return
 	}
 
-	// Build response with additional data
-	var response []gin.H
-	for _, conv := range conversations {
-		// Skip conversations without property
-		if conv.PropertyID == nil {
-			continue
-		}
-		
-		// Get property info
-		var property core.Property
-		if err := h.DB.First(&property, *conv.PropertyID).Error; err != nil {
-			continue // Skip if property not found
-		}
+	msg := res.Message
+	h.hub.broadcast(msg.ConversationID, messageEvent("message.updated", *msg))
+	c.JSON(http.StatusOK, msg.Redacted())
+}
 
-		// Get initiator info
-		var initiator core.User
-		if err := h.DB.First(&initiator, conv.InitiatorID).Error; err != nil {
-			continue // Skip if initiator not found
-		}
+func (h *ChatHandler) DeleteMessage(c *gin.Context) {
+	userID, convID, msgID, ok := messageParams(c)
+	if !ok {
+		return
+	}
 
-		// Get last message
-		var lastMessage core.Message
-		h.DB.Where("conversation_id = ?", conv.ID).Order("created_at DESC").First(&lastMessage)
-
-		// Count unread messages
-		var unreadCount int64
-		h.DB.Model(&core.Message{}).Where("conversation_id = ? AND sender_id != ? AND id > ?", 
-			conv.ID, userID, 0).Count(&unreadCount)
-
-		response = append(response, gin.H{
-			"id":              conv.ID,
-			"propertyId":      *conv.PropertyID,
-			"propertyTitle":   property.Title,
-			"propertyPrice":   property.Price,
-			"initiatorId":     conv.InitiatorID,
-			"ownerId":         conv.RecipientID,
-			"initiatorName":   initiator.Name,
-			"lastMessage":     lastMessage,
-			"unreadCount":     unreadCount,
-		})
-	}
-
-	c.JSON(http.StatusOK, gin.H{"conversations": response})
-}
+	msg, err := h.Chat.Delete(c.Request.Context(), userID, convID, msgID)
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeMessageNotFound))
+		return
+	}
 
-// --- WebSocket ---
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
+	h.hub.broadcast(msg.ConversationID, messageEvent("message.deleted", *msg))
+	c.JSON(http.StatusOK, msg.Redacted())
 }
 
-type wsClient struct {
-	conn *websocket.Conn
-	user uint
-	conv uint
-}
+// List conversations for a landlord
+func (h *ChatHandler) ListConversations(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	conversations, err := h.Chat.Inbox(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
 
-// Very small in-memory hub for MVP only
-var wsClients = make(map[*wsClient]struct{})
+	c.JSON(http.StatusOK, gin.H{"conversations": conversations})
+}
 
+// --- WebSocket ---
 func (h *ChatHandler) Socket(c *gin.Context) {
 	convID64, err := strconv.ParseUint(c.Param("conversationId"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_conversation_id"})
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidConversationID))
 		return
 	}
 	// parse token from query
 	token := c.Query("token")
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_token"})
+		apierr.Abort(c, apierr.New(apierr.CodeMissingToken))
 		return
 	}
 	claims, err := auth.ParseToken(token, h.Cfg.JWT.AccessSecret)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidToken).Wrap(err))
 		return
 	}
 	userID := uint(claims.UserID)
+	conv, err := h.Chat.Participant(c.Request.Context(), uint(convID64), userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeConversationNotFound))
+		return
+	}
+	peerID := conv.Peer(userID)
+	senderName := h.Chat.UserName(c.Request.Context(), userID)
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		return
 	}
 	client := &wsClient{conn: conn, user: userID, conv: uint(convID64)}
-	wsClients[client] = struct{}{}
+	if !h.hub.add(client) {
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(time.Second))
+		conn.Close()
+		return
+	}
+
+	// the session keeps the request ID of the upgrade request
+	ctx := c.Request.Context()
+	logger := slog.With("user_id", userID, "conversation_id", convID64)
+	logger.InfoContext(ctx, "chat: socket opened")
+	opened, sent := time.Now(), 0
 
 	defer func() {
+		h.hub.remove(client)
 		client.conn.Close()
-		delete(wsClients, client)
+		logger.InfoContext(ctx, "chat: socket closed", "duration", time.Since(opened), "messages", sent)
 	}()
 
 	for {
@@ -219,26 +307,34 @@ func (h *ChatHandler) Socket(c *gin.Context) {
 		if err := conn.ReadJSON(&incoming); err != nil {
 			break
 		}
-		msg := core.Message{
-			ConversationID: uint(convID64),
-			SenderID:       userID,
-			Type:           "text",
-			Content:        incoming.Content,
+		res, err := h.Chat.Send(ctx, conv, userID, incoming.Content)
+		if err != nil {
+			logger.ErrorContext(ctx, "chat: send message", "err", err)
 		}
-		if err := h.DB.Create(&msg).Error; err != nil {
+		// the peer may block the sender while the socket is open
+		if res.Blocked {
+			_ = client.writeJSON(gin.H{"event": "message.rejected", "conversationId": convID64, "reason": "blocked"})
+			continue
+		}
+		if res.Message == nil {
+			if res.Verdict.Action == chatfilter.Hold || res.Verdict.Action == chatfilter.Reject {
+				event := "message.held"
+				if res.Verdict.Action == chatfilter.Reject {
+					event = "message.rejected"
+				}
+				// only the sender learns about it
+				_ = client.writeJSON(gin.H{"event": event, "conversationId": convID64, "reason": res.Verdict.Reason()})
+			}
 			continue
 		}
+		msg := *res.Message
+		sent++
 		// broadcast to participants of same conversation
-		for c := range wsClients {
-			if c.conv == uint(convID64) {
-				_ = c.conn.WriteJSON(gin.H{
-					"id": msg.ID,
-					"conversationId": msg.ConversationID,
-					"senderId": msg.SenderID,
-					"type": msg.Type,
-					"content": msg.Content,
-					"createdAt": time.Now(),
-				})
+		h.hub.broadcast(msg.ConversationID, messageEvent("message.created", msg))
+		h.Notifier.MessageCreated(msg, peerID)
+		if !h.hub.watching(peerID, msg.ConversationID) {
+			if err := h.Center.NewMessage(ctx, peerID, senderName, msg); err != nil {
+				logger.ErrorContext(ctx, "chat: in-app notification", "err", err)
 			}
 		}
 	}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+)
+
+func (h *ChatHandler) BlockUser(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	target, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidUserID))
+		return
+	}
+	if err := h.Chat.Block(c.Request.Context(), userID, uint(target)); err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeUserNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user_blocked"})
+}
+
+func (h *ChatHandler) UnblockUser(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	target, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidUserID))
+		return
+	}
+	if err := h.Chat.Unblock(c.Request.Context(), userID, uint(target)); err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user_unblocked"})
+}
+
+func (h *ChatHandler) ListBlocked(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	items, err := h.Chat.Blocked(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+)
+
+// ListFlagged returns flagged chat messages, pending ones by default.
+// Use ?status=rejected|released|dismissed|all to see the rest.
+func (h *ChatHandler) ListFlagged(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	var before uint64
+	if v := c.Query("before"); v != "" {
+		var err error
+		if before, err = strconv.ParseUint(v, 10, 64); err != nil {
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidCursor))
+			return
+		}
+	}
+	items, err := h.Chat.Flagged(c.Request.Context(), status, uint(before), h.pageLimit(c))
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func flaggedID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidID))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ReleaseFlagged delivers a held message as the sender wrote it, or applies
+// a held edit.
+func (h *ChatHandler) ReleaseFlagged(c *gin.Context) {
+	reviewerID, _ := currentUserID(c)
+	id, ok := flaggedID(c)
+	if !ok {
+		return
+	}
+
+	msg, conv, err := h.Chat.Release(c.Request.Context(), reviewerID, id)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+
+	if msg.EditedAt != nil {
+		h.hub.broadcast(msg.ConversationID, messageEvent("message.updated", *msg))
+		c.JSON(http.StatusOK, gin.H{"message": msg})
+		return
+	}
+	h.hub.broadcast(msg.ConversationID, messageEvent("message.created", *msg))
+	h.Notifier.MessageCreated(*msg, conv.Peer(msg.SenderID))
+	c.JSON(http.StatusOK, gin.H{"message": msg})
+}
+
+// DismissFlagged drops a held message without delivering it.
+func (h *ChatHandler) DismissFlagged(c *gin.Context) {
+	reviewerID, _ := currentUserID(c)
+	id, ok := flaggedID(c)
+	if !ok {
+		return
+	}
+	if err := h.Chat.Dismiss(c.Request.Context(), reviewerID, id); err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "dismissed"})
+}
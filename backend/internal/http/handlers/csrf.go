@@ -1,74 +1,100 @@
 package handlers
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"net/http"
+	"strings"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
 
 	"github.com/gin-gonic/gin"
 )
 
+// CSRF protection is a signed double submit: GET /auth/csrf sets an
+// HttpOnly cookie and returns the same token in the body, and the web app
+// echoes it in a header on every state-changing request. Tokens carry an
+// HMAC of their nonce, so a cookie planted from a sibling subdomain is
+// rejected too.
 const (
 	CSRFTokenHeader = "X-CSRF-Token"
 	CSRFTokenCookie = "csrf_token"
 	CSRFTokenLength = 32
 )
 
-// CSRFMiddleware generates and validates CSRF tokens
-func CSRFMiddleware() gin.HandlerFunc {
+// CSRFMiddleware rejects state-changing requests whose header and cookie
+// tokens are missing, unsigned or different. Safe methods pass.
+func CSRFMiddleware(cfg *config.Config) gin.HandlerFunc {
+	secret := []byte(cfg.CSRF.Secret)
 	return func(c *gin.Context) {
-		// Skip CSRF for GET requests, static files, and authentication endpoints
-		if c.Request.Method == "GET" || 
-		   c.Request.URL.Path == "/health" ||
-		   c.Request.URL.Path == "/auth/login" ||
-		   c.Request.URL.Path == "/auth/register" ||
-		   c.Request.URL.Path == "/auth/refresh" {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
 			c.Next()
 			return
 		}
 
-		// Get CSRF token from cookie
-		csrfCookie, err := c.Cookie(CSRFTokenCookie)
-		if err != nil {
-			// Generate new CSRF token
-			csrfToken := generateCSRFToken()
-			c.SetCookie(CSRFTokenCookie, csrfToken, 3600, "/", "", false, true) // HttpOnly, Secure in production
-			c.Next()
+		cookie, _ := c.Cookie(CSRFTokenCookie)
+		header := c.GetHeader(CSRFTokenHeader)
+		if cookie == "" || header == "" {
+			apierr.Abort(c, apierr.New(apierr.CodeCSRFTokenMissing))
 			return
 		}
-
-		// Get CSRF token from header
-		csrfHeader := c.GetHeader(CSRFTokenHeader)
-		if csrfHeader == "" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "csrf_token_missing"})
-			c.Abort()
+		if !validCSRFToken(secret, header) || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			apierr.Abort(c, apierr.New(apierr.CodeCSRFTokenInvalid))
 			return
 		}
+		c.Next()
+	}
+}
 
-		// Validate CSRF token
-		if csrfHeader != csrfCookie {
-			c.JSON(http.StatusForbidden, gin.H{"error": "csrf_token_invalid"})
-			c.Abort()
+// CSRF hands out the token for this browser, reusing the cookie when it
+// still holds a valid one so that open tabs agree.
+func (h *AuthHandler) CSRF(c *gin.Context) {
+	secret := []byte(h.Cfg.CSRF.Secret)
+	token, err := c.Cookie(CSRFTokenCookie)
+	if err != nil || !validCSRFToken(secret, token) {
+		token, err = newCSRFToken(secret)
+		if err != nil {
+			apierr.Abort(c, apierr.Internal(err))
 			return
 		}
-
-		c.Next()
 	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(CSRFTokenCookie, token, int(h.Cfg.CSRF.TTL.Seconds()), "/", "", h.Cfg.Production(), true)
+	c.JSON(http.StatusOK, gin.H{"csrfToken": token})
 }
 
-// generateCSRFToken creates a random CSRF token
-func generateCSRFToken() string {
-	b := make([]byte, CSRFTokenLength)
-	rand.Read(b)
-	return base64.URLEncoding.EncodeToString(b)
+// newCSRFToken returns "nonce.signature", both base64url.
+func newCSRFToken(secret []byte) (string, error) {
+	nonce := make([]byte, CSRFTokenLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(nonce) + "." + enc.EncodeToString(csrfSignature(secret, nonce)), nil
 }
 
-// GetCSRFToken returns the current CSRF token for the client
-func GetCSRFToken(c *gin.Context) string {
-	csrfToken, err := c.Cookie(CSRFTokenCookie)
-	if err != nil {
-		csrfToken = generateCSRFToken()
-		c.SetCookie(CSRFTokenCookie, csrfToken, 3600, "/", "", false, true)
+func validCSRFToken(secret []byte, token string) bool {
+	n, s, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
 	}
-	return csrfToken
+	enc := base64.RawURLEncoding
+	nonce, err1 := enc.DecodeString(n)
+	sig, err2 := enc.DecodeString(s)
+	if err1 != nil || err2 != nil || len(nonce) != CSRFTokenLength {
+		return false
+	}
+	return hmac.Equal(sig, csrfSignature(secret, nonce))
+}
+
+func csrfSignature(secret, nonce []byte) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte("csrf:"))
+	m.Write(nonce)
+	return m.Sum(nil)
 }
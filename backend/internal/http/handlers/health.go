@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/health"
+)
+
+type HealthHandler struct {
+	Checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{Checker: checker}
+}
+
+// Live answers as long as the process serves HTTP; it checks nothing else
+// so a slow database does not get the instance restarted.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Ready runs the readiness checks and answers 503 if any fails.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	r := h.Checker.Ready(c.Request.Context())
+	status := http.StatusOK
+	if !r.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, r)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
+)
+
+type wsClient struct {
+	conn *websocket.Conn
+	user uint
+	conv uint
+
+	writeMu sync.Mutex // gorilla allows only one concurrent writer per conn
+}
+
+func (c *wsClient) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// chatHub tracks open chat sockets so that both the socket loop and plain
+// HTTP handlers (edit, delete) can push events to a conversation.
+type chatHub struct {
+	mu      sync.RWMutex
+	clients map[*wsClient]struct{}
+	rooms   map[uint]int // open sockets per conversation
+	closed  bool
+}
+
+func newChatHub() *chatHub {
+	return &chatHub{clients: make(map[*wsClient]struct{}), rooms: make(map[uint]int)}
+}
+
+// add registers c. It reports false once the hub is closed.
+func (h *chatHub) add(c *wsClient) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	if h.rooms[c.conv]++; h.rooms[c.conv] == 1 {
+		telemetry.ChatRooms.Inc()
+	}
+	telemetry.ChatConnections.Inc()
+	return true
+}
+
+func (h *chatHub) remove(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return // dropped by close
+	}
+	delete(h.clients, c)
+	if h.rooms[c.conv]--; h.rooms[c.conv] == 0 {
+		delete(h.rooms, c.conv)
+		telemetry.ChatRooms.Dec()
+	}
+	telemetry.ChatConnections.Dec()
+}
+
+// broadcast sends payload to every socket subscribed to the conversation.
+func (h *chatHub) broadcast(convID uint, payload interface{}) {
+	h.mu.RLock()
+	targets := make([]*wsClient, 0, len(h.clients))
+	for c := range h.clients {
+		if c.conv == convID {
+			targets = append(targets, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range targets {
+		_ = c.writeJSON(payload)
+	}
+}
+
+// online reports whether the user has at least one open socket.
+func (h *chatHub) online(userID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients {
+		if c.user == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// watching reports whether the user has a socket open on the conversation.
+func (h *chatHub) watching(userID, convID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients {
+		if c.user == userID && c.conv == convID {
+			return true
+		}
+	}
+	return false
+}
+
+// close sends every socket a close frame with the given code and closes
+// it. Sockets opened afterwards are refused.
+func (h *chatHub) close(code int, reason string) {
+	h.mu.Lock()
+	h.closed = true
+	clients := h.clients
+	h.clients = make(map[*wsClient]struct{})
+	telemetry.ChatConnections.Sub(float64(len(clients)))
+	telemetry.ChatRooms.Sub(float64(len(h.rooms)))
+	h.rooms = make(map[uint]int)
+	h.mu.Unlock()
+
+	msg := websocket.FormatCloseMessage(code, reason)
+	for c := range clients {
+		c.writeMu.Lock()
+		_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		c.writeMu.Unlock()
+		c.conn.Close()
+	}
+}
@@ -1,11 +1,12 @@
 package handlers
 
 import (
-	"net/http"
 	"strings"
 
 	"gofuckbiz/snimayprosto-rent-easy/internal/auth"
 	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,17 +15,17 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		h := c.GetHeader("Authorization")
 		if h == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing_authorization"})
+			apierr.Abort(c, apierr.New(apierr.CodeMissingAuthorization))
 			return
 		}
 		parts := strings.SplitN(h, " ", 2)
 		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_authorization"})
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidAuthorization))
 			return
 		}
 		claims, err := auth.ParseToken(parts[1], cfg.JWT.AccessSecret)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidToken).Wrap(err))
 			return
 		}
 		// Store as uint to avoid type conversion issues
@@ -33,4 +34,66 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+// OptionalAuth identifies the user like AuthMiddleware on public routes
+// that work for everyone. Requests without a valid token pass through as
+// anonymous.
+func OptionalAuth(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+			if claims, err := auth.ParseToken(parts[1], cfg.JWT.AccessSecret); err == nil {
+				c.Set("userId", uint(claims.UserID))
+			}
+		}
+		c.Next()
+	}
+}
+
+// currentUserID returns the authenticated user set by AuthMiddleware.
+func currentUserID(c *gin.Context) (uint, bool) {
+	v, ok := c.Get("userId")
+	if !ok {
+		return 0, false
+	}
+	switch id := v.(type) {
+	case uint:
+		return id, true
+	case int:
+		return uint(id), id >= 0
+	case float64:
+		return uint(id), id >= 0
+	}
+	return 0, false
+}
+
+// requireUser is currentUserID that aborts with unauthorized when the
+// request carries no user.
+func requireUser(c *gin.Context) (uint, bool) {
+	id, ok := currentUserID(c)
+	if !ok {
+		apierr.Abort(c, apierr.New(apierr.CodeUnauthorized))
+	}
+	return id, ok
+}
 
+// RequireRole lets through only users with one of the given roles. It must
+// run after AuthMiddleware.
+func RequireRole(users *service.UserService, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		ok, err := users.HasRole(c.Request.Context(), userID, roles...)
+		if err != nil {
+			// the token outlived its user
+			apierr.Abort(c, apierr.Map(err, apierr.CodeUnauthorized))
+			return
+		}
+		if !ok {
+			apierr.Abort(c, apierr.New(apierr.CodeForbidden))
+			return
+		}
+		c.Next()
+	}
+}
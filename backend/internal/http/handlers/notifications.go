@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/auth"
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type NotificationsHandler struct {
+	Notifications *service.NotificationService
+	Cfg           *config.Config
+
+	// Center feeds the live stream; without it Stream only sends pings
+	Center *notify.Center
+}
+
+func NewNotificationsHandler(notifications *service.NotificationService, cfg *config.Config) *NotificationsHandler {
+	return &NotificationsHandler{
+		Notifications: notifications,
+		Cfg:           cfg,
+	}
+}
+
+type updatePreferencesRequest struct {
+	EmailEnabled *bool `json:"emailEnabled"`
+	PushEnabled  *bool `json:"pushEnabled"`
+}
+
+type pushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" binding:"required,url"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
+
+func (h *NotificationsHandler) GetPreferences(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	prefs, err := h.Notifications.Preferences(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+func (h *NotificationsHandler) UpdatePreferences(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var req updatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Bind(err))
+		return
+	}
+	prefs, err := h.Notifications.UpdatePreferences(c.Request.Context(), userID, req.EmailEnabled, req.PushEnabled)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// PushKey returns the VAPID public key the browser needs to subscribe.
+func (h *NotificationsHandler) PushKey(c *gin.Context) {
+	if h.Cfg.Notify.VAPID.PublicKey == "" {
+		apierr.Abort(c, apierr.New(apierr.CodePushDisabled))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"publicKey": h.Cfg.Notify.VAPID.PublicKey})
+}
+
+func (h *NotificationsHandler) SubscribePush(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var req pushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Bind(err))
+		return
+	}
+	sub := core.PushSubscription{
+		UserID:   userID,
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}
+	if err := h.Notifications.SubscribePush(c.Request.Context(), &sub); err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "subscribed"})
+}
+
+func (h *NotificationsHandler) UnsubscribePush(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Endpoint string `json:"endpoint" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Bind(err))
+		return
+	}
+	if err := h.Notifications.UnsubscribePush(c.Request.Context(), userID, req.Endpoint); err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "unsubscribed"})
+}
+
+// List returns the user's notifications, newest first. Supports
+// ?before=<id>, ?limit= and ?unread=true.
+func (h *NotificationsHandler) List(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	f := service.NotificationFilter{UserID: userID, Limit: 30, UnreadOnly: c.Query("unread") == "true"}
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 && n <= 100 {
+		f.Limit = n
+	}
+	if v := c.Query("before"); v != "" {
+		before, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidCursor))
+			return
+		}
+		f.Before = uint(before)
+	}
+
+	items, hasMore, err := h.Notifications.List(c.Request.Context(), f)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	var nextBefore *uint
+	if hasMore {
+		nextBefore = &items[len(items)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "hasMore": hasMore, "nextBefore": nextBefore})
+}
+
+func (h *NotificationsHandler) UnreadCount(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	n, err := h.Notifications.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": n})
+}
+
+func (h *NotificationsHandler) MarkRead(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidNotificationID))
+		return
+	}
+	if err := h.Notifications.MarkRead(c.Request.Context(), userID, uint(id)); err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodeNotificationNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "marked_read"})
+}
+
+func (h *NotificationsHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	updated, err := h.Notifications.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "marked_read", "updated": updated})
+}
+
+// Stream pushes new notifications as server-sent events. EventSource
+// cannot set headers, so the access token may also come as ?token=.
+func (h *NotificationsHandler) Stream(c *gin.Context) {
+	token := c.Query("token")
+	if hdr := c.GetHeader("Authorization"); hdr != "" {
+		parts := strings.SplitN(hdr, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+			token = parts[1]
+		}
+	}
+	if token == "" {
+		apierr.Abort(c, apierr.New(apierr.CodeMissingToken))
+		return
+	}
+	claims, err := auth.ParseToken(token, h.Cfg.JWT.AccessSecret)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidToken).Wrap(err))
+		return
+	}
+	userID := claims.UserID
+
+	var events <-chan core.Notification
+	if h.Center != nil {
+		ch, cancel := h.Center.Subscribe(userID)
+		defer cancel()
+		events = ch
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	// the stream outlives the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	ping := time.NewTicker(25 * time.Second)
+	defer ping.Stop()
+
+	c.SSEvent("ready", gin.H{"userId": userID})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case n, ok := <-events:
+			if !ok { // server shutting down
+				return false
+			}
+			c.SSEvent("notification", n)
+		case <-ping.C:
+			c.SSEvent("ping", time.Now().Unix())
+		}
+		return true
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,190 +9,230 @@ import (
 	"fmt"
 	"os"
 	"errors"
-	"sort"
+	"io"
+	"log/slog"
+	"time"
 
 	"gofuckbiz/snimayprosto-rent-easy/internal/config"
 	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type PropertiesHandler struct {
-	DB  *gorm.DB
-	Cfg *config.Config
+	Properties *service.PropertyService
+	Cfg        *config.Config
+
+	// Center, when set, tells owners about moderation decisions
+	Center *notify.Center
 }
 
-func NewPropertiesHandler(db *gorm.DB, cfg *config.Config) *PropertiesHandler {
+func NewPropertiesHandler(properties *service.PropertyService, cfg *config.Config) *PropertiesHandler {
 	return &PropertiesHandler{
-		DB:  db,
-		Cfg: cfg,
+		Properties: properties,
+		Cfg:        cfg,
 	}
 }
 
+// createPropertyRequest is a new listing as the web app sends it. Rooms
+// is 0 for a studio; area is in square metres.
 type createPropertyRequest struct {
-	Title        string   `json:"title" binding:"required"`
-	Description  string   `json:"description"`
-	Address      string   `json:"address" binding:"required"`
-	PropertyType string   `json:"propertyType" binding:"required"`
-	Rooms        string   `json:"rooms" binding:"required"`
-	Price        string   `json:"price" binding:"required"`
-	PriceType    string   `json:"priceType" binding:"required"`
-	Phone        string   `json:"phone" binding:"required"`
-	Email        string   `json:"email"`
-	Amenities    []string `json:"amenities"`
+	Title        string   `json:"title" binding:"required,max=120"`
+	Description  string   `json:"description" binding:"max=5000"`
+	Address      string   `json:"address" binding:"required,max=300"`
+	City         string   `json:"city" binding:"max=100"` // taken from the address when empty
+	PropertyType string   `json:"propertyType" binding:"required,oneof=apartment room house studio"`
+	Rooms        *int     `json:"rooms" binding:"required,min=0,max=20"`
+	Area         int      `json:"area" binding:"required,min=1,max=10000"`
+	Price        float64  `json:"price" binding:"required,gt=0,max=100000000"`
+	PriceType    string   `json:"priceType" binding:"required,oneof=month day"`
+	Phone        phone    `json:"phone" binding:"required,e164"`
+	Email        string   `json:"email" binding:"omitempty,email,max=254"`
+	Amenities    []string `json:"amenities" binding:"max=30,dive,required,max=50"` // catalog codes
 	IsUrgent     bool     `json:"isUrgent"`
-	Visibility   string   `json:"visibility" binding:"required"`
-	Latitude     float64  `json:"latitude"`
-	Longitude    float64  `json:"longitude"`
+	Visibility   string   `json:"visibility" binding:"required,oneof=public registered"`
+	Latitude     *float64 `json:"latitude" binding:"required,latitude"`
+	Longitude    *float64 `json:"longitude" binding:"required,longitude"`
+	Promote      bool     `json:"promote"` // promote right away
+
+	Floor             *int     `json:"floor" binding:"omitempty,min=-3,max=200"`
+	TotalFloors       *int     `json:"totalFloors" binding:"omitempty,min=1,max=200"`
+	Furnished         *bool    `json:"furnished"`
+	PetsAllowed       *bool    `json:"petsAllowed"`
+	Balcony           *bool    `json:"balcony"`
+	Parking           *bool    `json:"parking"`
+	Deposit           *float64 `json:"deposit" binding:"omitempty,min=0,max=100000000"`
+	UtilitiesIncluded *bool    `json:"utilitiesIncluded"`
+
+	PhoneVisibility string `json:"phoneVisibility" binding:"omitempty,oneof=public on_request hidden"` // on_request by default
+	EmailVisibility string `json:"emailVisibility" binding:"omitempty,oneof=public on_request hidden"`
 }
 
-func (h *PropertiesHandler) Create(c *gin.Context) {
-	userIDVal, ok := c.Get("userId")
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-	var userIDUint uint
-	switch v := userIDVal.(type) {
-	case uint:
-		userIDUint = v
-	case int:
-		if v >= 0 { userIDUint = uint(v) }
-	case int64:
-		if v >= 0 { userIDUint = uint(v) }
-	default:
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	// Check user's plan and listing limit
-	var plan core.UserPlan
-	if err := h.DB.Where("user_id = ?", userIDUint).First(&plan).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Create default free plan
-			plan = core.UserPlan{
-				UserID:      userIDUint,
-				PlanType:    "free",
-				MaxListings: 3,
-			}
-			if err := h.DB.Create(&plan).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "create_plan_failed"})
-				return
-			}
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "plan_lookup_failed"})
-			return
-		}
+// promoteRequest is optional; without it the default product starts now.
+type promoteRequest struct {
+	Product  string     `json:"product" binding:"max=40"`
+	StartsAt *time.Time `json:"startsAt"` // schedules the promotion
+}
+
+type contactVisibilityRequest struct {
+	PhoneVisibility string `json:"phoneVisibility" binding:"required,oneof=public on_request hidden"`
+	EmailVisibility string `json:"emailVisibility" binding:"required,oneof=public on_request hidden"`
+}
+
+// phone is normalized to E.164 while decoding, so that the e164 rule
+// checks the normalized number.
+type phone string
+
+func (p *phone) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
 	}
+	*p = phone(service.NormalizePhone(s))
+	return nil
+}
 
-	// Count current active listings
-	var activeListings int64
-	if err := h.DB.Model(&core.Property{}).Where("owner_id = ?", userIDUint).Count(&activeListings).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "count_listings_failed"})
-		return
+// notOwned reports someone else's listing as missing, so that owner
+// endpoints do not reveal which IDs exist.
+func notOwned(err error) *apierr.Error {
+	if errors.Is(err, service.ErrNotOwner) {
+		return apierr.New(apierr.CodePropertyNotFoundNotOwned).Wrap(err)
 	}
+	return apierr.Map(err, apierr.CodePropertyNotFoundNotOwned)
+}
 
-	// Check if user can create more listings
-	if activeListings >= int64(plan.MaxListings) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":          "listing_limit_exceeded",
-			"currentPlan":    plan.PlanType,
-			"maxListings":    plan.MaxListings,
-			"activeListings": activeListings,
-		})
+func (h *PropertiesHandler) Create(c *gin.Context) {
+	userIDUint, ok := requireUser(c)
+	if !ok {
 		return
 	}
 
 	var req createPropertyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		apierr.Abort(c, apierr.Bind(err))
 		return
 	}
 
-	rooms, _ := strconv.Atoi(req.Rooms)
-	if req.Rooms == "studio" {
-		rooms = 0
-	} else if req.Rooms == "5+" {
-		rooms = 5
+	city := service.CanonicalCity(req.City)
+	if city == "" {
+		city = service.CityFromAddress(req.Address)
+	}
+	if city == "" {
+		apierr.Abort(c, apierr.Field("city", "required", ""))
+		return
+	}
+	if req.Floor != nil && req.TotalFloors != nil && *req.Floor > *req.TotalFloors {
+		apierr.Abort(c, apierr.Field("floor", "ltefield", "totalFloors"))
+		return
 	}
-	price, _ := strconv.ParseFloat(req.Price, 64)
+
 	p := core.Property{
 		OwnerID:      userIDUint,
-		Title:        req.Title,
-		Description:  req.Description,
-		Address:      req.Address,
+		Title:        strings.TrimSpace(req.Title),
+		Description:  strings.TrimSpace(req.Description),
+		Address:      strings.TrimSpace(req.Address),
+		City:         city,
 		PropertyType: req.PropertyType,
-		Rooms:        rooms,
-		Price:        price,
+		Rooms:        *req.Rooms,
+		Area:         req.Area,
+		Price:        req.Price,
 		PriceType:    req.PriceType,
-		ContactPhone: req.Phone,
+		ContactPhone: string(req.Phone),
 		ContactEmail: req.Email,
-		Amenities:    strings.Join(req.Amenities, ","),
+		Amenities:    req.Amenities,
 		IsUrgent:     req.IsUrgent,
 		Visibility:   req.Visibility,
-		Lat:          req.Latitude,
-		Lng:          req.Longitude,
+		Lat:          *req.Latitude,
+		Lng:          *req.Longitude,
+
+		Floor:             req.Floor,
+		TotalFloors:       req.TotalFloors,
+		Furnished:         req.Furnished,
+		PetsAllowed:       req.PetsAllowed,
+		Balcony:           req.Balcony,
+		Parking:           req.Parking,
+		Deposit:           req.Deposit,
+		UtilitiesIncluded: req.UtilitiesIncluded,
+
+		PhoneVisibility: req.PhoneVisibility,
+		EmailVisibility: req.EmailVisibility,
+	}
+	listing, err := h.Properties.Create(c.Request.Context(), &p, service.CreateOptions{Promote: req.Promote})
+	var unknown *service.UnknownAmenityError
+	if errors.As(err, &unknown) {
+		apierr.Abort(c, apierr.Field(fmt.Sprintf("amenities[%d]", unknown.Index), "amenity", "").Wrap(err))
+		return
 	}
-	if err := h.DB.Create(&p).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db_error"})
+	if err != nil {
+		apierr.Abort(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, p)
+	c.JSON(http.StatusCreated, listing)
 }
 
+// List serves the public feed. amenities is a comma-separated list of
+// catalog codes; pets and furnished take "true".
 func (h *PropertiesHandler) List(c *gin.Context) {
-	city := strings.TrimSpace(c.Query("city"))
-	var items []core.Property
-	
-	// First get promoted properties, then regular ones
-	q := h.DB.Preload("Images").
-		Select("properties.*, CASE WHEN property_promotions.id IS NOT NULL AND property_promotions.expires_at > NOW() THEN 1 ELSE 0 END as is_promoted").
-		Joins("LEFT JOIN property_promotions ON properties.id = property_promotions.property_id AND property_promotions.expires_at > NOW()").
-		Order("is_promoted DESC, created_at DESC")
-	
-	if city != "" {
-		like := "%" + city + "%"
-		q = q.Where("city ILIKE ? OR address ILIKE ?", like, like)
-	}
-	if err := q.Limit(100).Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db_error"})
-		return
-	}
-	
-	// Add promotion status to each property
-	for i := range items {
-		var promotion core.PropertyPromotion
-		if err := h.DB.Where("property_id = ? AND expires_at > NOW()", items[i].ID).First(&promotion).Error; err == nil {
-			// Property is promoted - this will be handled in frontend
+	f := service.PropertyFilter{
+		City:        strings.TrimSpace(c.Query("city")),
+		PetsAllowed: c.Query("pets") == "true",
+		Furnished:   c.Query("furnished") == "true",
+		Limit:       100,
+	}
+	for _, code := range strings.Split(c.Query("amenities"), ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			f.Amenities = append(f.Amenities, code)
 		}
 	}
-	
+	items, err := h.Properties.List(c.Request.Context(), f)
+	if err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"items": items})
 }
 
-func (h *PropertiesHandler) Get(c *gin.Context) {
-	propertyID, err := strconv.Atoi(c.Param("id"))
+// amenityView is a catalog entry labeled in the client's language.
+type amenityView struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+	Icon  string `json:"icon"`
+}
+
+// Amenities serves the amenity catalog for the listing form and filters.
+func (h *PropertiesHandler) Amenities(c *gin.Context) {
+	catalog, err := h.Properties.Amenities(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		apierr.Abort(c, err)
 		return
 	}
+	lang := apierr.Lang(c.GetHeader("Accept-Language"))
+	items := make([]amenityView, 0, len(catalog))
+	for _, a := range catalog {
+		items = append(items, amenityView{Code: a.Code, Label: a.Label(lang), Icon: a.Icon})
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
 
-	var property core.Property
-	if err := h.DB.Preload("Images").First(&property, propertyID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch property"})
+func (h *PropertiesHandler) Get(c *gin.Context) {
+	propertyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
 		return
 	}
 
-	// Sort images by order after loading
-	if len(property.Images) > 0 {
-		sort.Slice(property.Images, func(i, j int) bool {
-			return property.Images[i].Order < property.Images[j].Order
-		})
+	viewer := service.Viewer{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	viewer.UserID, _ = currentUserID(c)
+	property, err := h.Properties.Get(c.Request.Context(), uint(propertyID), viewer)
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodePropertyNotFound))
+		return
 	}
 
 	c.JSON(http.StatusOK, property)
@@ -201,53 +242,34 @@ func (h *PropertiesHandler) UploadImages(c *gin.Context) {
 	propertyIDStr := c.Param("id")
 	propertyID, err := strconv.ParseUint(propertyIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_property_id"})
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
 		return
 	}
 
-	// Check if property exists and user owns it
-	var property core.Property
-	if err := h.DB.First(&property, propertyID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "property_not_found"})
-		return
-	}
-
-	userIDVal, ok := c.Get("userId")
+	userIDUint, ok := requireUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-	var userIDUint uint
-	switch v := userIDVal.(type) {
-	case uint:
-		userIDUint = v
-	case int:
-		if v >= 0 { userIDUint = uint(v) }
-	case int64:
-		if v >= 0 { userIDUint = uint(v) }
-	default:
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	if property.OwnerID != userIDUint {
-		c.JSON(http.StatusForbidden, gin.H{"error": "not_owner"})
+	// Check if property exists and user owns it
+	if _, err := h.Properties.Owned(c.Request.Context(), userIDUint, uint(propertyID)); err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodePropertyNotFound))
 		return
 	}
 
 	form, err := c.MultipartForm()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_form"})
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidForm).Wrap(err))
 		return
 	}
 
 	files := form.File["images"]
 	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no_images"})
+		apierr.Abort(c, apierr.New(apierr.CodeNoImages))
 		return
 	}
 
-	var uploadedImages []core.PropertyImage
+	uploadedImages := []core.PropertyImage{}
 	for i, file := range files {
 		if file.Size > 5*1024*1024 { // 5MB limit
 			continue
@@ -273,7 +295,7 @@ func (h *PropertiesHandler) UploadImages(c *gin.Context) {
 			URL:        "/uploads/" + filename,
 			Order:      i,
 		}
-		if err := h.DB.Create(&img).Error; err != nil {
+		if err := h.Properties.AddImage(c.Request.Context(), &img); err != nil {
 			continue
 		}
 		uploadedImages = append(uploadedImages, img)
@@ -283,115 +305,218 @@ func (h *PropertiesHandler) UploadImages(c *gin.Context) {
 }
 
 func (h *PropertiesHandler) MyListings(c *gin.Context) {
-	userIDVal, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_not_found"})
+	userID, ok := requireUser(c)
+	if !ok {
 		return
 	}
 
-	var userID uint
-	switch v := userIDVal.(type) {
-	case float64:
-		userID = uint(v)
-	case uint:
-		userID = v
-	case int:
-		userID = uint(v)
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid_user_id_type"})
+	result, err := h.Properties.OwnerListings(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, err)
 		return
 	}
 
-	var properties []core.Property
-	if err := h.DB.Preload("Images").Where("owner_id = ?", userID).Order("created_at DESC").Find(&properties).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch_failed"})
+	c.JSON(http.StatusOK, gin.H{"items": result})
+}
+
+// DeleteProperty removes the caller's listing and its uploaded photos.
+func (h *PropertiesHandler) DeleteProperty(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	propertyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
 		return
 	}
 
-	// Add promotion status
-	type PropertyWithPromotion struct {
-		core.Property
-		IsPromoted bool      `json:"isPromoted"`
-		ExpiresAt  *time.Time `json:"promotionExpiresAt,omitempty"`
+	p, err := h.Properties.Delete(c.Request.Context(), userID, uint(propertyID))
+	if err != nil {
+		apierr.Abort(c, notOwned(err))
+		return
 	}
 
-	var result []PropertyWithPromotion
-	for _, prop := range properties {
-		var promotion core.PropertyPromotion
-		isPromoted := false
-		var expiresAt *time.Time
-		
-		if err := h.DB.Where("property_id = ? AND expires_at > NOW()", prop.ID).First(&promotion).Error; err == nil {
-			isPromoted = true
-			expiresAt = &promotion.ExpiresAt
+	for _, img := range p.Images {
+		if !strings.HasPrefix(img.URL, "/uploads/") {
+			continue
 		}
+		path := filepath.Join(h.Cfg.Uploads.Dir, filepath.Base(img.URL))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.WarnContext(c.Request.Context(), "properties: image file not removed", "path", path, "err", err)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "property_deleted"})
+}
 
-		result = append(result, PropertyWithPromotion{
-			Property:   prop,
-			IsPromoted: isPromoted,
-			ExpiresAt:  expiresAt,
-		})
+func (h *PropertiesHandler) PromoteProperty(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"items": result})
+	propertyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
+		return
+	}
+
+	var req promoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierr.Abort(c, apierr.Bind(err))
+		return
+	}
+
+	promotion, err := h.Properties.Promote(c.Request.Context(), userID, uint(propertyID),
+		service.PromoteOptions{Product: req.Product, StartsAt: req.StartsAt})
+	if err != nil {
+		apierr.Abort(c, notOwned(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "property_promoted",
+		"expiresAt": promotion.ExpiresAt,
+		"promotion": promotion,
+	})
 }
 
-func (h *PropertiesHandler) PromoteProperty(c *gin.Context) {
-	userIDVal, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_not_found"})
+// Promotions serves the promotion history of the caller's listing.
+func (h *PropertiesHandler) Promotions(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
 		return
 	}
 
-	var userID uint
-	switch v := userIDVal.(type) {
-	case float64:
-		userID = uint(v)
-	case uint:
-		userID = v
-	case int:
-		userID = uint(v)
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid_user_id_type"})
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
 		return
 	}
 
-	propertyID, err := strconv.Atoi(c.Param("id"))
+	items, err := h.Properties.Promotions(c.Request.Context(), userID, uint(propertyID))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_property_id"})
+		apierr.Abort(c, notOwned(err))
 		return
 	}
 
-	// Verify property ownership
-	var property core.Property
-	if err := h.DB.Where("id = ? AND owner_id = ?", propertyID, userID).First(&property).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "property_not_found_or_not_owned"})
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// promotionProductView is a catalog entry labeled in the client's
+// language.
+type promotionProductView struct {
+	Code    string `json:"code"`
+	Tier    string `json:"tier"`
+	Days    int    `json:"days"`
+	Credits int    `json:"credits"`
+	Label   string `json:"label"`
+}
+
+// PromotionProducts serves the promotion catalog.
+func (h *PropertiesHandler) PromotionProducts(c *gin.Context) {
+	lang := apierr.Lang(c.GetHeader("Accept-Language"))
+	items := make([]promotionProductView, 0, len(service.PromotionProducts))
+	for _, p := range service.PromotionProducts {
+		items = append(items, promotionProductView{Code: p.Code, Tier: p.Tier, Days: p.Days, Credits: p.Credits, Label: p.Label(lang)})
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// RevealContacts shows the contacts of a listing to a signed-in user. Every
+// reveal is recorded and counted in the landlord's listing stats.
+func (h *PropertiesHandler) RevealContacts(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
 		return
 	}
 
-	// Check if already promoted
-	var existingPromotion core.PropertyPromotion
-	if err := h.DB.Where("property_id = ? AND expires_at > NOW()", propertyID).First(&existingPromotion).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "already_promoted"})
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
 		return
 	}
 
-	// Create promotion (7 days)
-	promotion := core.PropertyPromotion{
-		PropertyID: uint(propertyID),
-		UserID:     userID,
-		ExpiresAt:  time.Now().AddDate(0, 0, 7), // 7 days
+	contacts, err := h.Properties.RevealContacts(c.Request.Context(), userID, uint(propertyID), c.ClientIP())
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodePropertyNotFound))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, contacts)
+}
+
+// SetContactVisibility lets the owner choose who sees each contact.
+func (h *PropertiesHandler) SetContactVisibility(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
 	}
 
-	if err := h.DB.Create(&promotion).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "promotion_failed"})
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
+		return
+	}
+
+	var req contactVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Bind(err))
+		return
+	}
+
+	p, err := h.Properties.SetContactVisibility(c.Request.Context(), userID, uint(propertyID), req.PhoneVisibility, req.EmailVisibility)
+	if err != nil {
+		apierr.Abort(c, notOwned(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "property_promoted",
-		"expiresAt": promotion.ExpiresAt,
+		"phoneVisibility": p.PhoneVisibility,
+		"emailVisibility": p.EmailVisibility,
 	})
 }
 
+// AddFavorite puts a listing in the caller's favorites.
+func (h *PropertiesHandler) AddFavorite(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
+		return
+	}
+
+	if err := h.Properties.Favorite(c.Request.Context(), userID, uint(propertyID)); err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodePropertyNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "favorite_added"})
+}
+
+func (h *PropertiesHandler) RemoveFavorite(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
 
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
+		return
+	}
+
+	if err := h.Properties.Unfavorite(c.Request.Context(), userID, uint(propertyID)); err != nil {
+		apierr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "favorite_removed"})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+)
+
+type reviewListingRequest struct {
+	Approved *bool  `json:"approved" binding:"required"`
+	Reason   string `json:"reason" binding:"max=500"`
+}
+
+// ReviewListing approves or rejects a listing and tells the owner.
+func (h *PropertiesHandler) ReviewListing(c *gin.Context) {
+	reviewerID, _ := currentUserID(c)
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidPropertyID))
+		return
+	}
+	var req reviewListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Bind(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	p, err := h.Properties.Review(ctx, reviewerID, uint(propertyID), *req.Approved, req.Reason)
+	if err != nil {
+		apierr.Abort(c, apierr.Map(err, apierr.CodePropertyNotFound))
+		return
+	}
+	if err := h.Center.ListingReviewed(ctx, *p, *req.Approved, p.ModerationReason); err != nil {
+		slog.ErrorContext(ctx, "properties: review notification", "property_id", p.ID, "err", err)
+	}
+	c.JSON(http.StatusOK, p.Redacted())
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+)
+
+// RateLimit allows each client IP at most limit requests per window.
+// A limit of 0 disables it.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimit(limit, window, func(c *gin.Context) string { return c.ClientIP() })
+}
+
+// UserRateLimit is RateLimit per signed-in user, for actions worth
+// limiting however many addresses a client has. It must run after
+// AuthMiddleware.
+func UserRateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimit(limit, window, func(c *gin.Context) string {
+		id, _ := currentUserID(c)
+		return strconv.FormatUint(uint64(id), 10)
+	})
+}
+
+func rateLimit(limit int, window time.Duration, key func(c *gin.Context) string) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	l := &ipLimiter{limit: limit, window: window, seen: make(map[string]*ipWindow)}
+	return func(c *gin.Context) {
+		if wait, ok := l.allow(key(c), time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			apierr.Abort(c, apierr.New(apierr.CodeRateLimited))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ipLimiter counts requests in fixed windows per key.
+type ipLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu    sync.Mutex
+	seen  map[string]*ipWindow
+	swept time.Time
+}
+
+type ipWindow struct {
+	start time.Time
+	count int
+}
+
+// allow records a request and reports whether it is within the limit, and
+// if not, how long until the window resets.
+func (l *ipLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// forget clients whose window ended so the map does not grow forever
+	if now.Sub(l.swept) > l.window {
+		for k, w := range l.seen {
+			if now.Sub(w.start) >= l.window {
+				delete(l.seen, k)
+			}
+		}
+		l.swept = now
+	}
+
+	w := l.seen[key]
+	if w == nil || now.Sub(w.start) >= l.window {
+		w = &ipWindow{start: now}
+		l.seen[key] = w
+	}
+	if w.count >= l.limit {
+		return w.start.Add(l.window).Sub(now), false
+	}
+	w.count++
+	return 0, true
+}
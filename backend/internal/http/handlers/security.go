@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaders sets the hardening headers configured in cfg.Security on
+// every response.
+func SecurityHeaders(cfg *config.Config) gin.HandlerFunc {
+	headers := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": cfg.Security.CSP,
+		"X-Frame-Options":         cfg.Security.FrameOptions,
+		"Referrer-Policy":         cfg.Security.ReferrerPolicy,
+	}
+	if age := int(cfg.Security.HSTSMaxAge.Seconds()); age > 0 {
+		headers["Strict-Transport-Security"] = "max-age=" + strconv.Itoa(age) + "; includeSubDomains"
+	}
+	for k, v := range headers {
+		if v == "" {
+			delete(headers, k)
+		}
+	}
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		for k, v := range headers {
+			h.Set(k, v)
+		}
+		c.Next()
+	}
+}
+
+// CheckOrigin returns a WebSocket origin check for the CORS allowlist.
+// Requests without an Origin header come from non-browser clients and
+// pass, as with gorilla's default check.
+func CheckOrigin(origins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[strings.ToLower(o)] = true
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || allowed[strings.ToLower(origin)]
+	}
+}
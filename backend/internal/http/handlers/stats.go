@@ -4,43 +4,44 @@ import (
 	"net/http"
 
 	"gofuckbiz/snimayprosto-rent-easy/internal/config"
-	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type StatsHandler struct {
-	DB  *gorm.DB
-	Cfg *config.Config
+	Services *service.Services
+	Cfg      *config.Config
 }
 
-func NewStatsHandler(db *gorm.DB, cfg *config.Config) *StatsHandler {
+func NewStatsHandler(services *service.Services, cfg *config.Config) *StatsHandler {
 	return &StatsHandler{
-		DB:  db,
-		Cfg: cfg,
+		Services: services,
+		Cfg:      cfg,
 	}
 }
 
 func (h *StatsHandler) GetStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
 	// Count properties
-	var propertyCount int64
-	if err := h.DB.Model(&core.Property{}).Count(&propertyCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed_to_count_properties"})
+	propertyCount, err := h.Services.Properties.Count(ctx)
+	if err != nil {
+		apierr.Abort(c, err)
 		return
 	}
 
 	// Count users
-	var userCount int64
-	if err := h.DB.Model(&core.User{}).Count(&userCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed_to_count_users"})
+	userCount, err := h.Services.Users.Count(ctx)
+	if err != nil {
+		apierr.Abort(c, err)
 		return
 	}
 
 	// Count conversations (as a proxy for satisfied customers)
-	var conversationCount int64
-	if err := h.DB.Model(&core.Conversation{}).Count(&conversationCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed_to_count_conversations"})
+	if _, err := h.Services.Chat.CountConversations(ctx); err != nil {
+		apierr.Abort(c, err)
 		return
 	}
 
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
+)
+
+// Observe wraps each request in a server span, continuing the caller's
+// trace when it sent a traceparent header, and records its latency by
+// route template. It must run after RequestID.
+func Observe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		telemetry.HTTPInFlight.Inc()
+		defer telemetry.HTTPInFlight.Dec()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched" // keeps 404 scans from adding series
+		}
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := telemetry.Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("request_id", c.GetString("requestId")),
+			))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if userID, ok := currentUserID(c); ok {
+			span.SetAttributes(attribute.Int64("user_id", int64(userID)))
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		telemetry.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Metrics serves the Prometheus metrics, behind a bearer token when
+// METRICS_TOKEN is set.
+func Metrics(cfg *config.Config) gin.HandlerFunc {
+	h := telemetry.Handler()
+	want := []byte("Bearer " + cfg.Metrics.Token)
+	return func(c *gin.Context) {
+		if cfg.Metrics.Token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), want) != 1 {
+			apierr.Abort(c, apierr.New(apierr.CodeInvalidMetricsToken))
+			return
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
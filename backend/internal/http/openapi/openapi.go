@@ -0,0 +1,113 @@
+// Package openapi holds the OpenAPI 3 description of the API, serves it at
+// /openapi.json and checks requests and responses against it.
+//
+// openapi.yaml is the contract with the web app: change it together with
+// the handlers. The router tests fail when a route is missing from it or a
+// response does not match it.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+func init() {
+	// Formats the document uses besides date-time.
+	openapi3.DefineStringFormatValidator("email", openapi3.NewRegexpFormatValidator(openapi3.FormatOfStringForEmail))
+	openapi3.DefineStringFormatValidator("uri", openapi3.NewCallbackValidator(func(s string) error {
+		u, err := url.ParseRequestURI(s)
+		if err == nil && (u.Scheme == "" || u.Host == "") {
+			err = errors.New("not an absolute URL")
+		}
+		return err
+	}))
+}
+
+// Load parses and checks the embedded document.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+var shared = sync.OnceValues(Load)
+
+// MustLoad is Load for the embedded document, which the tests keep valid.
+// The document is loaded once and shared; do not change it.
+func MustLoad() *openapi3.T {
+	doc, err := shared()
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+	return doc
+}
+
+// Handler serves the document as JSON.
+func Handler(doc *openapi3.T) gin.HandlerFunc {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
+
+// Key names an operation by method and full path, e.g.
+// "GET /api/v1/properties/{id}". Gin paths are converted, so
+// Key("GET", "/api/v1/properties/:id") is the same key.
+func Key(method, path string) string {
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		if s != "" && (s[0] == ':' || s[0] == '*') {
+			segs[i] = "{" + s[1:] + "}"
+		}
+	}
+	return method + " " + strings.Join(segs, "/")
+}
+
+// Operations lists the operations of doc by Key.
+func Operations(doc *openapi3.T) map[string]*routers.Route {
+	ops := map[string]*routers.Route{}
+	for path, item := range doc.Paths.Map() {
+		servers := doc.Servers
+		if len(item.Servers) > 0 {
+			servers = item.Servers
+		}
+		base := ""
+		var server *openapi3.Server
+		if len(servers) > 0 {
+			server = servers[0]
+			base = strings.TrimSuffix(server.URL, "/")
+		}
+		for method, op := range item.Operations() {
+			ops[Key(method, base+path)] = &routers.Route{
+				Spec:      doc,
+				Server:    server,
+				Path:      path,
+				PathItem:  item,
+				Method:    method,
+				Operation: op,
+			}
+		}
+	}
+	return ops
+}
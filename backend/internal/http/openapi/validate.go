@@ -0,0 +1,311 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+)
+
+// Validator checks requests and responses against the document.
+//
+// A request that breaks it does not reach the handler: it gets
+// invalid_request with field errors, or the x-error-code of the broken
+// parameter, so clients see the codes the handlers use. A response that
+// breaks it, or a route the document does not describe, goes to Report;
+// the client gets the response as it is.
+//
+// The two halves are separate middlewares, see Requests and Responses.
+type Validator struct {
+	ops      map[string]*routers.Route
+	errorDoc *openapi3.Schema
+
+	// Report is told about every mismatch. It logs at error level by
+	// default; tests fail instead.
+	Report func(c *gin.Context, err error)
+}
+
+func NewValidator(doc *openapi3.T) *Validator {
+	v := &Validator{ops: Operations(doc)}
+	if ref := doc.Components.Schemas["Error"]; ref != nil {
+		v.errorDoc = ref.Value
+	}
+	return v
+}
+
+func (v *Validator) report(c *gin.Context, err error) {
+	if v.Report != nil {
+		v.Report(c, err)
+		return
+	}
+	slog.ErrorContext(c.Request.Context(), "openapi: contract violation", "err", err)
+}
+
+func (v *Validator) route(c *gin.Context) (*routers.Route, string) {
+	method, path := c.Request.Method, c.FullPath()
+	if path == "" || method == http.MethodOptions || method == http.MethodHead {
+		return nil, ""
+	}
+	key := Key(method, path)
+	return v.ops[key], key
+}
+
+func (v *Validator) input(c *gin.Context, route *routers.Route) *openapi3filter.RequestValidationInput {
+	params := map[string]string{}
+	for _, p := range c.Params {
+		params[p.Key] = strings.TrimPrefix(p.Value, "/") // *filepath starts with a slash
+	}
+	return &openapi3filter.RequestValidationInput{
+		Request:    c.Request,
+		PathParams: params,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			MultiError:          true,
+			AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc, // the handlers check tokens
+			SkipSettingDefaults: true,
+			// uploads are checked by the handler
+			ExcludeRequestBody: strings.HasPrefix(c.ContentType(), "multipart/"),
+		},
+	}
+}
+
+// Requests rejects requests that break the document. It goes right before
+// the handlers, after rate limits and auth, so that those answer first as
+// they do without it.
+func (v *Validator) Requests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, _ := v.route(c)
+		if route == nil {
+			return
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), v.input(c, route)); err != nil {
+			apierr.Abort(c, requestError(err))
+		}
+	}
+}
+
+// Responses reports responses that break the document and routes missing
+// from it. It goes outside apierr.Middleware so that it sees rendered
+// errors.
+func (v *Validator) Responses() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, key := v.route(c)
+		if route == nil {
+			if key != "" {
+				v.report(c, fmt.Errorf("%s is not in the OpenAPI document", key))
+			}
+			c.Next()
+			return
+		}
+
+		w := &recorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if !isJSON(w.Header().Get("Content-Type")) {
+			return // streams, sockets, files
+		}
+		if err := v.checkResponse(c.Request.Context(), v.input(c, route), w); err != nil {
+			v.report(c, fmt.Errorf("%s: response %d: %w", key, w.Status(), err))
+		}
+	}
+}
+
+func (v *Validator) checkResponse(ctx context.Context, in *openapi3filter.RequestValidationInput, w *recorder) error {
+	status := w.Status()
+	if in.Route.Operation.Responses.Status(status) == nil && status >= 400 && v.errorDoc != nil {
+		// Errors any route may answer, like rate_limited or a missing CSRF
+		// token, are not listed on every operation.
+		var body any
+		if err := json.Unmarshal(w.body.Bytes(), &body); err != nil {
+			return err
+		}
+		return v.errorDoc.VisitJSON(body, openapi3.MultiErrors())
+	}
+	out := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: in,
+		Status:                 status,
+		Header:                 w.Header(),
+		Options:                &openapi3filter.Options{MultiError: true, IncludeResponseStatus: true},
+	}
+	return openapi3filter.ValidateResponse(ctx, out.SetBodyBytes(w.body.Bytes()))
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
+
+// recorder keeps a copy of JSON bodies.
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	if isJSON(w.Header().Get("Content-Type")) {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	if isJSON(w.Header().Get("Content-Type")) {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// requestError turns validation errors into what the handler would have
+// answered: the x-error-code of a parameter, or invalid_request with a
+// field error in the terms of the binding validator.
+func requestError(err error) *apierr.Error {
+	var errs openapi3.MultiError
+	if !errors.As(err, &errs) {
+		errs = openapi3.MultiError{err}
+	}
+	var fields []apierr.FieldError
+	for _, err := range errs {
+		var re *openapi3filter.RequestError
+		if !errors.As(err, &re) {
+			continue
+		}
+		if p := re.Parameter; p != nil {
+			if code, ok := p.Extensions["x-error-code"].(string); ok {
+				return apierr.New(apierr.Code(code)).Wrap(err)
+			}
+			fields = append(fields, paramField(p, re.Err))
+			continue
+		}
+		fields = append(fields, bodyFields(re.Err)...)
+	}
+	return apierr.Invalid(fields...).Wrap(err)
+}
+
+func paramField(p *openapi3.Parameter, err error) apierr.FieldError {
+	var se *openapi3.SchemaError
+	switch {
+	case errors.Is(err, openapi3filter.ErrInvalidRequired):
+		return apierr.FieldError{Field: p.Name, Rule: "required"}
+	case errors.As(err, &se):
+		f := schemaField(se)
+		f.Field = p.Name
+		return f
+	}
+	rule := "type"
+	if s := p.Schema; s != nil && s.Value != nil && (s.Value.Type.Is("integer") || s.Value.Type.Is("number")) {
+		rule = "number"
+	}
+	return apierr.FieldError{Field: p.Name, Rule: rule}
+}
+
+func bodyFields(err error) []apierr.FieldError {
+	var errs openapi3.MultiError
+	if !errors.As(err, &errs) {
+		errs = openapi3.MultiError{err}
+	}
+	var fields []apierr.FieldError
+	seen := map[string]bool{}
+	for _, err := range errs {
+		var se *openapi3.SchemaError
+		if !errors.As(err, &se) {
+			continue
+		}
+		// an exclusive bound fails both minimum and exclusiveMinimum
+		f := schemaField(se)
+		if key := f.Field + ":" + f.Rule; !seen[key] {
+			seen[key] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// schemaField names the failed keyword the way binding tags do: minLength
+// is min, enum is oneof and so on.
+func schemaField(se *openapi3.SchemaError) apierr.FieldError {
+	f := apierr.FieldError{Field: fieldPath(se.JSONPointer()), Rule: se.SchemaField}
+	s := se.Schema
+	if s == nil {
+		return f
+	}
+	switch se.SchemaField {
+	case "minLength":
+		f.Rule, f.Param, f.Kind = "min", strconv.FormatUint(s.MinLength, 10), reflect.String
+		if s.MinLength == 1 {
+			f.Rule, f.Param = "required", ""
+		}
+	case "maxLength":
+		f.Rule, f.Kind = "max", reflect.String
+		if s.MaxLength != nil {
+			f.Param = strconv.FormatUint(*s.MaxLength, 10)
+		}
+	case "minItems":
+		f.Rule, f.Param, f.Kind = "min", strconv.FormatUint(s.MinItems, 10), reflect.Slice
+	case "maxItems":
+		f.Rule, f.Kind = "max", reflect.Slice
+		if s.MaxItems != nil {
+			f.Param = strconv.FormatUint(*s.MaxItems, 10)
+		}
+	case "minimum", "exclusiveMinimum":
+		f.Rule, f.Kind = "min", reflect.Float64
+		if s.ExclusiveMin {
+			f.Rule, f.Kind = "gt", reflect.Invalid
+		}
+		if s.Min != nil {
+			f.Param = strconv.FormatFloat(*s.Min, 'f', -1, 64)
+		}
+	case "maximum", "exclusiveMaximum":
+		f.Rule, f.Kind = "max", reflect.Float64
+		if s.ExclusiveMax {
+			f.Rule, f.Kind = "lt", reflect.Invalid
+		}
+		if s.Max != nil {
+			f.Param = strconv.FormatFloat(*s.Max, 'f', -1, 64)
+		}
+	case "enum":
+		vals := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			vals[i] = fmt.Sprint(v)
+		}
+		f.Rule, f.Param = "oneof", strings.Join(vals, " ")
+	case "format":
+		f.Rule = s.Format
+		if s.Format == "uri" {
+			f.Rule = "url"
+		}
+	case "type", "nullable":
+		f.Rule, f.Param = "type", strings.Join(s.Type.Slice(), ",")
+	}
+	return f
+}
+
+// fieldPath joins a JSON pointer the way validator namespaces read:
+// ["images", "0", "url"] is "images[0].url".
+func fieldPath(ptr []string) string {
+	var b strings.Builder
+	for _, seg := range ptr {
+		if _, err := strconv.Atoi(seg); err == nil {
+			b.WriteString("[" + seg + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
@@ -1,33 +0,0 @@
-package http
-
-import (
-	"net/http"
-
-	gin "github.com/gin-gonic/gin"
-)
-
-func NewRouter() *gin.Engine {
-	r := gin.Default()
-
-	// Minimal CORS for dev without external deps
-	r.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
-
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"ok": true})
-	})
-
-	// auth routes will be attached in handlers package
-
-	return r
-}
-
-
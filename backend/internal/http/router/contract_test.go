@@ -0,0 +1,272 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/openapi"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/router"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// newContractEngine is newEngine with the OpenAPI checks on; a response
+// that does not match the document fails the test.
+func newContractEngine(t *testing.T, cfg *config.Config) (*gin.Engine, router.Deps) {
+	d := newDeps(t, cfg)
+	d.Contract = openapi.NewValidator(openapi.MustLoad())
+	d.Contract.Report = func(c *gin.Context, err error) { t.Errorf("openapi: %v", err) }
+	return router.New(d), d
+}
+
+func TestSpecCoversRoutes(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	ops := openapi.Operations(openapi.MustLoad())
+	routes := map[string]bool{}
+	for _, rt := range r.Routes() {
+		if rt.Method == http.MethodHead { // added by r.Static
+			continue
+		}
+		key := openapi.Key(rt.Method, rt.Path)
+		routes[key] = true
+		if ops[key] == nil {
+			t.Errorf("%s is not in openapi.yaml", key)
+		}
+	}
+	for key := range ops {
+		if !routes[key] {
+			t.Errorf("%s is in openapi.yaml but not routed", key)
+		}
+	}
+}
+
+func TestSpecServed(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	w := serve(r, "GET", "/openapi.json", "", nil)
+	var doc struct {
+		OpenAPI string
+		Paths   map[string]any
+	}
+	json.Unmarshal(w.Body.Bytes(), &doc)
+	if w.Code != http.StatusOK || !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Paths["/properties/{id}"] == nil {
+		t.Fatalf("openapi.json: %d %.200s", w.Code, w.Body)
+	}
+}
+
+// TestContract walks the API like the web app does with the checks on.
+// Notifications need a database and are covered by the apitest suite.
+func TestContract(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Chat.Filter.Phones = "hold"
+	r, d := newContractEngine(t, cfg)
+	csrf := bootstrapCSRF(t, r, nil)
+	call := func(method, path, body string, header map[string]string, want int) *httptest.ResponseRecorder {
+		t.Helper()
+		w := serve(r, method, path, body, header)
+		if w.Code != want {
+			t.Fatalf("%s %s: %d %s, want %d", method, path, w.Code, w.Body, want)
+		}
+		return w
+	}
+	api := router.Prefix
+	register := func(email string) (map[string]string, uint) {
+		t.Helper()
+		w := call("POST", api+"/auth/register",
+			`{"email":"`+email+`","password":"secret123","firstName":"A","lastName":"B","phone":"+79990000000"}`, csrf, http.StatusCreated)
+		var body struct {
+			AccessToken string
+			User        struct{ ID uint }
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		h := with(csrf, "Authorization", "Bearer "+body.AccessToken)
+		for _, ck := range w.Result().Cookies() {
+			h["Cookie"] += "; " + ck.Name + "=" + ck.Value
+		}
+		return h, body.User.ID
+	}
+
+	for _, path := range []string{"/healthz", "/health", "/readyz", "/metrics", "/openapi.json", api + "/stats"} {
+		call("GET", path, "", nil, http.StatusOK)
+	}
+
+	owner, _ := register("owner@example.com")
+	tenant, tenantID := register("tenant@example.com")
+	// empty lists are [], not null
+	call("GET", api+"/properties", "", nil, http.StatusOK)
+	call("GET", api+"/properties/my", "", owner, http.StatusOK)
+	call("GET", api+"/chat/conversations", "", owner, http.StatusOK)
+	call("GET", api+"/chat/blocks", "", owner, http.StatusOK)
+	call("POST", api+"/auth/login", `{"email":"owner@example.com","password":"secret123"}`, csrf, http.StatusOK)
+	call("POST", api+"/auth/refresh", "", owner, http.StatusOK)
+	call("GET", api+"/auth/me", "", owner, http.StatusOK)
+	call("PUT", api+"/auth/role", `{"role":"landlord"}`, owner, http.StatusOK)
+
+	call("GET", api+"/plans/my", "", owner, http.StatusOK)
+	call("POST", api+"/plans/upgrade", `{"planType":"premium"}`, owner, http.StatusOK)
+
+	w := call("POST", api+"/properties", `{"title":"Flat","address":"Москва, ул. Тверская, 1","propertyType":"apartment",
+		"rooms":2,"area":54,"price":50000,"priceType":"month","phone":"8 (999) 000-00-00","visibility":"public","amenities":["internet","tv"],
+		"latitude":55.76,"longitude":37.61,"floor":3,"totalFloors":9,"petsAllowed":true,"deposit":50000}`,
+		owner, http.StatusCreated)
+	var prop struct{ ID, OwnerID uint }
+	json.Unmarshal(w.Body.Bytes(), &prop)
+	propPath := fmt.Sprintf("%s/properties/%d", api, prop.ID)
+	reviewPath := fmt.Sprintf("%s/moderation/properties/%d/review", api, prop.ID)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("images", "a.jpg")
+	fw.Write([]byte("\xff\xd8\xff"))
+	mw.Close()
+	w = call("POST", propPath+"/images", buf.String(), with(owner, "Content-Type", mw.FormDataContentType()), http.StatusOK)
+	var imgs struct{ Images []struct{ URL string } }
+	json.Unmarshal(w.Body.Bytes(), &imgs)
+	if len(imgs.Images) != 1 {
+		t.Fatalf("upload: %s", w.Body)
+	}
+	call("GET", imgs.Images[0].URL, "", nil, http.StatusOK)
+
+	call("GET", api+"/properties", "", nil, http.StatusOK)
+	call("GET", api+"/properties?city="+url.QueryEscape("Москва"), "", nil, http.StatusOK)
+	call("GET", api+"/properties?amenities=internet,tv&pets=true", "", nil, http.StatusOK)
+	call("GET", api+"/amenities", "", nil, http.StatusOK)
+	call("GET", propPath, "", nil, http.StatusOK)
+	call("POST", propPath+"/contacts/reveal", "", tenant, http.StatusOK)
+	call("PUT", propPath+"/contacts", `{"phoneVisibility":"hidden","emailVisibility":"hidden"}`, owner, http.StatusOK)
+	call("POST", propPath+"/contacts/reveal", "", tenant, http.StatusForbidden)
+	call("PUT", propPath+"/contacts", `{"phoneVisibility":"public","emailVisibility":"on_request"}`, owner, http.StatusOK)
+	call("GET", api+"/promotions/products", "", nil, http.StatusOK)
+	call("POST", propPath+"/promote", "", owner, http.StatusOK)
+	call("POST", propPath+"/promote", `{"product":"urgent_7d"}`, owner, http.StatusOK)
+	call("POST", propPath+"/promote", `{"product":"top_city_3d","startsAt":"`+time.Now().Add(time.Hour).Format(time.RFC3339)+`"}`, owner, http.StatusConflict)
+	call("POST", propPath+"/promote", `{"product":"gold"}`, owner, http.StatusBadRequest)
+	call("GET", propPath+"/promotions", "", owner, http.StatusOK)
+	call("GET", api+"/plans/my", "", owner, http.StatusOK)
+	call("GET", api+"/properties/my", "", owner, http.StatusOK)
+
+	w = call("POST", fmt.Sprintf("%s/chat/start/%d", api, prop.ID), "", tenant, http.StatusOK)
+	var conv struct{ ID uint }
+	json.Unmarshal(w.Body.Bytes(), &conv)
+	ctx := context.Background()
+	c, err := d.Services.Chat.Participant(ctx, conv.ID, tenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent, err := d.Services.Chat.Send(ctx, c, tenantID, "Hello")
+	if err != nil || sent.Message == nil {
+		t.Fatalf("send: %+v %v", sent, err)
+	}
+	if _, err := d.Services.Chat.Send(ctx, c, tenantID, "call me +7 999 123 45 67"); err != nil {
+		t.Fatal(err)
+	}
+	convPath := fmt.Sprintf("%s/chat/%d/messages", api, conv.ID)
+	msgPath := fmt.Sprintf("%s/%d", convPath, sent.Message.ID)
+	call("GET", api+"/chat/conversations", "", owner, http.StatusOK)
+	call("GET", convPath, "", owner, http.StatusOK)
+	call("GET", convPath+"?before=1", "", owner, http.StatusOK)
+	call("GET", convPath+"?limit=1&before=100", "", owner, http.StatusOK)
+	call("GET", convPath+"/sync?since=2020-01-01T00:00:00Z", "", owner, http.StatusOK)
+	call("PUT", msgPath, `{"content":"Hello!"}`, tenant, http.StatusOK)
+	call("PUT", msgPath, `{"content":"Hi"}`, owner, http.StatusForbidden)
+	call("DELETE", msgPath, "", tenant, http.StatusOK)
+	call("DELETE", msgPath, "", tenant, http.StatusConflict)
+	call("POST", fmt.Sprintf("%s/chat/blocks/%d", api, tenantID), "", owner, http.StatusOK)
+	call("GET", api+"/chat/blocks", "", owner, http.StatusOK)
+	call("DELETE", fmt.Sprintf("%s/chat/blocks/%d", api, tenantID), "", owner, http.StatusOK)
+	call("PUT", propPath+"/favorite", "", tenant, http.StatusOK)
+	call("DELETE", propPath+"/favorite", "", tenant, http.StatusOK)
+	call("GET", propPath+"/analytics", "", owner, http.StatusOK)
+	call("GET", propPath+"/analytics", "", tenant, http.StatusNotFound)
+	call("GET", api+"/properties/my/analytics?from=2026-01-01&to=2026-01-31", "", owner, http.StatusOK)
+
+	admin, adminID := register("admin@example.com")
+	if err := d.Services.Users.UpdateRole(ctx, adminID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	call("GET", api+"/admin/audit", "", admin, http.StatusOK)
+	call("GET", api+"/admin/audit?limit=0", "", admin, http.StatusBadRequest)
+	call("GET", api+"/moderation/chat/flagged", "", admin, http.StatusOK)
+	call("GET", api+"/moderation/chat/flagged?status=all&limit=5", "", admin, http.StatusOK)
+	flagged, err := d.Services.Chat.Flagged(ctx, "pending", 0, 10)
+	if err != nil || len(flagged) != 1 {
+		t.Fatalf("flagged: %+v %v", flagged, err)
+	}
+	call("POST", fmt.Sprintf("%s/moderation/chat/flagged/%d/release", api, flagged[0].ID), "", admin, http.StatusOK)
+	call("POST", fmt.Sprintf("%s/moderation/chat/flagged/%d/dismiss", api, flagged[0].ID), "", admin, http.StatusConflict)
+	call("POST", reviewPath, `{"approved":true}`, owner, http.StatusForbidden)
+	call("POST", reviewPath, `{"reason":"x"}`, admin, http.StatusBadRequest)
+	call("POST", reviewPath, `{"approved":false,"reason":"Нет фото квартиры"}`, admin, http.StatusOK)
+	call("GET", propPath, "", nil, http.StatusNotFound)
+	call("GET", api+"/properties/my", "", owner, http.StatusOK)
+	notes, _, err := d.Services.Notifications.List(ctx, service.NotificationFilter{UserID: prop.OwnerID, Limit: 10})
+	if err != nil || len(notes) == 0 || notes[0].Type != core.NotificationListingRejected {
+		t.Fatalf("owner notifications: %+v %v", notes, err)
+	}
+	call("POST", reviewPath, `{"approved":true}`, admin, http.StatusOK)
+	call("GET", propPath, "", nil, http.StatusOK)
+
+	call("DELETE", propPath, "", owner, http.StatusOK)
+	call("GET", propPath, "", nil, http.StatusNotFound)
+	call("POST", api+"/auth/logout", "", owner, http.StatusOK)
+}
+
+// Bad requests get the same answer whether the document or the handler
+// catches them.
+func TestContractErrorsMatchHandlers(t *testing.T) {
+	plain := newEngine(t, config.Defaults())
+	checked, _ := newContractEngine(t, config.Defaults())
+	csrf := bootstrapCSRF(t, plain, nil)
+	api := router.Prefix
+	w := serve(plain, "POST", api+"/auth/register", `{"email":"owner@example.com","password":"secret123","firstName":"A","lastName":"B"}`, csrf)
+	var reg struct{ AccessToken string }
+	json.Unmarshal(w.Body.Bytes(), &reg)
+	owner := with(csrf, "Authorization", "Bearer "+reg.AccessToken) // tokens are valid on both engines
+	for _, tc := range []struct {
+		method, path, body string
+		header             map[string]string
+	}{
+		{"POST", api + "/auth/register", `{"email":"nope","password":"123","firstName":"A"}`, csrf},
+		{"POST", api + "/auth/login", `{"password":"x"}`, csrf},
+		{"GET", api + "/properties/abc", "", csrf},
+		{"DELETE", api + "/properties/-1", "", csrf},
+		{"GET", api + "/properties/my/analytics?from=yesterday", "", owner},
+		{"POST", api + "/properties", `{"title":"Дом","address":"Сочи","propertyType":"castle","rooms":25,"area":120,
+			"price":-1,"priceType":"week","phone":"+79990000000","visibility":"public","latitude":43.6,"longitude":39.7}`, owner},
+	} {
+		want := serve(plain, tc.method, tc.path, tc.body, tc.header)
+		got := serve(checked, tc.method, tc.path, tc.body, tc.header)
+		if got.Code != want.Code || fieldRules(t, got) != fieldRules(t, want) {
+			t.Errorf("%s %s:\n checked %d %s\n handler %d %s", tc.method, tc.path, got.Code, got.Body, want.Code, want.Body)
+		}
+	}
+}
+
+// fieldRules sums up an error as "code field:rule ...", sorted by field.
+func fieldRules(t *testing.T, w *httptest.ResponseRecorder) string {
+	var body struct {
+		Error  string
+		Fields []struct{ Field, Rule string }
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: %v", w.Body, err)
+	}
+	rules := make([]string, 0, len(body.Fields))
+	for _, f := range body.Fields {
+		rules = append(rules, f.Field+":"+f.Rule)
+	}
+	sort.Strings(rules)
+	return body.Error + " " + strings.Join(rules, " ")
+}
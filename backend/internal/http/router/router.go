@@ -0,0 +1,154 @@
+// Package router mounts every handler under /api/v1 with the shared
+// middleware chains. app.New builds the Deps, so cmd/api and the
+// integration tests serve the same routes.
+package router
+
+import (
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/apierr"
+	handlers "gofuckbiz/snimayprosto-rent-easy/internal/http/handlers"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/openapi"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// Prefix is where the API is mounted.
+const Prefix = "/api/v1"
+
+// Deps is everything the routes need.
+type Deps struct {
+	Cfg      *config.Config
+	Services *service.Services
+
+	Auth          *handlers.AuthHandler
+	Properties    *handlers.PropertiesHandler
+	Plans         *handlers.PlansHandler
+	Chat          *handlers.ChatHandler
+	Notifications *handlers.NotificationsHandler
+	Stats         *handlers.StatsHandler
+	Audit         *handlers.AuditHandler
+	Analytics     *handlers.AnalyticsHandler
+	Health        *handlers.HealthHandler
+
+	Contract *openapi.Validator // nil skips the request and response checks
+}
+
+// New builds the engine:
+//
+//	/healthz, /readyz, /metrics,   outside the API, no limits
+//	/openapi.json, /uploads/*
+//	/api/v1                        rate limit, CSRF on state-changing requests
+//	  /auth/register, ...          stricter rate limit
+//	  private routes               + auth
+//	    /moderation, /admin        + admin role
+func New(d Deps) *gin.Engine {
+	cfg := d.Cfg
+
+	// contract checks are no-ops unless enabled
+	checkRequests, checkResponses := func(*gin.Context) {}, func(*gin.Context) {}
+	if d.Contract != nil {
+		checkRequests, checkResponses = d.Contract.Requests(), d.Contract.Responses()
+	}
+
+	r := gin.New()
+	r.Use(handlers.RequestID(), handlers.Observe(), handlers.AccessLog(), handlers.Recovery(), checkResponses, apierr.Middleware(), handlers.SecurityHeaders(cfg))
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CORS.Origins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token", handlers.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", handlers.RequestIDHeader},
+		AllowCredentials: true,
+	}))
+
+	r.NoRoute(func(c *gin.Context) { apierr.Abort(c, apierr.New(apierr.CodeNotFound)) })
+
+	r.GET("/healthz", d.Health.Live)
+	r.GET("/health", d.Health.Live) // old name of /healthz
+	r.GET("/readyz", d.Health.Ready)
+	if cfg.Metrics.Enabled {
+		r.GET("/metrics", handlers.Metrics(cfg))
+	}
+	r.Static("/uploads", cfg.Uploads.Dir)
+	r.GET("/openapi.json", openapi.Handler(openapi.MustLoad()))
+
+	api := r.Group(Prefix,
+		handlers.RateLimit(cfg.RateLimit.Requests, cfg.RateLimit.Window),
+		handlers.CSRFMiddleware(cfg))
+	public := api.Group("", checkRequests)
+	private := api.Group("", handlers.AuthMiddleware(cfg), checkRequests)
+
+	// auth
+	signIn := api.Group("/auth", handlers.RateLimit(cfg.RateLimit.AuthRequests, cfg.RateLimit.Window), checkRequests)
+	signIn.POST("/register", d.Auth.Register)
+	signIn.POST("/login", d.Auth.Login)
+	signIn.POST("/refresh", d.Auth.Refresh)
+	public.GET("/auth/csrf", d.Auth.CSRF)
+	public.POST("/auth/logout", d.Auth.Logout)
+	private.GET("/auth/me", d.Auth.Me)
+	private.PUT("/auth/role", d.Auth.UpdateRole)
+
+	// properties
+	public.GET("/properties", d.Properties.List)
+	public.GET("/properties/:id", handlers.OptionalAuth(cfg), d.Properties.Get)
+	public.GET("/amenities", d.Properties.Amenities)
+	private.POST("/properties", d.Properties.Create)
+	private.GET("/properties/my", d.Properties.MyListings)
+	private.POST("/properties/:id/images", d.Properties.UploadImages)
+	private.POST("/properties/:id/promote", d.Properties.PromoteProperty)
+	private.GET("/properties/:id/promotions", d.Properties.Promotions)
+	public.GET("/promotions/products", d.Properties.PromotionProducts)
+	private.DELETE("/properties/:id", d.Properties.DeleteProperty)
+	private.POST("/properties/:id/contacts/reveal",
+		handlers.UserRateLimit(cfg.RateLimit.Reveals, cfg.RateLimit.RevealWindow), d.Properties.RevealContacts)
+	private.PUT("/properties/:id/contacts", d.Properties.SetContactVisibility)
+	private.PUT("/properties/:id/favorite", d.Properties.AddFavorite)
+	private.DELETE("/properties/:id/favorite", d.Properties.RemoveFavorite)
+	private.GET("/properties/:id/analytics", d.Analytics.Listing)
+	private.GET("/properties/my/analytics", d.Analytics.Account)
+
+	// plans
+	private.GET("/plans/my", d.Plans.GetMyPlan)
+	private.POST("/plans/upgrade", d.Plans.UpgradePlan)
+
+	// chat
+	private.POST("/chat/start/:propertyId", d.Chat.StartConversation)
+	private.GET("/chat/conversations", d.Chat.ListConversations)
+	private.GET("/chat/:conversationId/messages", d.Chat.ListMessages)
+	private.GET("/chat/:conversationId/messages/sync", d.Chat.SyncMessages)
+	private.PUT("/chat/:conversationId/messages/:messageId", d.Chat.EditMessage)
+	private.DELETE("/chat/:conversationId/messages/:messageId", d.Chat.DeleteMessage)
+	private.GET("/chat/blocks", d.Chat.ListBlocked)
+	private.POST("/chat/blocks/:userId", d.Chat.BlockUser)
+	private.DELETE("/chat/blocks/:userId", d.Chat.UnblockUser)
+	public.GET("/ws/chat/:conversationId", d.Chat.Socket) // token in query param
+
+	// moderation
+	mod := private.Group("/moderation", handlers.RequireRole(d.Services.Users, "admin"))
+	mod.GET("/chat/flagged", d.Chat.ListFlagged)
+	mod.POST("/chat/flagged/:id/release", d.Chat.ReleaseFlagged)
+	mod.POST("/chat/flagged/:id/dismiss", d.Chat.DismissFlagged)
+	mod.POST("/properties/:id/review", d.Properties.ReviewListing)
+
+	// admin
+	admin := private.Group("/admin", handlers.RequireRole(d.Services.Users, "admin"))
+	admin.GET("/audit", d.Audit.List)
+
+	// notifications
+	private.GET("/notifications", d.Notifications.List)
+	private.GET("/notifications/unread-count", d.Notifications.UnreadCount)
+	private.POST("/notifications/:id/read", d.Notifications.MarkRead)
+	private.POST("/notifications/read-all", d.Notifications.MarkAllRead)
+	public.GET("/notifications/stream", d.Notifications.Stream) // SSE, token in header or query param
+	private.GET("/notifications/preferences", d.Notifications.GetPreferences)
+	private.PUT("/notifications/preferences", d.Notifications.UpdatePreferences)
+	public.GET("/notifications/push/key", d.Notifications.PushKey)
+	private.POST("/notifications/push/subscriptions", d.Notifications.SubscribePush)
+	private.DELETE("/notifications/push/subscriptions", d.Notifications.UnsubscribePush)
+
+	// stats
+	public.GET("/stats", d.Stats.GetStats)
+
+	return r
+}
@@ -0,0 +1,563 @@
+package router_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/health"
+	handlers "gofuckbiz/snimayprosto-rent-easy/internal/http/handlers"
+	"gofuckbiz/snimayprosto-rent-easy/internal/http/router"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// newEngine wires the router around in-memory repositories.
+func newEngine(t *testing.T, cfg *config.Config) *gin.Engine {
+	return router.New(newDeps(t, cfg))
+}
+
+func newDeps(t *testing.T, cfg *config.Config) router.Deps {
+	gin.SetMode(gin.TestMode)
+	cfg.Uploads.Dir = t.TempDir()
+	s := service.New(memory.NewRepositories(), cfg)
+	properties := handlers.NewPropertiesHandler(s.Properties, cfg)
+	properties.Center = notify.NewCenter(s.Notifications.Notifications)
+	return router.Deps{
+		Cfg:           cfg,
+		Services:      s,
+		Auth:          handlers.NewAuthHandler(s.Users, cfg),
+		Properties:    properties,
+		Plans:         handlers.NewPlansHandler(s.Plans, cfg),
+		Chat:          handlers.NewChatHandler(s.Chat, cfg),
+		Notifications: handlers.NewNotificationsHandler(s.Notifications, cfg),
+		Stats:         handlers.NewStatsHandler(s, cfg),
+		Audit:         handlers.NewAuditHandler(s.Audit, cfg),
+		Analytics:     handlers.NewAnalyticsHandler(s.Analytics, cfg),
+		Health:        handlers.NewHealthHandler(health.New(time.Second)),
+	}
+}
+
+func serve(r *gin.Engine, method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func errorCode(w *httptest.ResponseRecorder) string {
+	var e struct{ Error string }
+	json.Unmarshal(w.Body.Bytes(), &e)
+	return e.Error
+}
+
+func TestRoutesAreVersioned(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if w := serve(r, "GET", path, "", nil); w.Code != http.StatusOK {
+			t.Fatalf("%s: %d", path, w.Code)
+		}
+	}
+	if w := serve(r, "GET", "/stats", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unversioned /stats: %d, want 404", w.Code)
+	}
+	if w := serve(r, "GET", router.Prefix+"/stats", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("%s/stats: %d %s", router.Prefix, w.Code, w.Body)
+	}
+	if w := serve(r, "GET", router.Prefix+"/plans/my", "", nil); errorCode(w) != "missing_authorization" {
+		t.Fatalf("private route without token: %d %s", w.Code, w.Body)
+	}
+}
+
+// bootstrapCSRF fetches a token the way the web app does and returns the
+// headers that carry it.
+func bootstrapCSRF(t *testing.T, r *gin.Engine, header map[string]string) map[string]string {
+	t.Helper()
+	w := serve(r, "GET", router.Prefix+"/auth/csrf", "", header)
+	var body struct{ CSRFToken string }
+	json.Unmarshal(w.Body.Bytes(), &body)
+	var cookie *http.Cookie
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == handlers.CSRFTokenCookie {
+			cookie = ck
+		}
+	}
+	if w.Code != http.StatusOK || cookie == nil || cookie.Value != body.CSRFToken || !cookie.HttpOnly {
+		t.Fatalf("csrf bootstrap: %d %s, cookie %+v", w.Code, w.Body, cookie)
+	}
+	return map[string]string{
+		"Cookie":                 handlers.CSRFTokenCookie + "=" + body.CSRFToken,
+		handlers.CSRFTokenHeader: body.CSRFToken,
+	}
+}
+
+func with(h map[string]string, kv ...string) map[string]string {
+	out := make(map[string]string, len(h)+len(kv)/2)
+	for k, v := range h {
+		out[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		out[kv[i]] = kv[i+1]
+	}
+	return out
+}
+
+func TestCSRF(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	csrf := bootstrapCSRF(t, r, nil)
+	if again := bootstrapCSRF(t, r, csrf); again[handlers.CSRFTokenHeader] != csrf[handlers.CSRFTokenHeader] {
+		t.Fatal("bootstrap with a valid cookie issued a new token")
+	}
+
+	register := `{"email":"anna@example.com","password":"secret123","firstName":"Анна","lastName":"Петрова"}`
+	if w := serve(r, "POST", router.Prefix+"/auth/register", register, nil); errorCode(w) != "csrf_token_missing" {
+		t.Fatalf("register without token: %d %s", w.Code, w.Body)
+	}
+	w := serve(r, "POST", router.Prefix+"/auth/register", register, csrf)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: %d %s", w.Code, w.Body)
+	}
+	var reg struct{ AccessToken string }
+	json.Unmarshal(w.Body.Bytes(), &reg)
+	refreshCookie := w.Result().Cookies()[0]
+	if refreshCookie.Name != "refresh_token" {
+		t.Fatalf("cookie %s, want refresh_token", refreshCookie.Name)
+	}
+
+	// the cookie-authenticated endpoints
+	stolen := map[string]string{"Cookie": "refresh_token=" + refreshCookie.Value}
+	for _, path := range []string{"/auth/refresh", "/auth/logout"} {
+		if w := serve(r, "POST", router.Prefix+path, "", stolen); errorCode(w) != "csrf_token_missing" {
+			t.Fatalf("%s without token: %d %s", path, w.Code, w.Body)
+		}
+	}
+	w = serve(r, "POST", router.Prefix+"/auth/refresh", "", with(csrf,
+		"Cookie", csrf["Cookie"]+"; refresh_token="+refreshCookie.Value))
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh with token: %d %s", w.Code, w.Body)
+	}
+
+	role := `{"role":"landlord"}`
+	bearer := "Bearer " + reg.AccessToken
+	other := bootstrapCSRF(t, r, nil)
+	foreign := bootstrapCSRF(t, newEngine(t, func() *config.Config {
+		c := config.Defaults()
+		c.CSRF.Secret = "another secret"
+		return c
+	}()), nil)
+	// always change the first character, whatever the random token starts with
+	tampered := csrf[handlers.CSRFTokenHeader]
+	if tampered[0] == 'A' {
+		tampered = "B" + tampered[1:]
+	} else {
+		tampered = "A" + tampered[1:]
+	}
+	for name, h := range map[string]map[string]string{
+		"header only":    {handlers.CSRFTokenHeader: csrf[handlers.CSRFTokenHeader]},
+		"unsigned":       {"Cookie": handlers.CSRFTokenCookie + "=abc", handlers.CSRFTokenHeader: "abc"},
+		"other session":  with(csrf, handlers.CSRFTokenHeader, other[handlers.CSRFTokenHeader]),
+		"foreign secret": foreign,
+		"tampered nonce": with(csrf, handlers.CSRFTokenHeader, tampered),
+	} {
+		w := serve(r, "PUT", router.Prefix+"/auth/role", role, with(h, "Authorization", bearer))
+		if w.Code != http.StatusForbidden || !strings.HasPrefix(errorCode(w), "csrf_token_") {
+			t.Errorf("%s: %d %s", name, w.Code, w.Body)
+		}
+	}
+	if w := serve(r, "PUT", router.Prefix+"/auth/role", role, with(csrf, "Authorization", bearer)); w.Code != http.StatusOK {
+		t.Fatalf("valid token: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.RateLimit.AuthRequests = 2
+	r := newEngine(t, cfg)
+	csrf := bootstrapCSRF(t, r, nil)
+	login := `{"email":"nobody@example.com","password":"secret123"}`
+	for i := 0; i < 2; i++ {
+		if w := serve(r, "POST", router.Prefix+"/auth/login", login, csrf); w.Code != http.StatusUnauthorized {
+			t.Fatalf("login %d: %d %s", i, w.Code, w.Body)
+		}
+	}
+	w := serve(r, "POST", router.Prefix+"/auth/login", login, csrf)
+	if w.Code != http.StatusTooManyRequests || errorCode(w) != "rate_limited" || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("third login: %d %s", w.Code, w.Body)
+	}
+	// other routes have their own budget
+	if w := serve(r, "GET", router.Prefix+"/stats", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("stats after auth limit: %d", w.Code)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Security.HSTSMaxAge = 24 * time.Hour
+	cfg.Security.FrameOptions = ""
+	w := serve(newEngine(t, cfg), "GET", "/healthz", "", nil)
+	for k, want := range map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"Strict-Transport-Security": "max-age=86400; includeSubDomains",
+		"Content-Security-Policy":   cfg.Security.CSP,
+		"X-Frame-Options":           "",
+	} {
+		if got := w.Header().Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.CORS.Origins = []string{"https://snimayprosto.ru"}
+	r := newEngine(t, cfg)
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		return serve(r, "OPTIONS", router.Prefix+"/properties", "", map[string]string{
+			"Origin": origin, "Access-Control-Request-Method": "POST",
+		})
+	}
+	if w := preflight("https://snimayprosto.ru"); w.Header().Get("Access-Control-Allow-Origin") != "https://snimayprosto.ru" {
+		t.Fatalf("allowed origin: %d %v", w.Code, w.Header())
+	}
+	if w := preflight("http://localhost:5173"); w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("origin outside the allowlist: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestSocketOrigin(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.CORS.Origins = []string{"https://snimayprosto.ru"}
+	srv := httptest.NewServer(newEngine(t, cfg))
+	defer srv.Close()
+
+	csrf := bootstrapCSRF(t, newEngine(t, cfg), nil) // same secret, so valid here too
+	post := func(path, token, body string) map[string]interface{} {
+		req, _ := http.NewRequest("POST", srv.URL+router.Prefix+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range csrf {
+			req.Header.Set(k, v)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&out)
+		if resp.StatusCode >= 300 {
+			t.Fatalf("POST %s: %d %v", path, resp.StatusCode, out)
+		}
+		return out
+	}
+	register := func(email string) string {
+		out := post("/auth/register", "", `{"email":"`+email+`","password":"secret123","firstName":"A","lastName":"B"}`)
+		return out["accessToken"].(string)
+	}
+	owner, tenant := register("owner@example.com"), register("tenant@example.com")
+	prop := post("/properties", owner, `{"title":"Квартира","address":"Казань","propertyType":"apartment","rooms":1,"area":30,`+
+		`"price":20000,"priceType":"month","phone":"+79990001122","visibility":"public","latitude":55.79,"longitude":49.1}`)
+	conv := post(fmt.Sprintf("/chat/start/%v", prop["id"]), tenant, "")
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + fmt.Sprintf("%s/ws/chat/%v?token=%s", router.Prefix, conv["id"], tenant)
+	dial := func(origin string) (*websocket.Conn, *http.Response, error) {
+		return websocket.DefaultDialer.Dial(url, http.Header{"Origin": {origin}})
+	}
+	if _, resp, err := dial("https://evil.example"); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("foreign origin: %v %v", err, resp)
+	}
+	conn, _, err := dial("https://snimayprosto.ru")
+	if err != nil {
+		t.Fatalf("allowed origin: %v", err)
+	}
+	conn.Close()
+}
+
+func TestRequestID(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	w := serve(r, "GET", router.Prefix+"/stats", "", nil)
+	if id := w.Header().Get(handlers.RequestIDHeader); len(id) != 24 {
+		t.Fatalf("generated request ID %q", id)
+	}
+	w = serve(r, "GET", router.Prefix+"/stats", "", map[string]string{handlers.RequestIDHeader: "edge-42.a"})
+	if id := w.Header().Get(handlers.RequestIDHeader); id != "edge-42.a" {
+		t.Fatalf("proxy request ID not kept: %q", id)
+	}
+	w = serve(r, "GET", router.Prefix+"/stats", "", map[string]string{handlers.RequestIDHeader: "bad id\n"})
+	if id := w.Header().Get(handlers.RequestIDHeader); id == "bad id\n" || id == "" {
+		t.Fatalf("invalid request ID echoed: %q", id)
+	}
+}
+
+func TestAuditIsAdminOnly(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	csrf := bootstrapCSRF(t, r, nil)
+	w := serve(r, "POST", router.Prefix+"/auth/register",
+		`{"email":"user@example.com","password":"secret123","firstName":"U","lastName":"Ser"}`, csrf)
+	var body struct{ AccessToken string }
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusCreated || body.AccessToken == "" {
+		t.Fatalf("register: %d %s", w.Code, w.Body)
+	}
+	w = serve(r, "GET", router.Prefix+"/admin/audit", "", map[string]string{"Authorization": "Bearer " + body.AccessToken})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("audit as a regular user: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	cfg := config.Defaults()
+	r := newEngine(t, cfg)
+	serve(r, "GET", router.Prefix+"/properties/42", "", nil)
+
+	w := serve(r, "GET", "/metrics", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("/metrics: %d", w.Code)
+	}
+	for _, want := range []string{
+		`snimayprosto_http_request_duration_seconds_count{method="GET",route="/api/v1/properties/:id",status="404"}`,
+		"snimayprosto_chat_connections 0",
+		"snimayprosto_registrations_total",
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("/metrics lacks %s", want)
+		}
+	}
+
+	cfg = config.Defaults()
+	cfg.Metrics.Token = "scrape-me"
+	r = newEngine(t, cfg)
+	if w := serve(r, "GET", "/metrics", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("/metrics without token: %d", w.Code)
+	}
+	if w := serve(r, "GET", "/metrics", "", map[string]string{"Authorization": "Bearer scrape-me"}); w.Code != http.StatusOK {
+		t.Fatalf("/metrics with token: %d", w.Code)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	r := newEngine(t, config.Defaults())
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	serve(r, "GET", router.Prefix+"/stats", "", map[string]string{"traceparent": parent})
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "GET /api/v1/stats" || s.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("span %q in trace %s", s.Name(), s.SpanContext().TraceID())
+	}
+	if s.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("span parent %s", s.Parent().SpanID())
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	csrf := bootstrapCSRF(t, r, nil)
+
+	w := serve(r, "POST", router.Prefix+"/auth/register", `{"email":"nope","password":"123","firstName":"A"}`,
+		with(csrf, "Accept-Language", "en-US,en;q=0.9"))
+	var body struct {
+		Error, Message, RequestID string
+		Fields                    []struct{ Field, Rule, Message string }
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	rules := map[string]string{}
+	for _, f := range body.Fields {
+		rules[f.Field] = f.Rule
+	}
+	if w.Code != http.StatusBadRequest || body.Error != "invalid_request" || body.RequestID != w.Header().Get(handlers.RequestIDHeader) {
+		t.Fatalf("register: %d %s", w.Code, w.Body)
+	}
+	if rules["email"] != "email" || rules["password"] != "min" || rules["lastName"] != "required" || len(rules) != 3 {
+		t.Fatalf("fields: %+v", body.Fields)
+	}
+	if body.Fields[0].Message == "" || body.Message != "Invalid request" {
+		t.Fatalf("english messages: %s", w.Body)
+	}
+
+	w = serve(r, "GET", router.Prefix+"/properties/abc", "", nil)
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusBadRequest || body.Error != "invalid_property_id" || body.Message != "Некорректный ID объявления" {
+		t.Fatalf("bad id: %d %s", w.Code, w.Body)
+	}
+	if w := serve(r, "GET", router.Prefix+"/properties/999", "", nil); w.Code != http.StatusNotFound || errorCode(w) != "property_not_found" {
+		t.Fatalf("missing property: %d %s", w.Code, w.Body)
+	}
+	if w := serve(r, "GET", "/nowhere", "", nil); w.Code != http.StatusNotFound || errorCode(w) != "not_found" {
+		t.Fatalf("unknown route: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestListingInput(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	csrf := bootstrapCSRF(t, r, nil)
+	w := serve(r, "POST", router.Prefix+"/auth/register", `{"email":"owner@example.com","password":"secret123","firstName":"A","lastName":"B"}`, csrf)
+	var reg struct{ AccessToken string }
+	json.Unmarshal(w.Body.Bytes(), &reg)
+	owner := with(csrf, "Authorization", "Bearer "+reg.AccessToken)
+	create := func(body string) (*httptest.ResponseRecorder, map[string]string) {
+		w := serve(r, "POST", router.Prefix+"/properties", body, owner)
+		var out struct {
+			Fields []struct{ Field, Rule string }
+		}
+		json.Unmarshal(w.Body.Bytes(), &out)
+		rules := map[string]string{}
+		for _, f := range out.Fields {
+			rules[f.Field] = f.Rule
+		}
+		return w, rules
+	}
+
+	w, rules := create(`{"title":"Дом","address":"Сочи","propertyType":"castle","rooms":25,"area":0,"price":-1,
+		"priceType":"week","phone":"12","visibility":"all","latitude":91,"longitude":0}`)
+	want := map[string]string{"propertyType": "oneof", "rooms": "max", "area": "required", "price": "gt",
+		"priceType": "oneof", "phone": "e164", "visibility": "oneof", "latitude": "latitude"}
+	if w.Code != http.StatusBadRequest || fmt.Sprint(rules) != fmt.Sprint(want) {
+		t.Fatalf("bad listing: %d %s", w.Code, w.Body)
+	}
+	if _, rules := create(`{"title":"Дом","rooms":"3"}`); rules["rooms"] != "type" {
+		t.Fatalf("rooms as a string: %v", rules)
+	}
+	if _, rules := create(`{"title":"Дом","address":"Подольск, ул. Ленина, 1","propertyType":"studio","rooms":0,"area":25,
+		"price":1500,"priceType":"day","phone":"+79990000000","visibility":"registered","latitude":55.43,"longitude":37.54}`); rules["city"] != "required" {
+		t.Fatalf("unknown city: %v", rules)
+	}
+
+	if _, rules := create(`{"title":"Дом","address":"Сочи","propertyType":"house","rooms":3,"area":120,"price":9000,"priceType":"day",
+		"phone":"+79990000000","visibility":"public","latitude":43.6,"longitude":39.7,"amenities":["tv","sauna"]}`); rules["amenities[1]"] != "amenity" {
+		t.Fatalf("unknown amenity: %v", rules)
+	}
+	if _, rules := create(`{"title":"Дом","address":"Сочи","propertyType":"house","rooms":3,"area":120,"price":9000,"priceType":"day",
+		"phone":"+79990000000","visibility":"public","latitude":43.6,"longitude":39.7,"floor":5,"totalFloors":2}`); rules["floor"] != "ltefield" {
+		t.Fatalf("floor above the top floor: %v", rules)
+	}
+
+	w, _ = create(`{"title":" Студия ","address":"12, Тверская улица, Москва, Россия","propertyType":"studio","rooms":0,"area":25,
+		"price":1500,"priceType":"day","phone":"8 (999) 123-45-67","visibility":"registered","latitude":55.76,"longitude":37.61}`)
+	var prop struct {
+		Title, City, Phone string
+		Rooms, Area        int
+	}
+	json.Unmarshal(w.Body.Bytes(), &prop)
+	if w.Code != http.StatusCreated || prop.Title != "Студия" || prop.City != "Москва" || prop.Phone != "+79991234567" || prop.Area != 25 {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestAmenityCatalog(t *testing.T) {
+	r := newEngine(t, config.Defaults())
+	var catalog struct {
+		Items []struct{ Code, Label string }
+	}
+	w := serve(r, "GET", router.Prefix+"/amenities", "", map[string]string{"Accept-Language": "en"})
+	json.Unmarshal(w.Body.Bytes(), &catalog)
+	if w.Code != http.StatusOK || len(catalog.Items) == 0 || catalog.Items[0].Label != "Internet" {
+		t.Fatalf("catalog: %d %s", w.Code, w.Body)
+	}
+	w = serve(r, "GET", router.Prefix+"/amenities", "", nil)
+	json.Unmarshal(w.Body.Bytes(), &catalog)
+	if catalog.Items[0].Label != "Интернет" {
+		t.Fatalf("russian catalog: %s", w.Body)
+	}
+}
+
+func TestContactReveal(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.RateLimit.Reveals = 2
+	r := newEngine(t, cfg)
+	csrf := bootstrapCSRF(t, r, nil)
+	api := router.Prefix
+	register := func(email string) map[string]string {
+		w := serve(r, "POST", api+"/auth/register", `{"email":"`+email+`","password":"secret123","firstName":"A","lastName":"B"}`, csrf)
+		var reg struct{ AccessToken string }
+		json.Unmarshal(w.Body.Bytes(), &reg)
+		return with(csrf, "Authorization", "Bearer "+reg.AccessToken)
+	}
+	owner, tenant, other := register("owner@example.com"), register("tenant@example.com"), register("other@example.com")
+	w := serve(r, "POST", api+"/properties", `{"title":"Дом","address":"Сочи","propertyType":"house","rooms":3,"area":120,"price":9000,
+		"priceType":"day","phone":"+79990001122","email":"owner@example.com","emailVisibility":"hidden","visibility":"public",
+		"latitude":43.6,"longitude":39.7}`, owner)
+	var prop struct {
+		ID           uint
+		Phone, Email string
+	}
+	json.Unmarshal(w.Body.Bytes(), &prop)
+	if w.Code != http.StatusCreated || prop.Phone == "" {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	propPath := fmt.Sprintf("%s/properties/%d", api, prop.ID)
+
+	for _, path := range []string{propPath, api + "/properties"} {
+		if w := serve(r, "GET", path, "", nil); strings.Contains(w.Body.String(), "+79990001122") || strings.Contains(w.Body.String(), "owner@example.com") {
+			t.Fatalf("GET %s shows contacts: %s", path, w.Body)
+		}
+	}
+	if w := serve(r, "POST", propPath+"/contacts/reveal", "", csrf); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous reveal: %d %s", w.Code, w.Body)
+	}
+	reveal := func(h map[string]string) *httptest.ResponseRecorder {
+		return serve(r, "POST", propPath+"/contacts/reveal", "", h)
+	}
+	if w := reveal(tenant); w.Code != http.StatusOK || w.Body.String() != `{"phone":"+79990001122"}` {
+		t.Fatalf("reveal: %d %s", w.Code, w.Body)
+	}
+	reveal(tenant)
+	reveal(other)
+	if w := reveal(owner); !strings.Contains(w.Body.String(), "owner@example.com") {
+		t.Fatalf("owner reveal: %s", w.Body)
+	}
+	var mine struct {
+		Items []struct{ ContactReveals int64 }
+	}
+	json.Unmarshal(serve(r, "GET", api+"/properties/my", "", owner).Body.Bytes(), &mine)
+	if len(mine.Items) != 1 || mine.Items[0].ContactReveals != 2 {
+		t.Fatalf("reveal count: %+v", mine.Items)
+	}
+
+	hide := `{"phoneVisibility":"hidden","emailVisibility":"hidden"}`
+	if w := serve(r, "PUT", propPath+"/contacts", hide, other); errorCode(w) != "property_not_found_or_not_owned" {
+		t.Fatalf("someone else's listing: %d %s", w.Code, w.Body)
+	}
+	serve(r, "PUT", propPath+"/contacts", hide, owner)
+	if w := reveal(other); w.Code != http.StatusForbidden || errorCode(w) != "contacts_hidden" {
+		t.Fatalf("hidden contacts: %d %s", w.Code, w.Body)
+	}
+	serve(r, "PUT", propPath+"/contacts", `{"phoneVisibility":"public","emailVisibility":"on_request"}`, owner)
+	if w := serve(r, "GET", propPath, "", nil); !strings.Contains(w.Body.String(), `"phone":"+79990001122"`) {
+		t.Fatalf("public phone: %s", w.Body)
+	}
+	if w := reveal(tenant); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third reveal of a user: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,133 @@
+// Package lifecycle runs background jobs and stops them together on
+// shutdown.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Manager owns a set of long-running jobs. Each job gets a context that is
+// cancelled by Stop.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	running map[string]int
+	beats   map[string]heartbeat // every job ever started, by name
+	stopped bool
+}
+
+type heartbeat struct {
+	at     time.Time
+	within time.Duration // zero until the job first calls Beat
+}
+
+// New returns a manager whose jobs also stop when parent is done.
+func New(parent context.Context) *Manager {
+	ctx, cancel := context.WithCancel(parent)
+	return &Manager{ctx: ctx, cancel: cancel, running: make(map[string]int), beats: make(map[string]heartbeat)}
+}
+
+type jobKey struct{}
+
+type jobRef struct {
+	m    *Manager
+	name string
+}
+
+// Beat tells the manager the job running with ctx is alive and will beat
+// again within the given time. Outside a managed job it does nothing.
+func Beat(ctx context.Context, within time.Duration) {
+	ref, ok := ctx.Value(jobKey{}).(jobRef)
+	if !ok {
+		return
+	}
+	ref.m.mu.Lock()
+	ref.m.beats[ref.name] = heartbeat{at: time.Now(), within: within}
+	ref.m.mu.Unlock()
+}
+
+// JobStatus is what health checks see of a job.
+type JobStatus struct {
+	Name     string    `json:"name"`
+	Running  int       `json:"running"`
+	LastBeat time.Time `json:"lastBeat,omitempty"`
+	Healthy  bool      `json:"healthy"`
+}
+
+// Status reports every job started so far. A job is unhealthy when it
+// returned before Stop, or when it missed the beat it promised.
+func (m *Manager) Status(now time.Time) []JobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]JobStatus, 0, len(m.beats))
+	for name, hb := range m.beats {
+		s := JobStatus{Name: name, Running: m.running[name], LastBeat: hb.at}
+		s.Healthy = m.stopped || s.Running > 0 && (hb.within == 0 || now.Sub(hb.at) <= hb.within)
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Go starts fn in a goroutine. fn must return once its context is done.
+// A panicking job is logged and does not take the process down.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name]++
+	if _, ok := m.beats[name]; !ok {
+		m.beats[name] = heartbeat{at: time.Now()}
+	}
+	m.mu.Unlock()
+	m.wg.Add(1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("lifecycle: job panicked", "job", name, "panic", r)
+			}
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+			m.wg.Done()
+		}()
+		fn(context.WithValue(m.ctx, jobKey{}, jobRef{m, name}))
+	}()
+}
+
+// Stop cancels every job and waits for them to return, or for ctx to be
+// done, in which case the error names the jobs still running.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+	m.cancel()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		names := make([]string, 0, len(m.running))
+		for name := range m.running {
+			names = append(names, name)
+		}
+		m.mu.Unlock()
+		sort.Strings(names)
+		return fmt.Errorf("lifecycle: jobs still running: %s", strings.Join(names, ", "))
+	}
+}
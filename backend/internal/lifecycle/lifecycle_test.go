@@ -0,0 +1,78 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStopWaitsForJobs(t *testing.T) {
+	m := New(context.Background())
+	var stopped atomic.Int32
+	for i := 0; i < 3; i++ {
+		m.Go("worker", func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			stopped.Add(1)
+		})
+	}
+	m.Go("panics", func(ctx context.Context) { panic("boom") })
+
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := stopped.Load(); n != 3 {
+		t.Fatalf("%d jobs finished before Stop returned, want 3", n)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	m := New(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	m.Go("stuck", func(ctx context.Context) { <-release })
+	m.Go("polite", func(ctx context.Context) { <-ctx.Done() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := m.Stop(ctx)
+	if err == nil || !strings.Contains(err.Error(), "stuck") || strings.Contains(err.Error(), "polite") {
+		t.Fatalf("Stop = %v, want the stuck job named", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	m := New(context.Background())
+	beat := make(chan struct{})
+	m.Go("ticker", func(ctx context.Context) {
+		Beat(ctx, time.Minute)
+		close(beat)
+		<-ctx.Done()
+	})
+	m.Go("quits", func(ctx context.Context) {})
+	<-beat
+	time.Sleep(10 * time.Millisecond) // let "quits" return
+
+	healthy := func(now time.Time) map[string]bool {
+		out := map[string]bool{}
+		for _, s := range m.Status(now) {
+			out[s.Name] = s.Healthy
+		}
+		return out
+	}
+	if h := healthy(time.Now()); !h["ticker"] || h["quits"] {
+		t.Fatalf("status = %v, want ticker healthy and quits not", h)
+	}
+	if h := healthy(time.Now().Add(2 * time.Minute)); h["ticker"] {
+		t.Fatal("ticker still healthy after missing its beat")
+	}
+
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if h := healthy(time.Now()); !h["ticker"] || !h["quits"] {
+		t.Fatalf("status after Stop = %v, want all healthy", h)
+	}
+}
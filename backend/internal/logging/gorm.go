@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger sends GORM's logs to slog. Every query is logged at debug
+// level, slow queries at warn and failed ones at error, all with the
+// request ID of the query's context.
+type GormLogger struct {
+	Logger *slog.Logger
+	Level  slog.Level    // records below it are dropped
+	Slow   time.Duration // 0 disables slow query warnings
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	c := *l
+	switch level {
+	case gormlogger.Silent:
+		c.Level = slog.LevelError + 1
+	case gormlogger.Error:
+		c.Level = slog.LevelError
+	case gormlogger.Warn:
+		c.Level = slog.LevelWarn
+	case gormlogger.Info:
+		c.Level = slog.LevelDebug
+	}
+	return &c
+}
+
+func (l *GormLogger) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if level < l.Level {
+		return
+	}
+	l.Logger.Log(ctx, level, msg, args...)
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, "db: "+fmt.Sprintf(msg, args...))
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelWarn, "db: "+fmt.Sprintf(msg, args...))
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelError, "db: "+fmt.Sprintf(msg, args...))
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	msg := "db: query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "db: query failed"
+	case l.Slow > 0 && elapsed > l.Slow:
+		level, msg = slog.LevelWarn, "db: slow query"
+	}
+	if level < l.Level {
+		return
+	}
+	sql, rows := fc()
+	args := []interface{}{slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed)}
+	if level == slog.LevelError {
+		args = append(args, slog.Any("error", err))
+	}
+	l.Logger.Log(ctx, level, msg, args...)
+}
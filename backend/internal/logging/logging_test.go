@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestRequestIDInRecords(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithRequest(context.Background(), Request{ID: "abc"})
+	logger.With("user_id", 7).InfoContext(ctx, "hello")
+	logger.DebugContext(ctx, "dropped")
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if rec["msg"] != "hello" || rec["request_id"] != "abc" || rec["user_id"] != float64(7) {
+		t.Fatalf("record = %v", rec)
+	}
+
+	if _, err := New(&buf, "loud", "json"); err == nil {
+		t.Fatal("unknown level accepted")
+	}
+	if _, err := New(&buf, "info", "xml"); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
+
+func TestGormLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "debug", "json")
+	l := &GormLogger{Logger: logger, Level: slog.LevelWarn, Slow: 100 * time.Millisecond}
+	ctx := WithRequest(context.Background(), Request{ID: "q1"})
+	sql := func() (string, int64) { return "SELECT 1", 1 }
+
+	records := func() []map[string]interface{} {
+		var out []map[string]interface{}
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var rec map[string]interface{}
+			if err := dec.Decode(&rec); err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, rec)
+		}
+		buf.Reset()
+		return out
+	}
+
+	l.Trace(ctx, time.Now(), sql, nil)
+	if recs := records(); len(recs) != 0 {
+		t.Fatalf("fast query logged at warn level: %v", recs)
+	}
+	l.Trace(ctx, time.Now().Add(-time.Second), sql, nil)
+	l.Trace(ctx, time.Now(), sql, errors.New("boom"))
+	recs := records()
+	if len(recs) != 2 || recs[0]["msg"] != "db: slow query" || recs[1]["error"] != "boom" || recs[1]["request_id"] != "q1" {
+		t.Fatalf("records = %v", recs)
+	}
+
+	l.LogMode(gormlogger.Info).Trace(ctx, time.Now(), sql, nil)
+	if recs := records(); len(recs) != 1 || recs[0]["sql"] != "SELECT 1" || recs[0]["level"] != "DEBUG" {
+		t.Fatalf("debug records = %v", recs)
+	}
+}
@@ -0,0 +1,183 @@
+package migrate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/migrate"
+	"gofuckbiz/snimayprosto-rent-easy/internal/testdb"
+)
+
+func file(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+
+func TestLoad(t *testing.T) {
+	migs, err := migrate.Load(fstest.MapFS{
+		"20261019000002_add_index.up.sql":    file("CREATE INDEX i ON t (a);"),
+		"20261019000001_create_t.down.sql":   file("DROP TABLE t;"),
+		"20261019000001_create_t.up.sql":     file("CREATE TABLE t (a int);"),
+		"20261019000003_irreversible.up.sql": file("DELETE FROM t;"),
+		"subdir/ignored.txt":                 file(""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migs) != 3 {
+		t.Fatalf("loaded %d migrations", len(migs))
+	}
+	first := migs[0]
+	if first.Version != 20261019000001 || first.Name != "create_t" || first.Up != "CREATE TABLE t (a int);" || first.Down != "DROP TABLE t;" {
+		t.Fatalf("first = %+v", first)
+	}
+	if migs[1].Version != 20261019000002 || migs[1].Down != "" || migs[2].Name != "irreversible" {
+		t.Fatalf("order = %+v", migs)
+	}
+
+	bad := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"bad name", fstest.MapFS{"20261019000001_Create-T.up.sql": file("x")}, "unexpected file"},
+		{"no version", fstest.MapFS{"create_t.up.sql": file("x")}, "unexpected file"},
+		{"other extension", fstest.MapFS{"20261019000001_create_t.sql": file("x")}, "unexpected file"},
+		{"duplicate version", fstest.MapFS{
+			"20261019000001_create_t.up.sql": file("x"),
+			"20261019000001_create_u.up.sql": file("y"),
+		}, "used by"},
+		{"down only", fstest.MapFS{"20261019000001_create_t.down.sql": file("DROP TABLE t;")}, "no up migration"},
+		{"blank up", fstest.MapFS{"20261019000001_create_t.up.sql": file("  \n")}, "no up migration"},
+	}
+	for _, tc := range bad {
+		if _, err := migrate.Load(tc.fsys); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	m, err := migrate.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mig := range m.Migrations {
+		if strings.TrimSpace(mig.Down) == "" {
+			t.Errorf("%d_%s has no down migration", mig.Version, mig.Name)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 15, 4, 5, 0, time.FixedZone("MSK", 3*60*60))
+	up, down, err := migrate.Create(dir, "Add Flagged  Edits!", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "20261019120405_add_flagged_edits.up.sql" || filepath.Base(down) != "20261019120405_add_flagged_edits.down.sql" {
+		t.Fatalf("paths = %s, %s", up, down)
+	}
+	if body, _ := os.ReadFile(up); string(body) != "-- Add Flagged  Edits!\n" {
+		t.Fatalf("up = %q", body)
+	}
+	// the pair loads back: the comment alone counts as a body
+	migs, err := migrate.Load(os.DirFS(dir))
+	if err != nil || len(migs) != 1 || migs[0].Name != "add_flagged_edits" {
+		t.Fatalf("load = %+v, %v", migs, err)
+	}
+
+	if _, _, err := migrate.Create(dir, "!!!", now); err == nil {
+		t.Fatal("created a migration without a name")
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	db, err := testdb.Open(t).DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migs, err := migrate.Load(fstest.MapFS{
+		"1_create_widgets.up.sql":   file("CREATE TABLE widgets (id int);"),
+		"1_create_widgets.down.sql": file("DROP TABLE widgets;"),
+		"2_widgets_name.up.sql":     file("ALTER TABLE widgets ADD COLUMN name text;"),
+		"2_widgets_name.down.sql":   file("ALTER TABLE widgets DROP COLUMN name;"),
+		"3_broken.up.sql":           file("ALTER TABLE nowhere ADD COLUMN x int;"),
+		"3_broken.down.sql":         file("SELECT 1;"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// testdb applied the embedded migrations; these come on top
+	m := &migrate.Migrator{DB: db, Migrations: migs[:2]}
+
+	if pending, err := m.Pending(ctx); err != nil || len(pending) != 2 {
+		t.Fatalf("pending = %+v, %v", pending, err)
+	}
+	if done, err := m.Up(ctx, 1); err != nil || len(done) != 1 || done[0].Version != 1 {
+		t.Fatalf("up 1 = %+v, %v", done, err)
+	}
+	if pending, _ := m.Pending(ctx); len(pending) != 1 || pending[0].Version != 2 {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if done, err := m.Up(ctx, 0); err != nil || len(done) != 1 {
+		t.Fatalf("up = %+v, %v", done, err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO widgets (id, name) VALUES (1, 'a')"); err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil || len(status) != 2 || status[0].AppliedAt == nil || status[1].AppliedAt == nil {
+		t.Fatalf("status = %+v, %v", status, err)
+	}
+
+	if done, err := m.Down(ctx, 1); err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("down = %+v, %v", done, err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT name FROM widgets"); err == nil {
+		t.Fatal("column still there after down")
+	}
+	if pending, _ := m.Pending(ctx); len(pending) != 1 || pending[0].Version != 2 {
+		t.Fatalf("pending after down = %+v", pending)
+	}
+
+	// a failing migration rolls back and stays pending
+	m.Migrations = migs
+	if done, err := m.Up(ctx, 0); err == nil || len(done) != 1 {
+		t.Fatalf("up with a broken migration = %+v, %v", done, err)
+	}
+	if pending, _ := m.Pending(ctx); len(pending) != 1 || pending[0].Version != 3 {
+		t.Fatalf("pending after failure = %+v", pending)
+	}
+}
+
+// TestEmbeddedRoundTrip rolls every embedded migration back and applies it
+// again, which catches down migrations that do not undo their up.
+func TestEmbeddedRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db, err := testdb.Open(t).DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := m.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Fatalf("pending after testdb = %+v, %v", pending, err)
+	}
+	steps := len(m.Migrations)
+	if done, err := m.Down(ctx, steps); err != nil || len(done) != steps {
+		t.Fatalf("down = %d, %v", len(done), err)
+	}
+	if pending, _ := m.Pending(ctx); len(pending) != steps {
+		t.Fatalf("pending = %d, want %d", len(pending), steps)
+	}
+	if done, err := m.Up(ctx, 0); err != nil || len(done) != steps {
+		t.Fatalf("up = %d, %v", len(done), err)
+	}
+}
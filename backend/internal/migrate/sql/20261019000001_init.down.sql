@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS push_subscriptions;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS user_blocks;
+DROP TABLE IF EXISTS flagged_messages;
+DROP TABLE IF EXISTS property_promotions;
+DROP TABLE IF EXISTS user_plans;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS conversations;
+DROP TABLE IF EXISTS favorites;
+DROP TABLE IF EXISTS property_images;
+DROP TABLE IF EXISTS properties;
+DROP TABLE IF EXISTS users;
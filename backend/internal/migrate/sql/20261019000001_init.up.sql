@@ -0,0 +1,161 @@
+-- Baseline schema. Written with IF NOT EXISTS so databases created by the
+-- old AutoMigrate startup adopt it without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            bigserial PRIMARY KEY,
+    email         text NOT NULL,
+    password_hash text,
+    name          text,
+    role          text,
+    created_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS properties (
+    id            bigserial PRIMARY KEY,
+    owner_id      bigint NOT NULL,
+    title         text,
+    description   text,
+    price         numeric,
+    price_type    text,
+    city          text,
+    address       text,
+    lat           numeric,
+    lng           numeric,
+    rooms         bigint,
+    area          bigint,
+    amenities     text,
+    property_type text,
+    contact_phone text,
+    contact_email text,
+    is_urgent     boolean,
+    visibility    text,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_properties_owner_id ON properties (owner_id);
+
+CREATE TABLE IF NOT EXISTS property_images (
+    id          bigserial PRIMARY KEY,
+    property_id bigint NOT NULL,
+    url         text,
+    "order"     bigint,
+    CONSTRAINT fk_properties_images FOREIGN KEY (property_id) REFERENCES properties (id)
+);
+CREATE INDEX IF NOT EXISTS idx_property_images_property_id ON property_images (property_id);
+
+CREATE TABLE IF NOT EXISTS favorites (
+    user_id     bigint NOT NULL,
+    property_id bigint NOT NULL,
+    PRIMARY KEY (user_id, property_id)
+);
+
+CREATE TABLE IF NOT EXISTS conversations (
+    id              bigserial PRIMARY KEY,
+    property_id     bigint,
+    initiator_id    bigint NOT NULL,
+    recipient_id    bigint NOT NULL,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    last_message_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_conversations_property_id ON conversations (property_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_initiator_id ON conversations (initiator_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_recipient_id ON conversations (recipient_id);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id              bigserial PRIMARY KEY,
+    conversation_id bigint NOT NULL,
+    sender_id       bigint NOT NULL,
+    type            varchar(20) DEFAULT 'text',
+    content         text,
+    attachment_url  text,
+    created_at      timestamptz,
+    read_at         timestamptz
+);
+-- columns added after the first release
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at timestamptz;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+UPDATE messages SET updated_at = created_at WHERE updated_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages (conversation_id);
+CREATE INDEX IF NOT EXISTS idx_messages_sender_id ON messages (sender_id);
+CREATE INDEX IF NOT EXISTS idx_messages_updated_at ON messages (updated_at);
+
+CREATE TABLE IF NOT EXISTS user_plans (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint NOT NULL,
+    plan_type    varchar(20) DEFAULT 'free',
+    max_listings bigint DEFAULT 3,
+    expires_at   timestamptz,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_plans_user_id ON user_plans (user_id);
+
+CREATE TABLE IF NOT EXISTS property_promotions (
+    id          bigserial PRIMARY KEY,
+    property_id bigint NOT NULL,
+    user_id     bigint NOT NULL,
+    expires_at  timestamptz NOT NULL,
+    created_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_property_promotions_property_id ON property_promotions (property_id);
+CREATE INDEX IF NOT EXISTS idx_property_promotions_user_id ON property_promotions (user_id);
+
+CREATE TABLE IF NOT EXISTS flagged_messages (
+    id              bigserial PRIMARY KEY,
+    conversation_id bigint NOT NULL,
+    sender_id       bigint NOT NULL,
+    content         text,
+    action          varchar(20),
+    detectors       text,
+    reason          text,
+    status          varchar(20) DEFAULT 'pending',
+    reviewer_id     bigint,
+    reviewed_at     timestamptz,
+    message_id      bigint,
+    created_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_flagged_messages_conversation_id ON flagged_messages (conversation_id);
+CREATE INDEX IF NOT EXISTS idx_flagged_messages_sender_id ON flagged_messages (sender_id);
+CREATE INDEX IF NOT EXISTS idx_flagged_messages_status ON flagged_messages (status);
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id bigint NOT NULL,
+    blocked_id bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks (blocked_id);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id       bigint PRIMARY KEY,
+    email_enabled boolean,
+    push_enabled  boolean,
+    updated_at    timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    endpoint   text NOT NULL,
+    p256dh     text,
+    auth       text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id ON push_subscriptions (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_push_subscriptions_endpoint ON push_subscriptions (endpoint);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    type       varchar(40) NOT NULL,
+    title      text,
+    body       text,
+    link       text,
+    dedup_key  text,
+    read_at    timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications (user_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_dedup ON notifications (user_id, dedup_key);
@@ -0,0 +1,17 @@
+-- Security audit log: sign-ins, role and plan changes, listing deletions.
+
+CREATE TABLE audit_events (
+    id          bigserial PRIMARY KEY,
+    actor_id    bigint,
+    action      varchar(40) NOT NULL,
+    target_type varchar(20),
+    target_id   bigint,
+    details     jsonb NOT NULL DEFAULT '{}',
+    ip          text,
+    user_agent  text,
+    request_id  text,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_audit_events_actor_id ON audit_events (actor_id);
+CREATE INDEX idx_audit_events_action ON audit_events (action);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
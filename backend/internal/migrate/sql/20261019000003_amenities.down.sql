@@ -0,0 +1,22 @@
+ALTER TABLE properties ADD COLUMN amenities text;
+
+UPDATE properties p SET amenities = NULLIF(concat_ws(',',
+    CASE WHEN p.balcony THEN 'Балкон' END,
+    CASE WHEN p.furnished THEN 'Мебель' END,
+    CASE WHEN p.parking THEN 'Парковка' END,
+    (SELECT string_agg(a.label_ru, ',' ORDER BY a."order")
+     FROM property_amenities pa JOIN amenities a ON a.id = pa.amenity_id
+     WHERE pa.property_id = p.id)), '');
+
+ALTER TABLE properties
+    DROP COLUMN floor,
+    DROP COLUMN total_floors,
+    DROP COLUMN furnished,
+    DROP COLUMN pets_allowed,
+    DROP COLUMN balcony,
+    DROP COLUMN parking,
+    DROP COLUMN deposit,
+    DROP COLUMN utilities_included;
+
+DROP TABLE IF EXISTS property_amenities;
+DROP TABLE IF EXISTS amenities;
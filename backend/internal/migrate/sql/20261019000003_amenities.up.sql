@@ -0,0 +1,62 @@
+-- Amenity catalog and typed listing attributes, replacing the amenities CSV
+-- column. CSV values are matched to the catalog by label or code; balcony,
+-- furniture and parking become attributes. Values matching neither are
+-- dropped.
+
+CREATE TABLE amenities (
+    id       bigserial PRIMARY KEY,
+    code     varchar(40) NOT NULL,
+    label_ru text NOT NULL,
+    label_en text NOT NULL,
+    icon     varchar(40) NOT NULL DEFAULT '',
+    "order"  integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX idx_amenities_code ON amenities (code);
+
+INSERT INTO amenities (code, label_ru, label_en, icon, "order") VALUES
+    ('internet',         'Интернет',             'Internet',         'wifi',            1),
+    ('fridge',           'Холодильник',          'Fridge',           'refrigerator',    2),
+    ('washer',           'Стиральная машина',    'Washing machine',  'washing-machine', 3),
+    ('dishwasher',       'Посудомоечная машина', 'Dishwasher',       'utensils',        4),
+    ('air_conditioning', 'Кондиционер',          'Air conditioning', 'air-vent',        5),
+    ('tv',               'Телевизор',            'TV',               'tv',              6),
+    ('microwave',        'Микроволновка',        'Microwave',        'microwave',       7),
+    ('elevator',         'Лифт',                 'Elevator',         'arrow-up-down',   8),
+    ('concierge',        'Консьерж',             'Concierge',        'bell',            9),
+    ('workspace',        'Рабочее место',        'Workspace',        'laptop',          10);
+
+CREATE TABLE property_amenities (
+    property_id bigint NOT NULL REFERENCES properties (id) ON DELETE CASCADE,
+    amenity_id  bigint NOT NULL REFERENCES amenities (id),
+    PRIMARY KEY (property_id, amenity_id)
+);
+CREATE INDEX idx_property_amenities_amenity_id ON property_amenities (amenity_id);
+
+ALTER TABLE properties
+    ADD COLUMN floor              integer,
+    ADD COLUMN total_floors       integer,
+    ADD COLUMN furnished          boolean,
+    ADD COLUMN pets_allowed       boolean,
+    ADD COLUMN balcony            boolean,
+    ADD COLUMN parking            boolean,
+    ADD COLUMN deposit            numeric,
+    ADD COLUMN utilities_included boolean;
+
+CREATE TEMPORARY TABLE legacy_amenities ON COMMIT DROP AS
+    SELECT DISTINCT p.id AS property_id, lower(trim(v)) AS name
+    FROM properties p, unnest(string_to_array(p.amenities, ',')) AS v
+    WHERE trim(v) <> '';
+
+INSERT INTO property_amenities (property_id, amenity_id)
+    SELECT DISTINCT l.property_id, a.id
+    FROM legacy_amenities l
+    JOIN amenities a ON l.name IN (lower(a.label_ru), lower(a.label_en), a.code);
+
+UPDATE properties p SET balcony = true
+    FROM legacy_amenities l WHERE l.property_id = p.id AND l.name IN ('балкон', 'balcony');
+UPDATE properties p SET furnished = true
+    FROM legacy_amenities l WHERE l.property_id = p.id AND l.name IN ('мебель', 'furniture', 'furnished');
+UPDATE properties p SET parking = true
+    FROM legacy_amenities l WHERE l.property_id = p.id AND l.name IN ('парковка', 'parking');
+
+ALTER TABLE properties DROP COLUMN amenities;
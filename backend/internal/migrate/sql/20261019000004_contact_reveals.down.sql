@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS contact_reveals;
+
+ALTER TABLE properties
+    DROP COLUMN phone_visibility,
+    DROP COLUMN email_visibility;
@@ -0,0 +1,17 @@
+-- Listing contacts are hidden unless the landlord makes them public;
+-- signed-in users ask for them and every reveal is recorded.
+
+ALTER TABLE properties
+    ADD COLUMN phone_visibility varchar(20) NOT NULL DEFAULT 'on_request',
+    ADD COLUMN email_visibility varchar(20) NOT NULL DEFAULT 'on_request';
+
+CREATE TABLE contact_reveals (
+    id          bigserial PRIMARY KEY,
+    property_id bigint NOT NULL REFERENCES properties (id) ON DELETE CASCADE,
+    viewer_id   bigint NOT NULL,
+    fields      text NOT NULL,
+    ip          text,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_contact_reveals_property_id ON contact_reveals (property_id);
+CREATE INDEX idx_contact_reveals_viewer_created ON contact_reveals (viewer_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS listing_views;
+DROP TABLE IF EXISTS listing_stats;
@@ -0,0 +1,19 @@
+-- Daily listing analytics for landlords. listing_views remembers who was
+-- already counted on a day, so repeated visits add one view.
+
+CREATE TABLE listing_stats (
+    property_id   bigint NOT NULL REFERENCES properties (id) ON DELETE CASCADE,
+    day           date NOT NULL,
+    views         bigint NOT NULL DEFAULT 0,
+    reveals       bigint NOT NULL DEFAULT 0,
+    favorites     bigint NOT NULL DEFAULT 0,
+    conversations bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (property_id, day)
+);
+
+CREATE TABLE listing_views (
+    property_id bigint NOT NULL REFERENCES properties (id) ON DELETE CASCADE,
+    day         date NOT NULL,
+    viewer      varchar(80) NOT NULL,
+    PRIMARY KEY (property_id, day, viewer)
+);
@@ -0,0 +1,14 @@
+-- Only the latest promotion of each listing survives the way back.
+DROP INDEX IF EXISTS idx_property_promotions_property_expires;
+
+DELETE FROM property_promotions p
+USING property_promotions newer
+WHERE newer.property_id = p.property_id AND newer.id > p.id;
+
+ALTER TABLE property_promotions
+    DROP COLUMN product,
+    DROP COLUMN tier,
+    DROP COLUMN credits,
+    DROP COLUMN starts_at;
+
+CREATE UNIQUE INDEX idx_property_promotions_property_id ON property_promotions (property_id);
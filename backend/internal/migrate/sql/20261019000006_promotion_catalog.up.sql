@@ -0,0 +1,19 @@
+-- Promotions become products with a tier and a start time, and a listing
+-- keeps its whole promotion history. Existing promotions were the free
+-- 7-day top placement.
+
+DROP INDEX IF EXISTS idx_property_promotions_property_id;
+
+ALTER TABLE property_promotions
+    ADD COLUMN product   varchar(40) NOT NULL DEFAULT 'top_city_7d',
+    ADD COLUMN tier      varchar(20) NOT NULL DEFAULT 'top_city',
+    ADD COLUMN credits   integer NOT NULL DEFAULT 0,
+    ADD COLUMN starts_at timestamptz;
+
+UPDATE property_promotions SET starts_at = COALESCE(created_at, expires_at - interval '7 days');
+
+ALTER TABLE property_promotions
+    ALTER COLUMN starts_at SET NOT NULL,
+    ALTER COLUMN product DROP DEFAULT;
+
+CREATE INDEX idx_property_promotions_property_expires ON property_promotions (property_id, expires_at);
@@ -0,0 +1 @@
+ALTER TABLE flagged_messages DROP COLUMN edit_of;
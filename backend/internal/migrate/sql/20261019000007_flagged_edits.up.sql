@@ -0,0 +1,4 @@
+-- Edits run through the chat filter too. A held edit remembers the message
+-- it changes so releasing it updates that message.
+
+ALTER TABLE flagged_messages ADD COLUMN edit_of bigint;
@@ -0,0 +1,3 @@
+ALTER TABLE properties
+    DROP COLUMN moderation_status,
+    DROP COLUMN moderation_reason;
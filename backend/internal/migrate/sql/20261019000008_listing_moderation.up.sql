@@ -0,0 +1,6 @@
+-- Moderators can reject a published listing, which takes it out of the
+-- public feed. Existing listings stay approved.
+
+ALTER TABLE properties
+    ADD COLUMN moderation_status varchar(20) NOT NULL DEFAULT 'approved',
+    ADD COLUMN moderation_reason text NOT NULL DEFAULT '';
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// Center stores in-app notifications and fans them out to the user's open
+// streams. Handlers and background jobs emit into it.
+type Center struct {
+	Notifications service.NotificationRepository
+
+	mu     sync.Mutex
+	subs   map[uint]map[chan core.Notification]struct{}
+	closed bool
+}
+
+func NewCenter(notifications service.NotificationRepository) *Center {
+	return &Center{Notifications: notifications, subs: make(map[uint]map[chan core.Notification]struct{})}
+}
+
+// Emit saves n and pushes it to live streams. When n.DedupKey is set and
+// the user already has a notification with that key, nothing happens.
+func (c *Center) Emit(ctx context.Context, n core.Notification) error {
+	if c == nil {
+		return nil
+	}
+	created, err := c.Notifications.Create(ctx, &n)
+	if err != nil || !created {
+		return err
+	}
+	c.publish(n)
+	return nil
+}
+
+// Subscribe returns a channel of notifications for the user. Call the
+// returned func to unsubscribe. Slow readers miss live events; they can
+// always reload the list.
+func (c *Center) Subscribe(userID uint) (<-chan core.Notification, func()) {
+	ch := make(chan core.Notification, 16)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if c.subs[userID] == nil {
+		c.subs[userID] = make(map[chan core.Notification]struct{})
+	}
+	c.subs[userID][ch] = struct{}{}
+	c.mu.Unlock()
+
+	return ch, func() {
+		c.mu.Lock()
+		delete(c.subs[userID], ch)
+		if len(c.subs[userID]) == 0 {
+			delete(c.subs, userID)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Close ends every stream: subscriber channels are closed, and later
+// subscriptions get a closed channel. Used on shutdown.
+func (c *Center) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for userID, chans := range c.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(c.subs, userID)
+	}
+}
+
+func (c *Center) publish(n core.Notification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.subs[n.UserID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+func dedupKey(format string, args ...interface{}) *string {
+	k := fmt.Sprintf(format, args...)
+	return &k
+}
+
+// NewMessage tells the recipient about a chat message.
+func (c *Center) NewMessage(ctx context.Context, recipientID uint, senderName string, msg core.Message) error {
+	return c.Emit(ctx, core.Notification{
+		UserID: recipientID,
+		Type:   core.NotificationNewMessage,
+		Title:  "Новое сообщение",
+		Body:   fmt.Sprintf("%s: %s", senderName, truncate(msg.Content, 100)),
+		Link:   fmt.Sprintf("/?conversation=%d", msg.ConversationID),
+	})
+}
+
+// ListingReviewed tells the owner whether moderation approved the listing.
+func (c *Center) ListingReviewed(ctx context.Context, p core.Property, approved bool, reason string) error {
+	n := core.Notification{
+		UserID: p.OwnerID,
+		Type:   core.NotificationListingApproved,
+		Title:  "Объявление опубликовано",
+		Body:   p.Title,
+		Link:   fmt.Sprintf("/listing/%d", p.ID),
+	}
+	if !approved {
+		n.Type = core.NotificationListingRejected
+		n.Title = "Объявление отклонено"
+		if reason != "" {
+			n.Body = fmt.Sprintf("%s: %s", p.Title, reason)
+		}
+	}
+	return c.Emit(ctx, n)
+}
+
+// PromotionExpiring warns the owner once per promotion end date.
+func (c *Center) PromotionExpiring(ctx context.Context, promo core.PropertyPromotion, title string) error {
+	return c.Emit(ctx, core.Notification{
+		UserID:   promo.UserID,
+		Type:     core.NotificationPromotionExpiring,
+		Title:    "Продвижение скоро закончится",
+		Body:     fmt.Sprintf("%s — до %s", title, promo.ExpiresAt.Format("02.01.2006 15:04")),
+		Link:     "/my-listings",
+		DedupKey: dedupKey("promotion:%d:%d", promo.ID, promo.ExpiresAt.Unix()),
+	})
+}
+
+// PlanExpiring warns the user once per plan end date.
+func (c *Center) PlanExpiring(ctx context.Context, plan core.UserPlan) error {
+	if plan.ExpiresAt == nil {
+		return nil
+	}
+	return c.Emit(ctx, core.Notification{
+		UserID:   plan.UserID,
+		Type:     core.NotificationPlanExpiring,
+		Title:    "Тариф скоро закончится",
+		Body:     fmt.Sprintf("Тариф %s действует до %s", plan.PlanType, plan.ExpiresAt.Format("02.01.2006")),
+		Link:     "/pricing",
+		DedupKey: dedupKey("plan:%d:%d", plan.ID, plan.ExpiresAt.Unix()),
+	})
+}
+
+// SavedSearchMatch tells a user that a new listing fits one of their searches.
+func (c *Center) SavedSearchMatch(ctx context.Context, userID uint, searchName string, p core.Property) error {
+	return c.Emit(ctx, core.Notification{
+		UserID:   userID,
+		Type:     core.NotificationSavedSearchMatch,
+		Title:    "Новое объявление по вашему поиску",
+		Body:     fmt.Sprintf("%s: %s", searchName, p.Title),
+		Link:     fmt.Sprintf("/listing/%d", p.ID),
+		DedupKey: dedupKey("search:%s:%d", searchName, p.ID),
+	})
+}
@@ -0,0 +1,92 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestCenterFanOut(t *testing.T) {
+	ctx := context.Background()
+	c := notify.NewCenter(memory.NewRepositories().Notifications)
+	tab1, cancel1 := c.Subscribe(1)
+	tab2, cancel2 := c.Subscribe(1)
+	other, cancelOther := c.Subscribe(2)
+	defer cancelOther()
+
+	if err := c.Emit(ctx, core.Notification{UserID: 1, Title: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range []<-chan core.Notification{tab1, tab2} {
+		if n := <-ch; n.Title != "first" || n.ID == 0 {
+			t.Fatalf("got %+v", n)
+		}
+	}
+	if len(other) != 0 {
+		t.Fatal("another user got the notification")
+	}
+
+	cancel1()
+	key := "plan:1:1"
+	for i := 0; i < 2; i++ {
+		if err := c.Emit(ctx, core.Notification{UserID: 1, Title: "second", DedupKey: &key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(tab1) != 0 {
+		t.Fatal("unsubscribed stream got a notification")
+	}
+	if len(tab2) != 1 {
+		t.Fatalf("deduplicated notification published %d times", len(tab2))
+	}
+	cancel2()
+
+	c.Close()
+	if _, ok := <-other; ok {
+		t.Fatal("stream open after Close")
+	}
+	late, _ := c.Subscribe(1)
+	if _, ok := <-late; ok {
+		t.Fatal("subscribed after Close")
+	}
+}
+
+func TestCenterEmitters(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories()
+	c := notify.NewCenter(repos.Notifications)
+	p := core.Property{ID: 7, OwnerID: 1, Title: "Студия у метро"}
+	list := func(userID uint) []core.Notification {
+		items, err := repos.Notifications.List(ctx, service.NotificationFilter{UserID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+
+	if err := c.ListingReviewed(ctx, p, false, "нет фото"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ListingReviewed(ctx, p, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	got := list(1)
+	if len(got) != 2 ||
+		got[1].Type != core.NotificationListingRejected || got[1].Body != "Студия у метро: нет фото" || got[1].Link != "/listing/7" ||
+		got[0].Type != core.NotificationListingApproved || got[0].Body != "Студия у метро" {
+		t.Fatalf("reviews = %+v", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := c.SavedSearchMatch(ctx, 2, "Студии", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := list(2); len(got) != 1 || got[0].Type != core.NotificationSavedSearchMatch || got[0].Body != "Студии: Студия у метро" {
+		t.Fatalf("search matches = %+v", got)
+	}
+}
@@ -0,0 +1,258 @@
+// Package notify tells users about chat messages they missed while offline,
+// by e-mail and Web Push.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/lifecycle"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// Presence tells whether a user currently has a live chat connection.
+type Presence interface {
+	IsOnline(userID uint) bool
+}
+
+// Dispatcher batches messages for offline recipients into digests. A digest
+// goes out DigestDelay after the first missed message, and a user gets at
+// most one digest per Throttle. Pending digests live in memory.
+type Dispatcher struct {
+	Repos    service.Repositories
+	Email    EmailSender // nil disables e-mail
+	Push     PushSender  // nil disables Web Push
+	Presence Presence
+
+	AppURL      string
+	DigestDelay time.Duration
+	Throttle    time.Duration
+
+	Now func() time.Time // defaults to time.Now, tests override it
+
+	mu       sync.Mutex
+	pending  map[uint]*digest // by recipient
+	lastSent map[uint]time.Time
+}
+
+type digest struct {
+	first    time.Time
+	messages map[uint]struct{} // message IDs, a set so repeats collapse
+}
+
+// NewDispatcher wires senders from config: SMTP when SMTP_HOST is set
+// (otherwise e-mails are logged) and Web Push when VAPID keys are set.
+func NewDispatcher(repos service.Repositories, cfg *config.Config, presence Presence) *Dispatcher {
+	d := &Dispatcher{
+		Repos:       repos,
+		Presence:    presence,
+		AppURL:      strings.TrimRight(cfg.Notify.AppURL, "/"),
+		DigestDelay: cfg.Notify.DigestDelay,
+		Throttle:    cfg.Notify.Throttle,
+		Now:         time.Now,
+		pending:     make(map[uint]*digest),
+		lastSent:    make(map[uint]time.Time),
+	}
+	if cfg.Notify.SMTP.Host != "" {
+		s := cfg.Notify.SMTP
+		d.Email = NewSMTPSender(s.Host, s.Port, s.User, s.Pass, s.From)
+	} else {
+		d.Email = LogEmailSender{}
+	}
+	if cfg.Notify.VAPID.PublicKey != "" && cfg.Notify.VAPID.PrivateKey != "" {
+		v := cfg.Notify.VAPID
+		d.Push = NewWebPushSender(v.PublicKey, v.PrivateKey, v.Subject)
+	}
+	return d
+}
+
+// MessageCreated queues msg for recipientID unless they are connected.
+func (d *Dispatcher) MessageCreated(msg core.Message, recipientID uint) {
+	if d == nil || recipientID == 0 || recipientID == msg.SenderID {
+		return
+	}
+	if d.Presence != nil && d.Presence.IsOnline(recipientID) {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dg := d.pending[recipientID]
+	if dg == nil {
+		dg = &digest{first: d.clock(), messages: make(map[uint]struct{})}
+		d.pending[recipientID] = dg
+	}
+	dg.messages[msg.ID] = struct{}{}
+}
+
+func (d *Dispatcher) clock() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return time.Now()
+}
+
+// Run flushes due digests until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	interval := d.DigestDelay / 5
+	if interval < time.Second {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	// a flush sends e-mails, so allow it a minute on top of the tick
+	lifecycle.Beat(ctx, interval+time.Minute)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			d.Flush(ctx)
+			lifecycle.Beat(ctx, interval+time.Minute)
+		}
+	}
+}
+
+// Flush sends every digest that is due. Digests of users who came back
+// online are dropped: they will see the messages in the app.
+func (d *Dispatcher) Flush(ctx context.Context) {
+	now := d.clock()
+	due := make(map[uint][]uint)
+
+	d.mu.Lock()
+	for userID, dg := range d.pending {
+		if now.Sub(dg.first) < d.DigestDelay || now.Sub(d.lastSent[userID]) < d.Throttle {
+			continue
+		}
+		delete(d.pending, userID)
+		if d.Presence != nil && d.Presence.IsOnline(userID) {
+			continue
+		}
+		ids := make([]uint, 0, len(dg.messages))
+		for id := range dg.messages {
+			ids = append(ids, id)
+		}
+		due[userID] = ids
+		d.lastSent[userID] = now
+	}
+	// past Throttle an entry holds nothing back
+	for userID, at := range d.lastSent {
+		if now.Sub(at) >= d.Throttle {
+			delete(d.lastSent, userID)
+		}
+	}
+	d.mu.Unlock()
+
+	for userID, ids := range due {
+		if err := d.deliver(ctx, userID, ids); err != nil {
+			slog.ErrorContext(ctx, "notify: digest failed", "user_id", userID, "err", err)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, userID uint, messageIDs []uint) error {
+	user, err := d.Repos.Users.ByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	prefs, err := d.Repos.Notifications.Preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !prefs.EmailEnabled && !prefs.PushEnabled {
+		return nil
+	}
+
+	// messages deleted since they were queued are left out
+	lines, err := d.Repos.Chat.MissedMessages(ctx, messageIDs)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	subject, body := d.render(lines)
+	var errs []error
+	if prefs.EmailEnabled && d.Email != nil && user.Email != "" {
+		if err := d.Email.SendEmail(ctx, user.Email, subject, body); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		}
+	}
+	if prefs.PushEnabled && d.Push != nil {
+		if err := d.sendPush(ctx, userID, subject, lines); err != nil {
+			errs = append(errs, fmt.Errorf("push: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) render(lines []service.MissedMessage) (string, string) {
+	convs := make(map[uint][]service.MissedMessage)
+	for _, l := range lines {
+		convs[l.ConversationID] = append(convs[l.ConversationID], l)
+	}
+	ids := make([]uint, 0, len(convs))
+	for id := range convs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	subject := fmt.Sprintf("Новые сообщения: %d", len(lines))
+	var b strings.Builder
+	fmt.Fprintf(&b, "Пока вас не было, пришло %d новых сообщений в %d диалогах.\n\n", len(lines), len(convs))
+	for _, id := range ids {
+		for _, l := range convs[id] {
+			fmt.Fprintf(&b, "%s: %s\n", l.SenderName, truncate(l.Content, 140))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Ответить: %s/\n", d.AppURL)
+	b.WriteString("\nНастроить уведомления можно в профиле.\n")
+	return subject, b.String()
+}
+
+func (d *Dispatcher) sendPush(ctx context.Context, userID uint, title string, lines []service.MissedMessage) error {
+	subs, err := d.Repos.Notifications.PushSubscriptions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	last := lines[len(lines)-1]
+	payload, err := json.Marshal(map[string]interface{}{
+		"title": title,
+		"body":  fmt.Sprintf("%s: %s", last.SenderName, truncate(last.Content, 100)),
+		"url":   d.AppURL + "/",
+		"tag":   "chat-digest",
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range subs {
+		err := d.Push.SendPush(ctx, PushSubscription{Endpoint: s.Endpoint, P256dh: s.P256dh, Auth: s.Auth}, payload)
+		switch {
+		case errors.Is(err, ErrSubscriptionGone):
+			_ = d.Repos.Notifications.DeletePushSubscription(ctx, userID, s.Endpoint)
+		case err != nil:
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
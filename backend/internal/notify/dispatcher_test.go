@@ -0,0 +1,179 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify/notifytest"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type dispatcherEnv struct {
+	d        *notify.Dispatcher
+	repos    service.Repositories
+	email    *notifytest.EmailSender
+	push     *notifytest.PushSender
+	presence *notifytest.Presence
+	now      time.Time
+	conv     uint
+	sender   *core.User
+	reader   *core.User
+}
+
+func newDispatcherEnv(t *testing.T) *dispatcherEnv {
+	t.Helper()
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Notify.DigestDelay = 5 * time.Minute
+	cfg.Notify.Throttle = 30 * time.Minute
+	e := &dispatcherEnv{
+		repos:    memory.NewRepositories(),
+		email:    &notifytest.EmailSender{},
+		push:     &notifytest.PushSender{},
+		presence: &notifytest.Presence{},
+		now:      time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	e.d = notify.NewDispatcher(e.repos, cfg, e.presence)
+	e.d.Email, e.d.Push = e.email, e.push
+	e.d.Now = func() time.Time { return e.now }
+
+	e.sender = &core.User{Email: "owner@example.com", Name: "Анна"}
+	e.reader = &core.User{Email: "tenant@example.com", Name: "Иван"}
+	for _, u := range []*core.User{e.sender, e.reader} {
+		if err := e.repos.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conv := &core.Conversation{InitiatorID: e.reader.ID, RecipientID: e.sender.ID}
+	if err := e.repos.Chat.CreateConversation(ctx, conv); err != nil {
+		t.Fatal(err)
+	}
+	e.conv = conv.ID
+	return e
+}
+
+// send stores a message from sender to reader and queues it.
+func (e *dispatcherEnv) send(t *testing.T, content string) core.Message {
+	t.Helper()
+	m := core.Message{ConversationID: e.conv, SenderID: e.sender.ID, Content: content}
+	if err := e.repos.Chat.CreateMessage(context.Background(), &m); err != nil {
+		t.Fatal(err)
+	}
+	e.d.MessageCreated(m, e.reader.ID)
+	return m
+}
+
+func (e *dispatcherEnv) flushAt(offset time.Duration) {
+	e.now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC).Add(offset)
+	e.d.Flush(context.Background())
+}
+
+func TestDispatcherDigest(t *testing.T) {
+	e := newDispatcherEnv(t)
+	m := e.send(t, "Здравствуйте, квартира свободна?")
+	e.d.MessageCreated(m, e.reader.ID) // a repeat collapses
+	e.send(t, "Можно посмотреть завтра?")
+
+	e.flushAt(4 * time.Minute)
+	if len(e.email.Sent()) != 0 {
+		t.Fatal("sent before DigestDelay")
+	}
+	e.flushAt(5 * time.Minute)
+	sent := e.email.Sent()
+	if len(sent) != 1 || sent[0].To != e.reader.Email || sent[0].Subject != "Новые сообщения: 2" ||
+		!strings.Contains(sent[0].Body, "Анна: Можно посмотреть завтра?") {
+		t.Fatalf("digest = %+v", sent)
+	}
+
+	// within Throttle the next digest waits
+	e.send(t, "Ау?")
+	e.flushAt(15 * time.Minute)
+	if len(e.email.Sent()) != 1 {
+		t.Fatal("throttle ignored")
+	}
+	e.flushAt(35 * time.Minute)
+	if len(e.email.Sent()) != 2 {
+		t.Fatal("digest after the throttle was not sent")
+	}
+	e.flushAt(65 * time.Minute)
+	if n := e.d.Throttled(); n != 0 {
+		t.Fatalf("remembers %d users after the throttle", n)
+	}
+}
+
+func TestDispatcherPresence(t *testing.T) {
+	e := newDispatcherEnv(t)
+	e.presence.Set(e.reader.ID, true)
+	e.send(t, "Вы онлайн")
+	e.flushAt(10 * time.Minute)
+	if len(e.email.Sent()) != 0 {
+		t.Fatal("queued for an online user")
+	}
+
+	// the user comes back before the digest goes out
+	e.presence.Set(e.reader.ID, false)
+	e.send(t, "А теперь?")
+	e.presence.Set(e.reader.ID, true)
+	e.flushAt(15 * time.Minute)
+	e.presence.Set(e.reader.ID, false)
+	e.flushAt(25 * time.Minute)
+	if len(e.email.Sent()) != 0 {
+		t.Fatal("digest sent to a user who came back")
+	}
+
+	// messages to oneself are never queued
+	e.d.MessageCreated(core.Message{ID: 99, SenderID: e.reader.ID}, e.reader.ID)
+	e.flushAt(35 * time.Minute)
+	if len(e.email.Sent()) != 0 {
+		t.Fatal("own message queued")
+	}
+}
+
+func TestDispatcherChannels(t *testing.T) {
+	ctx := context.Background()
+	e := newDispatcherEnv(t)
+	subs := []core.PushSubscription{
+		{UserID: e.reader.ID, Endpoint: "https://push.example.com/live", P256dh: "k", Auth: "a"},
+		{UserID: e.reader.ID, Endpoint: "https://push.example.com/gone", P256dh: "k", Auth: "a"},
+	}
+	for i := range subs {
+		if err := e.repos.Notifications.SavePushSubscription(ctx, &subs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.push.Gone = map[string]bool{subs[1].Endpoint: true}
+	if err := e.repos.Notifications.SavePreferences(ctx, &core.NotificationPreference{UserID: e.reader.ID, PushEnabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	e.send(t, "Только пуш")
+	deleted := e.send(t, "Удалённое")
+	now := e.now
+	deleted.DeletedAt = &now
+	if err := e.repos.Chat.UpdateMessage(ctx, &deleted); err != nil {
+		t.Fatal(err)
+	}
+	e.flushAt(5 * time.Minute)
+
+	if len(e.email.Sent()) != 0 {
+		t.Fatal("e-mail sent with e-mail disabled")
+	}
+	pushes := e.push.Sent()
+	if len(pushes) != 1 || pushes[0].Subscription.Endpoint != subs[0].Endpoint {
+		t.Fatalf("pushes = %+v", pushes)
+	}
+	var payload struct{ Title, Body string }
+	if err := json.Unmarshal(pushes[0].Payload, &payload); err != nil || payload.Title != "Новые сообщения: 1" || payload.Body != "Анна: Только пуш" {
+		t.Fatalf("payload = %s", pushes[0].Payload)
+	}
+	if left, _ := e.repos.Notifications.PushSubscriptions(ctx, e.reader.ID); len(left) != 1 || left[0].Endpoint != subs[0].Endpoint {
+		t.Fatalf("gone subscription kept: %+v", left)
+	}
+}
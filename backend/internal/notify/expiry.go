@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/lifecycle"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// ExpiryReminder periodically emits "expiring soon" notifications for paid
+// plans and promotions that end within Lead. Dedup keys on the
+// notifications keep it from repeating itself.
+type ExpiryReminder struct {
+	Repos    service.Repositories
+	Center   *Center
+	Lead     time.Duration
+	Interval time.Duration
+
+	Now func() time.Time // defaults to time.Now, tests override it
+}
+
+func (r *ExpiryReminder) Run(ctx context.Context) {
+	r.Check(ctx)
+	lifecycle.Beat(ctx, 2*r.Interval)
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			r.Check(ctx)
+			lifecycle.Beat(ctx, 2*r.Interval)
+		}
+	}
+}
+
+// Check runs one pass.
+func (r *ExpiryReminder) Check(ctx context.Context) {
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+	until := now.Add(r.Lead)
+
+	plans, err := r.Repos.Plans.Expiring(ctx, now, until)
+	if err != nil {
+		slog.ErrorContext(ctx, "notify: expiring plans", "err", err)
+	}
+	for _, p := range plans {
+		if err := r.Center.PlanExpiring(ctx, p); err != nil {
+			slog.ErrorContext(ctx, "notify: plan expiry", "plan_id", p.ID, "err", err)
+		}
+	}
+
+	promos, err := r.Repos.Properties.ExpiringPromotions(ctx, now, until)
+	if err != nil {
+		slog.ErrorContext(ctx, "notify: expiring promotions", "err", err)
+	}
+	for _, p := range promos {
+		if err := r.Center.PromotionExpiring(ctx, p.PropertyPromotion, p.Title); err != nil {
+			slog.ErrorContext(ctx, "notify: promotion expiry", "promotion_id", p.ID, "err", err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestExpiryReminder(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	repos := memory.NewRepositories()
+	r := &notify.ExpiryReminder{
+		Repos:  repos,
+		Center: notify.NewCenter(repos.Notifications),
+		Lead:   24 * time.Hour,
+		Now:    func() time.Time { return now },
+	}
+
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	plans := []core.UserPlan{
+		{UserID: 1, PlanType: "premium", ExpiresAt: at(23 * time.Hour)}, // due
+		{UserID: 2, PlanType: "premium", ExpiresAt: at(25 * time.Hour)}, // later
+		{UserID: 3, PlanType: "premium", ExpiresAt: at(-time.Hour)},     // already over
+		{UserID: 4, PlanType: "free", ExpiresAt: at(time.Hour)},
+	}
+	for i := range plans {
+		if err := repos.Plans.Create(ctx, &plans[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := &core.Property{OwnerID: 5, Title: "Студия у метро"}
+	if err := repos.Properties.Create(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	promos := []core.PropertyPromotion{
+		{PropertyID: p.ID, UserID: 5, StartsAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{PropertyID: p.ID, UserID: 6, StartsAt: now, ExpiresAt: now.Add(48 * time.Hour)},
+	}
+	for i := range promos {
+		if err := repos.Properties.CreatePromotion(ctx, &promos[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r.Check(ctx)
+	r.Check(ctx) // dedup keys keep the second pass quiet
+	notified := func(userID uint) []core.Notification {
+		items, err := repos.Notifications.List(ctx, service.NotificationFilter{UserID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+	if got := notified(1); len(got) != 1 || got[0].Type != core.NotificationPlanExpiring {
+		t.Fatalf("user 1: %+v", got)
+	}
+	for _, userID := range []uint{2, 3, 4, 6} {
+		if got := notified(userID); len(got) != 0 {
+			t.Fatalf("user %d: %+v", userID, got)
+		}
+	}
+	if got := notified(5); len(got) != 1 || got[0].Type != core.NotificationPromotionExpiring || got[0].Body != "Студия у метро — до 01.10.2026 13:00" {
+		t.Fatalf("user 5: %+v", got)
+	}
+
+	// a day later the second promotion is due
+	now = now.Add(25 * time.Hour)
+	r.Check(ctx)
+	if got := notified(6); len(got) != 1 {
+		t.Fatalf("user 6 a day later: %+v", got)
+	}
+}
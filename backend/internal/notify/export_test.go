@@ -0,0 +1,8 @@
+package notify
+
+// Throttled returns how many users the dispatcher remembers a digest for.
+func (d *Dispatcher) Throttled() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.lastSent)
+}
@@ -0,0 +1,89 @@
+// Package notifytest provides in-memory senders that record what the
+// notify dispatcher would have delivered.
+package notifytest
+
+import (
+	"context"
+	"sync"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+)
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// EmailSender records e-mails instead of sending them.
+type EmailSender struct {
+	mu   sync.Mutex
+	sent []Email
+	Err  error // returned from every call when set
+}
+
+func (s *EmailSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Err != nil {
+		return s.Err
+	}
+	s.sent = append(s.sent, Email{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+func (s *EmailSender) Sent() []Email {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Email(nil), s.sent...)
+}
+
+type Push struct {
+	Subscription notify.PushSubscription
+	Payload      []byte
+}
+
+// PushSender records pushes. Endpoints listed in Gone answer with
+// notify.ErrSubscriptionGone, like an expired browser subscription.
+type PushSender struct {
+	mu   sync.Mutex
+	sent []Push
+	Gone map[string]bool
+}
+
+func (s *PushSender) SendPush(ctx context.Context, sub notify.PushSubscription, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Gone[sub.Endpoint] {
+		return notify.ErrSubscriptionGone
+	}
+	s.sent = append(s.sent, Push{Subscription: sub, Payload: payload})
+	return nil
+}
+
+func (s *PushSender) Sent() []Push {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Push(nil), s.sent...)
+}
+
+// Presence is a settable online list.
+type Presence struct {
+	mu     sync.Mutex
+	online map[uint]bool
+}
+
+func (p *Presence) Set(userID uint, online bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.online == nil {
+		p.online = make(map[uint]bool)
+	}
+	p.online[userID] = online
+}
+
+func (p *Presence) IsOnline(userID uint) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.online[userID]
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strings"
+
+	webpush "github.com/SherClockHolmes/webpush-go"
+)
+
+// EmailSender delivers a plain-text e-mail.
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// PushSubscription is what a browser gives us from PushManager.subscribe().
+type PushSubscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// PushSender delivers an encrypted Web Push payload to one subscription.
+type PushSender interface {
+	SendPush(ctx context.Context, sub PushSubscription, payload []byte) error
+}
+
+// ErrSubscriptionGone is returned by a PushSender when the push service
+// says the subscription expired; the dispatcher then forgets it.
+var ErrSubscriptionGone = errors.New("notify: push subscription gone")
+
+// SMTPSender sends e-mail through an SMTP relay with PLAIN auth.
+type SMTPSender struct {
+	Addr string // host:port
+	Host string
+	User string
+	Pass string
+	From string
+}
+
+func NewSMTPSender(host string, port int, user, pass, from string) *SMTPSender {
+	return &SMTPSender{Addr: fmt.Sprintf("%s:%d", host, port), Host: host, User: user, Pass: pass, From: from}
+}
+
+func (s *SMTPSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	var a smtp.Auth
+	if s.User != "" {
+		a = smtp.PlainAuth("", s.User, s.Pass, s.Host)
+	}
+	msg := strings.Join([]string{
+		"From: " + s.From,
+		"To: " + to,
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+		"",
+		body,
+	}, "\r\n")
+	return smtp.SendMail(s.Addr, a, s.From, []string{to}, []byte(msg))
+}
+
+// LogEmailSender writes e-mails to the log. Used when SMTP is not
+// configured so local development still shows what would be sent. The
+// body quotes chat messages, so it is only logged at debug level.
+type LogEmailSender struct{}
+
+func (LogEmailSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	slog.InfoContext(ctx, "notify: email not sent, SMTP is not configured", "to", to, "subject", subject)
+	slog.DebugContext(ctx, "notify: email body", "to", to, "body", body)
+	return nil
+}
+
+// WebPushSender sends notifications signed with the server's VAPID keys.
+type WebPushSender struct {
+	PublicKey  string
+	PrivateKey string
+	Subject    string
+	TTL        int // seconds the push service keeps an undelivered message
+}
+
+func NewWebPushSender(publicKey, privateKey, subject string) *WebPushSender {
+	return &WebPushSender{PublicKey: publicKey, PrivateKey: privateKey, Subject: subject, TTL: 24 * 60 * 60}
+}
+
+func (s *WebPushSender) SendPush(ctx context.Context, sub PushSubscription, payload []byte) error {
+	resp, err := webpush.SendNotificationWithContext(ctx, payload, &webpush.Subscription{
+		Endpoint: sub.Endpoint,
+		Keys:     webpush.Keys{P256dh: sub.P256dh, Auth: sub.Auth},
+	}, &webpush.Options{
+		Subscriber:      s.Subject,
+		VAPIDPublicKey:  s.PublicKey,
+		VAPIDPrivateKey: s.PrivateKey,
+		TTL:             s.TTL,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("notify: push service returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package notify_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/notify"
+)
+
+func TestLogEmailSenderHidesBody(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	defer slog.SetDefault(prev)
+
+	if err := (notify.LogEmailSender{}).SendEmail(context.Background(), "tenant@example.com", "Новые сообщения: 1", "Анна: мой телефон +79991234567"); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "tenant@example.com") || strings.Contains(out, "+79991234567") {
+		t.Fatalf("log = %s", out)
+	}
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+type Analytics struct {
+	s *Store
+}
+
+// statKey is a listing's day; days are UTC dates.
+type statKey struct {
+	propertyID uint
+	day        string
+}
+
+// viewKey is a visitor counted on a listing's day.
+type viewKey struct {
+	statKey
+	viewer string
+}
+
+func keyOf(propertyID uint, day time.Time) statKey {
+	return statKey{propertyID, day.UTC().Format(time.DateOnly)}
+}
+
+func (r *Analytics) AddView(_ context.Context, propertyID uint, day time.Time, viewer string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	v := viewKey{keyOf(propertyID, day), viewer}
+	if r.s.views[v] {
+		return nil
+	}
+	r.s.views[v] = true
+	return r.add(propertyID, day, core.MetricViews)
+}
+
+func (r *Analytics) Add(_ context.Context, propertyID uint, day time.Time, metric string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.add(propertyID, day, metric)
+}
+
+// add bumps a counter; callers hold mu.
+func (r *Analytics) add(propertyID uint, day time.Time, metric string) error {
+	k := keyOf(propertyID, day)
+	st, ok := r.s.stats[k]
+	if !ok {
+		d, _ := time.Parse(time.DateOnly, k.day)
+		st = core.ListingStat{PropertyID: propertyID, Day: d}
+	}
+	switch metric {
+	case core.MetricViews:
+		st.Views++
+	case core.MetricReveals:
+		st.Reveals++
+	case core.MetricFavorites:
+		st.Favorites++
+	case core.MetricConversations:
+		st.Conversations++
+	default:
+		return fmt.Errorf("unknown metric %q", metric)
+	}
+	r.s.stats[k] = st
+	return nil
+}
+
+func (r *Analytics) Daily(_ context.Context, propertyIDs []uint, from, to time.Time) ([]core.ListingStat, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ids := map[uint]bool{}
+	for _, id := range propertyIDs {
+		ids[id] = true
+	}
+	lo, hi := from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly)
+	items := []core.ListingStat{}
+	for k, st := range r.s.stats {
+		if ids[k.propertyID] && lo <= k.day && k.day <= hi {
+			items = append(items, st)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Day.Equal(items[j].Day) {
+			return items[i].Day.Before(items[j].Day)
+		}
+		return items[i].PropertyID < items[j].PropertyID
+	})
+	return items, nil
+}
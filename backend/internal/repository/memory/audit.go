@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Audit struct {
+	s *Store
+}
+
+func (r *Audit) Create(_ context.Context, e *core.AuditEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	e.ID = r.s.nextID()
+	e.CreatedAt = r.s.Now()
+	r.s.audit[e.ID] = *e
+	return nil
+}
+
+func (r *Audit) List(_ context.Context, f service.AuditFilter) ([]core.AuditEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	all := sortedByID(r.s.audit)
+	out := []core.AuditEvent{}
+	for i := len(all) - 1; i >= 0 && (f.Limit <= 0 || len(out) < f.Limit); i-- {
+		e := all[i]
+		if f.Action != "" && e.Action != f.Action ||
+			f.ActorID > 0 && (e.ActorID == nil || *e.ActorID != f.ActorID) ||
+			f.Before > 0 && e.ID >= f.Before {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
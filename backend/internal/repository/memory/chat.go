@@ -0,0 +1,313 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Chat struct {
+	s *Store
+}
+
+func (r *Chat) ConversationByID(_ context.Context, id uint) (*core.Conversation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	c, ok := r.s.convs[id]
+	if !ok {
+		return nil, service.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *Chat) FindConversation(_ context.Context, propertyID, a, b uint) (*core.Conversation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, c := range sortedByID(r.s.convs) {
+		if c.PropertyID == nil || *c.PropertyID != propertyID {
+			continue
+		}
+		if c.InitiatorID == a && c.RecipientID == b || c.InitiatorID == b && c.RecipientID == a {
+			return &c, nil
+		}
+	}
+	return nil, service.ErrNotFound
+}
+
+func (r *Chat) CreateConversation(_ context.Context, c *core.Conversation) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	c.ID = r.s.nextID()
+	c.CreatedAt = r.s.Now()
+	c.UpdatedAt = c.CreatedAt
+	r.s.convs[c.ID] = *c
+	return nil
+}
+
+func (r *Chat) Inbox(_ context.Context, recipientID uint) ([]core.Conversation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	out := []core.Conversation{}
+	for _, c := range sortedByID(r.s.convs) {
+		if c.RecipientID != recipientID {
+			continue
+		}
+		if _, blocked := r.s.blocks[[2]uint{recipientID, c.InitiatorID}]; blocked {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (r *Chat) CountConversations(_ context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return int64(len(r.s.convs)), nil
+}
+
+func (r *Chat) CreateMessage(_ context.Context, m *core.Message) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.createMessage(m)
+	return nil
+}
+
+func (r *Chat) createMessage(m *core.Message) {
+	m.ID = r.s.nextID()
+	m.CreatedAt = r.s.Now()
+	m.UpdatedAt = m.CreatedAt
+	r.s.messages[m.ID] = *m
+}
+
+func (r *Chat) MessageByID(_ context.Context, conversationID, id uint) (*core.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	m, ok := r.s.messages[id]
+	if !ok || m.ConversationID != conversationID {
+		return nil, service.ErrNotFound
+	}
+	return &m, nil
+}
+
+func (r *Chat) UpdateMessage(_ context.Context, m *core.Message) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.updateMessage(m)
+}
+
+func (r *Chat) updateMessage(m *core.Message) error {
+	stored, ok := r.s.messages[m.ID]
+	if !ok {
+		return nil
+	}
+	stored.Content = m.Content
+	stored.EditedAt = m.EditedAt
+	stored.DeletedAt = m.DeletedAt
+	stored.UpdatedAt = r.s.Now()
+	m.UpdatedAt = stored.UpdatedAt
+	r.s.messages[m.ID] = stored
+	return nil
+}
+
+// conversationMessages returns the conversation's messages by ascending
+// ID; callers hold mu.
+func (r *Chat) conversationMessages(conversationID uint) []core.Message {
+	out := []core.Message{}
+	for _, m := range sortedByID(r.s.messages) {
+		if m.ConversationID == conversationID {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (r *Chat) MessagesBefore(_ context.Context, conversationID, before uint, limit int) ([]core.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	msgs := r.conversationMessages(conversationID)
+	out := []core.Message{}
+	for i := len(msgs) - 1; i >= 0 && len(out) < limit; i-- {
+		if before == 0 || msgs[i].ID < before {
+			out = append(out, msgs[i])
+		}
+	}
+	return out, nil
+}
+
+func (r *Chat) MessagesChanged(_ context.Context, conversationID uint, since, until time.Time, limit int) ([]core.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	out := []core.Message{}
+	for _, m := range r.conversationMessages(conversationID) {
+		if m.UpdatedAt.After(since) && !m.UpdatedAt.After(until) {
+			out = append(out, m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].UpdatedAt.Before(out[j].UpdatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *Chat) LastMessage(_ context.Context, conversationID uint) (*core.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	msgs := r.conversationMessages(conversationID)
+	if len(msgs) == 0 {
+		return nil, service.ErrNotFound
+	}
+	return &msgs[len(msgs)-1], nil
+}
+
+func (r *Chat) CountUnread(_ context.Context, conversationID, readerID uint) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var n int64
+	for _, m := range r.conversationMessages(conversationID) {
+		if m.SenderID != readerID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *Chat) MissedMessages(_ context.Context, ids []uint) ([]service.MissedMessage, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []service.MissedMessage{}
+	for _, m := range sortedByID(r.s.messages) {
+		if m.DeletedAt == nil && slices.Contains(ids, m.ID) {
+			items = append(items, service.MissedMessage{ConversationID: m.ConversationID, SenderName: r.s.users[m.SenderID].Name, Content: m.Content})
+		}
+	}
+	return items, nil
+}
+
+func (r *Chat) Block(_ context.Context, b *core.UserBlock) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	key := [2]uint{b.BlockerID, b.BlockedID}
+	if _, ok := r.s.blocks[key]; ok {
+		return service.ErrConflict
+	}
+	b.CreatedAt = r.s.Now()
+	r.s.blocks[key] = *b
+	return nil
+}
+
+func (r *Chat) Unblock(_ context.Context, blockerID, blockedID uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.blocks, [2]uint{blockerID, blockedID})
+	return nil
+}
+
+func (r *Chat) HasBlocked(_ context.Context, blockerID, blockedID uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	_, ok := r.s.blocks[[2]uint{blockerID, blockedID}]
+	return ok, nil
+}
+
+func (r *Chat) ListBlocked(_ context.Context, blockerID uint) ([]service.BlockedUser, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []service.BlockedUser{}
+	for _, b := range r.s.blocks {
+		u, ok := r.s.users[b.BlockedID]
+		if b.BlockerID != blockerID || !ok {
+			continue
+		}
+		items = append(items, service.BlockedUser{UserID: u.ID, Name: u.Name, BlockedAt: b.CreatedAt})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].BlockedAt.After(items[j].BlockedAt) })
+	return items, nil
+}
+
+func (r *Chat) CreateFlagged(_ context.Context, f *core.FlaggedMessage) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	f.ID = r.s.nextID()
+	f.CreatedAt = r.s.Now()
+	r.s.flagged[f.ID] = *f
+	return nil
+}
+
+func (r *Chat) FlaggedByID(_ context.Context, id uint) (*core.FlaggedMessage, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	f, ok := r.s.flagged[id]
+	if !ok {
+		return nil, service.ErrNotFound
+	}
+	return &f, nil
+}
+
+func (r *Chat) ListFlagged(_ context.Context, status string, before uint, limit int) ([]core.FlaggedMessage, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	all := sortedByID(r.s.flagged)
+	out := []core.FlaggedMessage{}
+	for i := len(all) - 1; i >= 0 && len(out) < limit; i-- {
+		f := all[i]
+		if status != "" && f.Status != status || before > 0 && f.ID >= before {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func (r *Chat) ReviewFlagged(_ context.Context, f *core.FlaggedMessage) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.reviewFlagged(f)
+}
+
+func (r *Chat) reviewFlagged(f *core.FlaggedMessage) error {
+	stored, ok := r.s.flagged[f.ID]
+	if !ok || stored.Status != "pending" {
+		return service.ErrAlreadyReviewed
+	}
+	r.s.flagged[f.ID] = *f
+	return nil
+}
+
+func (r *Chat) ReleaseFlagged(_ context.Context, f *core.FlaggedMessage, msg *core.Message) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.reviewFlagged(f); err != nil {
+		return err
+	}
+	if msg.ID != 0 {
+		r.updateMessage(msg)
+	} else {
+		r.createMessage(msg)
+	}
+	f.MessageID = &msg.ID
+	r.s.flagged[f.ID] = *f
+	return nil
+}
+
+// sortedByID returns the map values by ascending ID.
+func sortedByID[T interface {
+	core.Conversation | core.Message | core.FlaggedMessage | core.AuditEvent |
+		core.Notification | core.PushSubscription
+}](m map[uint]T) []T {
+	ids := make([]uint, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	out := make([]T, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, m[id])
+	}
+	return out
+}
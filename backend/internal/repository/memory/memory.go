@@ -0,0 +1,179 @@
+// Package memory implements the service repositories in process memory.
+// It is meant for tests: data lives as long as the Store.
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// Store holds every table behind one lock.
+type Store struct {
+	mu sync.Mutex
+	// txMu runs transactions one at a time, which is all the isolation
+	// tests need
+	txMu sync.Mutex
+
+	users      map[uint]core.User
+	properties map[uint]core.Property
+	images     map[uint]core.PropertyImage
+	plans      map[uint]core.UserPlan
+	promotions map[uint]core.PropertyPromotion
+	convs      map[uint]core.Conversation
+	messages   map[uint]core.Message
+	blocks     map[[2]uint]core.UserBlock
+	flagged    map[uint]core.FlaggedMessage
+	audit      map[uint]core.AuditEvent
+	reveals    map[uint]core.ContactReveal
+	favorites  map[[2]uint]bool // user, property
+	stats      map[statKey]core.ListingStat
+	views      map[viewKey]bool
+	amenities  []core.Amenity
+
+	notifications map[uint]core.Notification
+	prefs         map[uint]core.NotificationPreference // by user
+	pushSubs      map[uint]core.PushSubscription
+
+	lastID uint
+
+	// Now stamps CreatedAt/UpdatedAt the way the database would.
+	Now func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:      map[uint]core.User{},
+		properties: map[uint]core.Property{},
+		images:     map[uint]core.PropertyImage{},
+		plans:      map[uint]core.UserPlan{},
+		promotions: map[uint]core.PropertyPromotion{},
+		convs:      map[uint]core.Conversation{},
+		messages:   map[uint]core.Message{},
+		blocks:     map[[2]uint]core.UserBlock{},
+		flagged:    map[uint]core.FlaggedMessage{},
+		audit:      map[uint]core.AuditEvent{},
+		reveals:    map[uint]core.ContactReveal{},
+		favorites:  map[[2]uint]bool{},
+		stats:      map[statKey]core.ListingStat{},
+		views:      map[viewKey]bool{},
+		amenities:  catalog,
+
+		notifications: map[uint]core.Notification{},
+		prefs:         map[uint]core.NotificationPreference{},
+		pushSubs:      map[uint]core.PushSubscription{},
+
+		Now: time.Now,
+	}
+}
+
+// catalog is the amenity catalog the migrations install.
+var catalog = []core.Amenity{
+	{ID: 1, Code: "internet", LabelRU: "Интернет", LabelEN: "Internet", Icon: "wifi", Order: 1},
+	{ID: 2, Code: "fridge", LabelRU: "Холодильник", LabelEN: "Fridge", Icon: "refrigerator", Order: 2},
+	{ID: 3, Code: "washer", LabelRU: "Стиральная машина", LabelEN: "Washing machine", Icon: "washing-machine", Order: 3},
+	{ID: 4, Code: "dishwasher", LabelRU: "Посудомоечная машина", LabelEN: "Dishwasher", Icon: "utensils", Order: 4},
+	{ID: 5, Code: "air_conditioning", LabelRU: "Кондиционер", LabelEN: "Air conditioning", Icon: "air-vent", Order: 5},
+	{ID: 6, Code: "tv", LabelRU: "Телевизор", LabelEN: "TV", Icon: "tv", Order: 6},
+	{ID: 7, Code: "microwave", LabelRU: "Микроволновка", LabelEN: "Microwave", Icon: "microwave", Order: 7},
+	{ID: 8, Code: "elevator", LabelRU: "Лифт", LabelEN: "Elevator", Icon: "arrow-up-down", Order: 8},
+	{ID: 9, Code: "concierge", LabelRU: "Консьерж", LabelEN: "Concierge", Icon: "bell", Order: 9},
+	{ID: 10, Code: "workspace", LabelRU: "Рабочее место", LabelEN: "Workspace", Icon: "laptop", Order: 10},
+}
+
+// NewRepositories returns repositories sharing a fresh store.
+func NewRepositories() service.Repositories {
+	return NewStore().Repositories()
+}
+
+func (s *Store) Repositories() service.Repositories {
+	return service.Repositories{
+		Users:      &Users{s},
+		Properties: &Properties{s},
+		Plans:      &Plans{s},
+		Chat:       &Chat{s},
+		Audit:      &Audit{s},
+		Analytics:  &Analytics{s},
+
+		Notifications: &Notifications{s},
+
+		Tx: &Tx{s},
+	}
+}
+
+// Tx serializes transactions and restores the store when fn fails.
+// Transactions do not nest.
+type Tx struct {
+	s *Store
+}
+
+func (t *Tx) WithinTx(_ context.Context, fn func(repos service.Repositories) error) error {
+	t.s.txMu.Lock()
+	defer t.s.txMu.Unlock()
+	snap := t.s.snapshot()
+	if err := fn(t.s.Repositories()); err != nil {
+		t.s.restore(snap)
+		return err
+	}
+	return nil
+}
+
+// snapshot copies every table.
+func (s *Store) snapshot() *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Store{
+		users:      maps.Clone(s.users),
+		properties: maps.Clone(s.properties),
+		images:     maps.Clone(s.images),
+		plans:      maps.Clone(s.plans),
+		promotions: maps.Clone(s.promotions),
+		convs:      maps.Clone(s.convs),
+		messages:   maps.Clone(s.messages),
+		blocks:     maps.Clone(s.blocks),
+		flagged:    maps.Clone(s.flagged),
+		audit:      maps.Clone(s.audit),
+		reveals:    maps.Clone(s.reveals),
+		favorites:  maps.Clone(s.favorites),
+		stats:      maps.Clone(s.stats),
+		views:      maps.Clone(s.views),
+
+		notifications: maps.Clone(s.notifications),
+		prefs:         maps.Clone(s.prefs),
+		pushSubs:      maps.Clone(s.pushSubs),
+	}
+}
+
+// restore puts back the tables of a snapshot. IDs handed out since stay
+// used, like a database sequence.
+func (s *Store) restore(snap *Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = snap.users
+	s.properties = snap.properties
+	s.images = snap.images
+	s.plans = snap.plans
+	s.promotions = snap.promotions
+	s.convs = snap.convs
+	s.messages = snap.messages
+	s.blocks = snap.blocks
+	s.flagged = snap.flagged
+	s.audit = snap.audit
+	s.reveals = snap.reveals
+	s.favorites = snap.favorites
+	s.stats = snap.stats
+	s.views = snap.views
+	s.notifications = snap.notifications
+	s.prefs = snap.prefs
+	s.pushSubs = snap.pushSubs
+}
+
+// nextID hands out IDs from one sequence; callers hold mu.
+func (s *Store) nextID() uint {
+	s.lastID++
+	return s.lastID
+}
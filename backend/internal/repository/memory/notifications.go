@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Notifications struct {
+	s *Store
+}
+
+func (r *Notifications) Create(_ context.Context, n *core.Notification) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if n.DedupKey != nil {
+		for _, other := range r.s.notifications {
+			if other.UserID == n.UserID && other.DedupKey != nil && *other.DedupKey == *n.DedupKey {
+				return false, nil
+			}
+		}
+	}
+	n.ID = r.s.nextID()
+	n.CreatedAt = r.s.Now()
+	r.s.notifications[n.ID] = *n
+	return true, nil
+}
+
+func (r *Notifications) List(_ context.Context, f service.NotificationFilter) ([]core.Notification, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	all := sortedByID(r.s.notifications)
+	out := []core.Notification{}
+	for i := len(all) - 1; i >= 0 && (f.Limit <= 0 || len(out) < f.Limit); i-- {
+		n := all[i]
+		if n.UserID != f.UserID ||
+			f.Before > 0 && n.ID >= f.Before ||
+			f.UnreadOnly && n.ReadAt != nil {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func (r *Notifications) CountUnread(_ context.Context, userID uint) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var n int64
+	for _, item := range r.s.notifications {
+		if item.UserID == userID && item.ReadAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *Notifications) MarkRead(_ context.Context, userID, id uint, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	n, ok := r.s.notifications[id]
+	if !ok || n.UserID != userID {
+		return service.ErrNotFound
+	}
+	if n.ReadAt == nil {
+		n.ReadAt = &at
+		r.s.notifications[id] = n
+	}
+	return nil
+}
+
+func (r *Notifications) MarkAllRead(_ context.Context, userID uint, at time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var updated int64
+	for id, n := range r.s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &at
+			r.s.notifications[id] = n
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+func (r *Notifications) Preferences(_ context.Context, userID uint) (*core.NotificationPreference, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	p, ok := r.s.prefs[userID]
+	if !ok {
+		p = core.NotificationPreference{UserID: userID, EmailEnabled: true, PushEnabled: true}
+	}
+	return &p, nil
+}
+
+func (r *Notifications) SavePreferences(_ context.Context, p *core.NotificationPreference) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	p.UpdatedAt = r.s.Now()
+	r.s.prefs[p.UserID] = *p
+	return nil
+}
+
+func (r *Notifications) SavePushSubscription(_ context.Context, s *core.PushSubscription) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, other := range r.s.pushSubs {
+		if other.Endpoint == s.Endpoint {
+			other.UserID, other.P256dh, other.Auth = s.UserID, s.P256dh, s.Auth
+			r.s.pushSubs[id] = other
+			*s = other
+			return nil
+		}
+	}
+	s.ID = r.s.nextID()
+	s.CreatedAt = r.s.Now()
+	r.s.pushSubs[s.ID] = *s
+	return nil
+}
+
+func (r *Notifications) DeletePushSubscription(_ context.Context, userID uint, endpoint string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, s := range r.s.pushSubs {
+		if s.UserID == userID && s.Endpoint == endpoint {
+			delete(r.s.pushSubs, id)
+		}
+	}
+	return nil
+}
+
+func (r *Notifications) PushSubscriptions(_ context.Context, userID uint) ([]core.PushSubscription, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []core.PushSubscription{}
+	for _, s := range sortedByID(r.s.pushSubs) {
+		if s.UserID == userID {
+			items = append(items, s)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Plans struct {
+	s *Store
+}
+
+func (r *Plans) ByUser(_ context.Context, userID uint) (*core.UserPlan, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, p := range r.s.plans {
+		if p.UserID == userID {
+			return &p, nil
+		}
+	}
+	return nil, service.ErrNotFound
+}
+
+func (r *Plans) FirstOrCreate(_ context.Context, def *core.UserPlan) (*core.UserPlan, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, p := range r.s.plans {
+		if p.UserID == def.UserID {
+			return &p, nil
+		}
+	}
+	r.create(def)
+	p := *def
+	return &p, nil
+}
+
+func (r *Plans) Create(_ context.Context, p *core.UserPlan) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, other := range r.s.plans {
+		if other.UserID == p.UserID {
+			return service.ErrConflict
+		}
+	}
+	r.create(p)
+	return nil
+}
+
+func (r *Plans) create(p *core.UserPlan) {
+	p.ID = r.s.nextID()
+	p.CreatedAt = r.s.Now()
+	p.UpdatedAt = p.CreatedAt
+	r.s.plans[p.ID] = *p
+}
+
+func (r *Plans) Save(_ context.Context, p *core.UserPlan) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if p.ID == 0 {
+		p.ID = r.s.nextID()
+		p.CreatedAt = r.s.Now()
+	}
+	p.UpdatedAt = r.s.Now()
+	r.s.plans[p.ID] = *p
+	return nil
+}
+
+func (r *Plans) Expiring(_ context.Context, from, until time.Time) ([]core.UserPlan, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []core.UserPlan{}
+	for _, p := range r.s.plans {
+		if p.PlanType != "free" && p.ExpiresAt != nil && p.ExpiresAt.After(from) && !p.ExpiresAt.After(until) {
+			items = append(items, p)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
@@ -0,0 +1,346 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Properties struct {
+	s *Store
+}
+
+func (r *Properties) Create(_ context.Context, p *core.Property) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	p.ID = r.s.nextID()
+	p.CreatedAt = r.s.Now()
+	for i := range p.Images {
+		p.Images[i].ID = r.s.nextID()
+		p.Images[i].PropertyID = p.ID
+		r.s.images[p.Images[i].ID] = p.Images[i]
+	}
+	order := map[string]int{}
+	for _, a := range r.s.amenities {
+		order[a.Code] = a.Order
+	}
+	stored := *p
+	stored.Images = nil
+	stored.Amenities = slices.Clone(p.Amenities)
+	sort.Slice(stored.Amenities, func(i, j int) bool {
+		return order[stored.Amenities[i]] < order[stored.Amenities[j]]
+	})
+	r.s.properties[p.ID] = stored
+	return nil
+}
+
+// withImages returns a copy of p with its images sorted by Order and its
+// own amenity slice; callers hold mu.
+func (r *Properties) withImages(p core.Property) core.Property {
+	p.Amenities = append([]string{}, p.Amenities...)
+	p.Images = []core.PropertyImage{}
+	for _, img := range r.s.images {
+		if img.PropertyID == p.ID {
+			p.Images = append(p.Images, img)
+		}
+	}
+	sort.Slice(p.Images, func(i, j int) bool {
+		if p.Images[i].Order != p.Images[j].Order {
+			return p.Images[i].Order < p.Images[j].Order
+		}
+		return p.Images[i].ID < p.Images[j].ID
+	})
+	return p
+}
+
+func (r *Properties) ByID(_ context.Context, id uint) (*core.Property, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	p, ok := r.s.properties[id]
+	if !ok {
+		return nil, service.ErrNotFound
+	}
+	p = r.withImages(p)
+	return &p, nil
+}
+
+// promoted reports whether the property is at the top of the feed at now;
+// callers hold mu.
+func (r *Properties) promoted(propertyID uint, now time.Time) bool {
+	for _, promo := range r.s.promotions {
+		if promo.PropertyID == propertyID && promo.Tier == core.PromotionTopCity && promo.Running(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Properties) List(_ context.Context, f service.PropertyFilter) ([]core.Property, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	city := strings.ToLower(f.City)
+	now := r.s.Now()
+	items := []core.Property{}
+	promoted := map[uint]bool{}
+	for _, p := range r.s.properties {
+		if city != "" && !strings.Contains(strings.ToLower(p.City), city) && !strings.Contains(strings.ToLower(p.Address), city) {
+			continue
+		}
+		if !matches(p, f) {
+			continue
+		}
+		promoted[p.ID] = r.promoted(p.ID, now)
+		items = append(items, r.withImages(p))
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if promoted[a.ID] != promoted[b.ID] {
+			return promoted[a.ID]
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	if f.Limit > 0 && len(items) > f.Limit {
+		items = items[:f.Limit]
+	}
+	return items, nil
+}
+
+// matches applies the amenity and attribute parts of f.
+func matches(p core.Property, f service.PropertyFilter) bool {
+	if p.ModerationStatus == core.ListingRejected {
+		return false
+	}
+	for _, code := range f.Amenities {
+		if !slices.Contains(p.Amenities, code) {
+			return false
+		}
+	}
+	if f.PetsAllowed && (p.PetsAllowed == nil || !*p.PetsAllowed) {
+		return false
+	}
+	if f.Furnished && (p.Furnished == nil || !*p.Furnished) {
+		return false
+	}
+	return true
+}
+
+func (r *Properties) ListByOwner(_ context.Context, ownerID uint) ([]core.Property, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []core.Property{}
+	for _, p := range r.s.properties {
+		if p.OwnerID == ownerID {
+			items = append(items, r.withImages(p))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].ID > items[j].ID
+	})
+	return items, nil
+}
+
+func (r *Properties) CountByOwner(_ context.Context, ownerID uint) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var n int64
+	for _, p := range r.s.properties {
+		if p.OwnerID == ownerID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *Properties) Count(_ context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return int64(len(r.s.properties)), nil
+}
+
+func (r *Properties) AddImage(_ context.Context, img *core.PropertyImage) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	img.ID = r.s.nextID()
+	r.s.images[img.ID] = *img
+	return nil
+}
+
+func (r *Properties) Delete(_ context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.properties[id]; !ok {
+		return service.ErrNotFound
+	}
+	delete(r.s.properties, id)
+	for imgID, img := range r.s.images {
+		if img.PropertyID == id {
+			delete(r.s.images, imgID)
+		}
+	}
+	for revealID, rev := range r.s.reveals {
+		if rev.PropertyID == id {
+			delete(r.s.reveals, revealID)
+		}
+	}
+	for fav := range r.s.favorites {
+		if fav[1] == id {
+			delete(r.s.favorites, fav)
+		}
+	}
+	for k := range r.s.stats {
+		if k.propertyID == id {
+			delete(r.s.stats, k)
+		}
+	}
+	for v := range r.s.views {
+		if v.propertyID == id {
+			delete(r.s.views, v)
+		}
+	}
+	return nil
+}
+
+func (r *Properties) RunningPromotions(_ context.Context, propertyIDs []uint, now time.Time) ([]core.PropertyPromotion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []core.PropertyPromotion{}
+	for _, promo := range r.s.promotions {
+		if slices.Contains(propertyIDs, promo.PropertyID) && promo.Running(now) {
+			items = append(items, promo)
+		}
+	}
+	return items, nil
+}
+
+func (r *Properties) CreatePromotion(_ context.Context, p *core.PropertyPromotion) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	p.ID = r.s.nextID()
+	p.CreatedAt = r.s.Now()
+	r.s.promotions[p.ID] = *p
+	return nil
+}
+
+func (r *Properties) Promotions(_ context.Context, propertyID uint) ([]core.PropertyPromotion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []core.PropertyPromotion{}
+	for _, promo := range r.s.promotions {
+		if promo.PropertyID == propertyID {
+			items = append(items, promo)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].StartsAt.Equal(items[j].StartsAt) {
+			return items[i].StartsAt.Before(items[j].StartsAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+func (r *Properties) PromotionCredits(_ context.Context, userID uint, since time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	n := 0
+	for _, promo := range r.s.promotions {
+		if promo.UserID == userID && !promo.CreatedAt.Before(since) {
+			n += promo.Credits
+		}
+	}
+	return n, nil
+}
+
+func (r *Properties) ExpiringPromotions(_ context.Context, from, until time.Time) ([]service.ExpiringPromotion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []service.ExpiringPromotion{}
+	for _, promo := range r.s.promotions {
+		p, ok := r.s.properties[promo.PropertyID]
+		if ok && promo.ExpiresAt.After(from) && !promo.ExpiresAt.After(until) {
+			items = append(items, service.ExpiringPromotion{PropertyPromotion: promo, Title: p.Title})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (r *Properties) Amenities(_ context.Context) ([]core.Amenity, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return slices.Clone(r.s.amenities), nil
+}
+
+func (r *Properties) SetContactVisibility(_ context.Context, id uint, phone, email string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	p, ok := r.s.properties[id]
+	if !ok {
+		return service.ErrNotFound
+	}
+	p.PhoneVisibility, p.EmailVisibility = phone, email
+	r.s.properties[id] = p
+	return nil
+}
+
+func (r *Properties) SetModeration(_ context.Context, id uint, status, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	p, ok := r.s.properties[id]
+	if !ok {
+		return service.ErrNotFound
+	}
+	p.ModerationStatus, p.ModerationReason = status, reason
+	r.s.properties[id] = p
+	return nil
+}
+
+func (r *Properties) CreateReveal(_ context.Context, rev *core.ContactReveal) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rev.ID = r.s.nextID()
+	rev.CreatedAt = r.s.Now()
+	r.s.reveals[rev.ID] = *rev
+	return nil
+}
+
+func (r *Properties) CountRevealers(_ context.Context, propertyID uint) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	viewers := map[uint]bool{}
+	for _, rev := range r.s.reveals {
+		if rev.PropertyID == propertyID {
+			viewers[rev.ViewerID] = true
+		}
+	}
+	return int64(len(viewers)), nil
+}
+
+func (r *Properties) AddFavorite(_ context.Context, userID, propertyID uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	k := [2]uint{userID, propertyID}
+	if r.s.favorites[k] {
+		return false, nil
+	}
+	r.s.favorites[k] = true
+	return true, nil
+}
+
+func (r *Properties) RemoveFavorite(_ context.Context, userID, propertyID uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.favorites, [2]uint{userID, propertyID})
+	return nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Users struct {
+	s *Store
+}
+
+func (r *Users) Create(_ context.Context, u *core.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, other := range r.s.users {
+		if other.Email == u.Email {
+			return service.ErrConflict
+		}
+	}
+	u.ID = r.s.nextID()
+	u.CreatedAt = r.s.Now()
+	r.s.users[u.ID] = *u
+	return nil
+}
+
+func (r *Users) ByID(_ context.Context, id uint) (*core.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[id]
+	if !ok {
+		return nil, service.ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r *Users) ByEmail(_ context.Context, email string) (*core.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, service.ErrNotFound
+}
+
+func (r *Users) UpdateRole(_ context.Context, id uint, role string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if u, ok := r.s.users[id]; ok {
+		u.Role = role
+		r.s.users[id] = u
+	}
+	return nil
+}
+
+func (r *Users) Count(_ context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return int64(len(r.s.users)), nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+type Analytics struct {
+	DB *gorm.DB
+}
+
+// date is the UTC date of t as Postgres reads it.
+func date(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+func (r *Analytics) AddView(ctx context.Context, propertyID uint, day time.Time, viewer string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`INSERT INTO listing_views (property_id, day, viewer) VALUES (?, ?, ?)
+			ON CONFLICT DO NOTHING`, propertyID, date(day), viewer)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return add(tx, propertyID, day, core.MetricViews)
+	})
+}
+
+func (r *Analytics) Add(ctx context.Context, propertyID uint, day time.Time, metric string) error {
+	return add(r.DB.WithContext(ctx), propertyID, day, metric)
+}
+
+// add upserts the day and bumps one counter. metric becomes a column name,
+// so only the known metrics pass.
+func add(db *gorm.DB, propertyID uint, day time.Time, metric string) error {
+	switch metric {
+	case core.MetricViews, core.MetricReveals, core.MetricFavorites, core.MetricConversations:
+	default:
+		return fmt.Errorf("unknown metric %q", metric)
+	}
+	return db.Exec(fmt.Sprintf(`INSERT INTO listing_stats (property_id, day, %[1]s) VALUES (?, ?, 1)
+		ON CONFLICT (property_id, day) DO UPDATE SET %[1]s = listing_stats.%[1]s + 1`, metric),
+		propertyID, date(day)).Error
+}
+
+func (r *Analytics) Daily(ctx context.Context, propertyIDs []uint, from, to time.Time) ([]core.ListingStat, error) {
+	items := []core.ListingStat{}
+	if len(propertyIDs) == 0 {
+		return items, nil
+	}
+	err := r.DB.WithContext(ctx).
+		Where("property_id IN ? AND day BETWEEN ? AND ?", propertyIDs, date(from), date(to)).
+		Order("day, property_id").Find(&items).Error
+	return items, err
+}
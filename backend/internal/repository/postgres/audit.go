@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Audit struct {
+	DB *gorm.DB
+}
+
+func (r *Audit) Create(ctx context.Context, e *core.AuditEvent) error {
+	return translate(r.DB.WithContext(ctx).Create(e).Error)
+}
+
+func (r *Audit) List(ctx context.Context, f service.AuditFilter) ([]core.AuditEvent, error) {
+	q := r.DB.WithContext(ctx).Order("id desc").Limit(f.Limit)
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.ActorID > 0 {
+		q = q.Where("actor_id = ?", f.ActorID)
+	}
+	if f.Before > 0 {
+		q = q.Where("id < ?", f.Before)
+	}
+	var items []core.AuditEvent
+	err := q.Find(&items).Error
+	return items, err
+}
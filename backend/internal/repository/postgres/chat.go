@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Chat struct {
+	DB *gorm.DB
+}
+
+func (r *Chat) ConversationByID(ctx context.Context, id uint) (*core.Conversation, error) {
+	var c core.Conversation
+	if err := r.DB.WithContext(ctx).First(&c, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &c, nil
+}
+
+func (r *Chat) FindConversation(ctx context.Context, propertyID, a, b uint) (*core.Conversation, error) {
+	var c core.Conversation
+	if err := r.DB.WithContext(ctx).
+		Where("(initiator_id = ? AND recipient_id = ? OR initiator_id = ? AND recipient_id = ?) AND property_id = ?", a, b, b, a, propertyID).
+		First(&c).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &c, nil
+}
+
+func (r *Chat) CreateConversation(ctx context.Context, c *core.Conversation) error {
+	return translate(r.DB.WithContext(ctx).Create(c).Error)
+}
+
+func (r *Chat) Inbox(ctx context.Context, recipientID uint) ([]core.Conversation, error) {
+	db := r.DB.WithContext(ctx)
+	var convs []core.Conversation
+	err := db.Where("recipient_id = ?", recipientID).
+		Where("initiator_id NOT IN (?)", db.Model(&core.UserBlock{}).Select("blocked_id").Where("blocker_id = ?", recipientID)).
+		Find(&convs).Error
+	return convs, err
+}
+
+func (r *Chat) CountConversations(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.Conversation{}).Count(&n).Error
+	return n, err
+}
+
+func (r *Chat) CreateMessage(ctx context.Context, m *core.Message) error {
+	return translate(r.DB.WithContext(ctx).Create(m).Error)
+}
+
+func (r *Chat) MessageByID(ctx context.Context, conversationID, id uint) (*core.Message, error) {
+	var m core.Message
+	if err := r.DB.WithContext(ctx).Where("id = ? AND conversation_id = ?", id, conversationID).First(&m).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &m, nil
+}
+
+func (r *Chat) UpdateMessage(ctx context.Context, m *core.Message) error {
+	return r.DB.WithContext(ctx).Model(m).Updates(map[string]interface{}{
+		"content":    m.Content,
+		"edited_at":  m.EditedAt,
+		"deleted_at": m.DeletedAt,
+	}).Error
+}
+
+func (r *Chat) MessagesBefore(ctx context.Context, conversationID, before uint, limit int) ([]core.Message, error) {
+	q := r.DB.WithContext(ctx).Where("conversation_id = ?", conversationID)
+	if before > 0 {
+		q = q.Where("id < ?", before)
+	}
+	var msgs []core.Message
+	err := q.Order("id desc").Limit(limit).Find(&msgs).Error
+	return msgs, err
+}
+
+func (r *Chat) MessagesChanged(ctx context.Context, conversationID uint, since, until time.Time, limit int) ([]core.Message, error) {
+	var msgs []core.Message
+	err := r.DB.WithContext(ctx).
+		Where("conversation_id = ? AND updated_at > ? AND updated_at <= ?", conversationID, since, until).
+		Order("updated_at asc, id asc").Limit(limit).Find(&msgs).Error
+	return msgs, err
+}
+
+func (r *Chat) LastMessage(ctx context.Context, conversationID uint) (*core.Message, error) {
+	var m core.Message
+	if err := r.DB.WithContext(ctx).Where("conversation_id = ?", conversationID).Order("created_at DESC").First(&m).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &m, nil
+}
+
+func (r *Chat) CountUnread(ctx context.Context, conversationID, readerID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.Message{}).
+		Where("conversation_id = ? AND sender_id != ?", conversationID, readerID).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *Chat) MissedMessages(ctx context.Context, ids []uint) ([]service.MissedMessage, error) {
+	var items []service.MissedMessage
+	err := r.DB.WithContext(ctx).Table("messages").
+		Select("messages.conversation_id, users.name AS sender_name, messages.content").
+		Joins("JOIN users ON users.id = messages.sender_id").
+		Where("messages.id IN ? AND messages.deleted_at IS NULL", ids).
+		Order("messages.id").
+		Scan(&items).Error
+	return items, err
+}
+
+func (r *Chat) Block(ctx context.Context, b *core.UserBlock) error {
+	return translate(r.DB.WithContext(ctx).Create(b).Error)
+}
+
+func (r *Chat) Unblock(ctx context.Context, blockerID, blockedID uint) error {
+	return r.DB.WithContext(ctx).Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&core.UserBlock{}).Error
+}
+
+func (r *Chat) HasBlocked(ctx context.Context, blockerID, blockedID uint) (bool, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.UserBlock{}).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *Chat) ListBlocked(ctx context.Context, blockerID uint) ([]service.BlockedUser, error) {
+	items := []service.BlockedUser{}
+	err := r.DB.WithContext(ctx).Table("user_blocks").
+		Select("user_blocks.blocked_id AS user_id, users.name AS name, user_blocks.created_at AS blocked_at").
+		Joins("JOIN users ON users.id = user_blocks.blocked_id").
+		Where("user_blocks.blocker_id = ?", blockerID).
+		Order("user_blocks.created_at DESC").
+		Scan(&items).Error
+	return items, err
+}
+
+func (r *Chat) CreateFlagged(ctx context.Context, f *core.FlaggedMessage) error {
+	return translate(r.DB.WithContext(ctx).Create(f).Error)
+}
+
+func (r *Chat) FlaggedByID(ctx context.Context, id uint) (*core.FlaggedMessage, error) {
+	var f core.FlaggedMessage
+	if err := r.DB.WithContext(ctx).First(&f, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &f, nil
+}
+
+func (r *Chat) ListFlagged(ctx context.Context, status string, before uint, limit int) ([]core.FlaggedMessage, error) {
+	q := r.DB.WithContext(ctx).Order("id desc").Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if before > 0 {
+		q = q.Where("id < ?", before)
+	}
+	var items []core.FlaggedMessage
+	err := q.Find(&items).Error
+	return items, err
+}
+
+func (r *Chat) ReviewFlagged(ctx context.Context, f *core.FlaggedMessage) error {
+	res := r.DB.WithContext(ctx).Model(&core.FlaggedMessage{}).
+		Where("id = ? AND status = ?", f.ID, "pending").
+		Updates(map[string]interface{}{
+			"status":      f.Status,
+			"reviewer_id": f.ReviewerID,
+			"reviewed_at": f.ReviewedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return service.ErrAlreadyReviewed
+	}
+	return nil
+}
+
+func (r *Chat) ReleaseFlagged(ctx context.Context, f *core.FlaggedMessage, msg *core.Message) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// claim the row first so a second reviewer gets nothing to release
+		if err := (&Chat{DB: tx}).ReviewFlagged(ctx, f); err != nil {
+			return err
+		}
+		if msg.ID != 0 {
+			if err := (&Chat{DB: tx}).UpdateMessage(ctx, msg); err != nil {
+				return err
+			}
+		} else if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		f.MessageID = &msg.ID
+		return tx.Model(f).Update("message_id", f.MessageID).Error
+	})
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Notifications struct {
+	DB *gorm.DB
+}
+
+func (r *Notifications) Create(ctx context.Context, n *core.Notification) (bool, error) {
+	res := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(n)
+	if res.Error != nil {
+		return false, translate(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *Notifications) List(ctx context.Context, f service.NotificationFilter) ([]core.Notification, error) {
+	q := r.DB.WithContext(ctx).Where("user_id = ?", f.UserID)
+	if f.Before > 0 {
+		q = q.Where("id < ?", f.Before)
+	}
+	if f.UnreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	items := []core.Notification{}
+	err := q.Order("id desc").Find(&items).Error
+	return items, err
+}
+
+func (r *Notifications) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+func (r *Notifications) MarkRead(ctx context.Context, userID, id uint, at time.Time) error {
+	res := r.DB.WithContext(ctx).Model(&core.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", at))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Notifications) MarkAllRead(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	res := r.DB.WithContext(ctx).Model(&core.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+func (r *Notifications) Preferences(ctx context.Context, userID uint) (*core.NotificationPreference, error) {
+	prefs := core.NotificationPreference{UserID: userID, EmailEnabled: true, PushEnabled: true}
+	err := r.DB.WithContext(ctx).First(&prefs, "user_id = ?", userID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (r *Notifications) SavePreferences(ctx context.Context, p *core.NotificationPreference) error {
+	return translate(r.DB.WithContext(ctx).Save(p).Error)
+}
+
+func (r *Notifications) SavePushSubscription(ctx context.Context, s *core.PushSubscription) error {
+	return translate(r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "p256dh", "auth"}),
+	}).Create(s).Error)
+}
+
+func (r *Notifications) DeletePushSubscription(ctx context.Context, userID uint, endpoint string) error {
+	return r.DB.WithContext(ctx).
+		Where("user_id = ? AND endpoint = ?", userID, endpoint).
+		Delete(&core.PushSubscription{}).Error
+}
+
+func (r *Notifications) PushSubscriptions(ctx context.Context, userID uint) ([]core.PushSubscription, error) {
+	var items []core.PushSubscription
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&items).Error
+	return items, err
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+type Plans struct {
+	DB *gorm.DB
+}
+
+func (r *Plans) ByUser(ctx context.Context, userID uint) (*core.UserPlan, error) {
+	var p core.UserPlan
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).First(&p).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &p, nil
+}
+
+func (r *Plans) FirstOrCreate(ctx context.Context, def *core.UserPlan) (*core.UserPlan, error) {
+	db := r.DB.WithContext(ctx)
+	lock := func() (*core.UserPlan, error) {
+		var p core.UserPlan
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", def.UserID).First(&p).Error
+		return &p, err
+	}
+	// most users have a plan already, so only the first call inserts
+	p, err := lock()
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return p, translate(err)
+	}
+	// a parallel insert for the same user is skipped rather than failing
+	// the surrounding transaction
+	if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).
+		Create(def).Error; err != nil {
+		return nil, translate(err)
+	}
+	p, err = lock()
+	return p, translate(err)
+}
+
+func (r *Plans) Create(ctx context.Context, p *core.UserPlan) error {
+	return translate(r.DB.WithContext(ctx).Create(p).Error)
+}
+
+func (r *Plans) Save(ctx context.Context, p *core.UserPlan) error {
+	return translate(r.DB.WithContext(ctx).Save(p).Error)
+}
+
+func (r *Plans) Expiring(ctx context.Context, from, until time.Time) ([]core.UserPlan, error) {
+	var items []core.UserPlan
+	err := r.DB.WithContext(ctx).
+		Where("plan_type <> ? AND expires_at > ? AND expires_at <= ?", "free", from, until).
+		Order("id").Find(&items).Error
+	return items, err
+}
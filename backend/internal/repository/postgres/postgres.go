@@ -0,0 +1,54 @@
+// Package postgres implements the service repositories on top of GORM.
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func NewRepositories(db *gorm.DB) service.Repositories {
+	return service.Repositories{
+		Users:      &Users{DB: db},
+		Properties: &Properties{DB: db},
+		Plans:      &Plans{DB: db},
+		Chat:       &Chat{DB: db},
+		Audit:      &Audit{DB: db},
+		Analytics:  &Analytics{DB: db},
+
+		Notifications: &Notifications{DB: db},
+
+		Tx: &Tx{DB: db},
+	}
+}
+
+// Tx starts transactions on DB. Inside a transaction it nests through
+// savepoints.
+type Tx struct {
+	DB *gorm.DB
+}
+
+func (t *Tx) WithinTx(ctx context.Context, fn func(repos service.Repositories) error) error {
+	return t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepositories(tx))
+	})
+}
+
+// translate maps driver errors onto the service sentinel errors.
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service.ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return service.ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/postgres"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service/servicetest"
+	"gofuckbiz/snimayprosto-rent-easy/internal/testdb"
+)
+
+// The owner starts without a plan row, so the requests also race on
+// creating it.
+func TestListingLimitConcurrent(t *testing.T) {
+	db := testdb.Open(t)
+	s := service.New(postgres.NewRepositories(db), config.Defaults())
+	owner, err := s.Users.Register(context.Background(), "owner@example.com", "secret1", "Owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := servicetest.CreateListingsConcurrently(t, s, owner.ID, 20)
+	if want := int64(service.Plans["free"].MaxListings); created != want {
+		t.Fatalf("created %d listings in parallel, want %d", created, want)
+	}
+}
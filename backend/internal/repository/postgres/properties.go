@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+type Properties struct {
+	DB *gorm.DB
+}
+
+func (r *Properties) Create(ctx context.Context, p *core.Property) error {
+	return translate(r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		if len(p.Amenities) == 0 {
+			return nil
+		}
+		return tx.Exec(`INSERT INTO property_amenities (property_id, amenity_id)
+			SELECT ?, id FROM amenities WHERE code IN ?`, p.ID, p.Amenities).Error
+	}))
+}
+
+func (r *Properties) ByID(ctx context.Context, id uint) (*core.Property, error) {
+	var p core.Property
+	if err := r.DB.WithContext(ctx).Preload("Images").First(&p, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	sort.Slice(p.Images, func(i, j int) bool {
+		return p.Images[i].Order < p.Images[j].Order
+	})
+	items := []core.Property{p}
+	if err := r.loadAmenities(ctx, items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// loadAmenities fills in the amenity codes of items in catalog order.
+func (r *Properties) loadAmenities(ctx context.Context, items []core.Property) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		items[i].Amenities = []string{}
+	}
+	var rows []struct {
+		PropertyID uint
+		Code       string
+	}
+	err := r.DB.WithContext(ctx).Table("property_amenities").
+		Select("property_amenities.property_id, amenities.code").
+		Joins("JOIN amenities ON amenities.id = property_amenities.amenity_id").
+		Where("property_amenities.property_id IN ?", ids).
+		Order(`amenities."order"`).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]*core.Property, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+	for _, row := range rows {
+		p := byID[row.PropertyID]
+		p.Amenities = append(p.Amenities, row.Code)
+	}
+	return nil
+}
+
+func (r *Properties) List(ctx context.Context, f service.PropertyFilter) ([]core.Property, error) {
+	var items []core.Property
+	// listings at the top of the feed first, then the newest
+	q := r.DB.WithContext(ctx).Preload("Images").
+		Select(`properties.*, EXISTS (SELECT 1 FROM property_promotions pp
+			WHERE pp.property_id = properties.id AND pp.tier = ?
+			AND pp.starts_at <= NOW() AND pp.expires_at > NOW()) AS is_promoted`, core.PromotionTopCity).
+		Where("properties.moderation_status <> ?", core.ListingRejected).
+		Order("is_promoted DESC, created_at DESC, id DESC")
+	if f.City != "" {
+		like := "%" + f.City + "%"
+		q = q.Where("city ILIKE ? OR address ILIKE ?", like, like)
+	}
+	if len(f.Amenities) > 0 {
+		q = q.Where(`properties.id IN (SELECT pa.property_id FROM property_amenities pa
+			JOIN amenities a ON a.id = pa.amenity_id WHERE a.code IN ?
+			GROUP BY pa.property_id HAVING count(*) = ?)`, f.Amenities, len(f.Amenities))
+	}
+	if f.PetsAllowed {
+		q = q.Where("properties.pets_allowed")
+	}
+	if f.Furnished {
+		q = q.Where("properties.furnished")
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	if err := q.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, r.loadAmenities(ctx, items)
+}
+
+func (r *Properties) ListByOwner(ctx context.Context, ownerID uint) ([]core.Property, error) {
+	var items []core.Property
+	if err := r.DB.WithContext(ctx).Preload("Images").Where("owner_id = ?", ownerID).Order("created_at DESC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, r.loadAmenities(ctx, items)
+}
+
+func (r *Properties) CountByOwner(ctx context.Context, ownerID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.Property{}).Where("owner_id = ?", ownerID).Count(&n).Error
+	return n, err
+}
+
+func (r *Properties) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.Property{}).Count(&n).Error
+	return n, err
+}
+
+func (r *Properties) AddImage(ctx context.Context, img *core.PropertyImage) error {
+	return translate(r.DB.WithContext(ctx).Create(img).Error)
+}
+
+func (r *Properties) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&core.PropertyImage{}, &core.Favorite{}, &core.PropertyAmenity{}, &core.ContactReveal{},
+			&core.ListingStat{}, &core.ListingView{}} {
+			if err := tx.Where("property_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Delete(&core.Property{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return service.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *Properties) RunningPromotions(ctx context.Context, propertyIDs []uint, now time.Time) ([]core.PropertyPromotion, error) {
+	items := []core.PropertyPromotion{}
+	if len(propertyIDs) == 0 {
+		return items, nil
+	}
+	err := r.DB.WithContext(ctx).
+		Where("property_id IN ? AND starts_at <= ? AND expires_at > ?", propertyIDs, now, now).
+		Find(&items).Error
+	return items, err
+}
+
+func (r *Properties) CreatePromotion(ctx context.Context, p *core.PropertyPromotion) error {
+	return translate(r.DB.WithContext(ctx).Create(p).Error)
+}
+
+func (r *Properties) Promotions(ctx context.Context, propertyID uint) ([]core.PropertyPromotion, error) {
+	var items []core.PropertyPromotion
+	err := r.DB.WithContext(ctx).Where("property_id = ?", propertyID).Order("starts_at, id").Find(&items).Error
+	return items, err
+}
+
+func (r *Properties) PromotionCredits(ctx context.Context, userID uint, since time.Time) (int, error) {
+	var n int
+	err := r.DB.WithContext(ctx).Model(&core.PropertyPromotion{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Select("COALESCE(SUM(credits), 0)").Scan(&n).Error
+	return n, err
+}
+
+func (r *Properties) ExpiringPromotions(ctx context.Context, from, until time.Time) ([]service.ExpiringPromotion, error) {
+	var items []service.ExpiringPromotion
+	err := r.DB.WithContext(ctx).Table("property_promotions").
+		Select("property_promotions.*, properties.title").
+		Joins("JOIN properties ON properties.id = property_promotions.property_id").
+		Where("property_promotions.expires_at > ? AND property_promotions.expires_at <= ?", from, until).
+		Order("property_promotions.id").
+		Scan(&items).Error
+	return items, err
+}
+
+func (r *Properties) Amenities(ctx context.Context) ([]core.Amenity, error) {
+	var items []core.Amenity
+	err := r.DB.WithContext(ctx).Order(`"order", id`).Find(&items).Error
+	return items, err
+}
+
+func (r *Properties) SetContactVisibility(ctx context.Context, id uint, phone, email string) error {
+	res := r.DB.WithContext(ctx).Model(&core.Property{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"phone_visibility": phone, "email_visibility": email})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Properties) SetModeration(ctx context.Context, id uint, status, reason string) error {
+	res := r.DB.WithContext(ctx).Model(&core.Property{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"moderation_status": status, "moderation_reason": reason})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Properties) CreateReveal(ctx context.Context, rev *core.ContactReveal) error {
+	return translate(r.DB.WithContext(ctx).Create(rev).Error)
+}
+
+func (r *Properties) CountRevealers(ctx context.Context, propertyID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.ContactReveal{}).
+		Where("property_id = ?", propertyID).Distinct("viewer_id").Count(&n).Error
+	return n, err
+}
+
+func (r *Properties) AddFavorite(ctx context.Context, userID, propertyID uint) (bool, error) {
+	res := r.DB.WithContext(ctx).Exec(`INSERT INTO favorites (user_id, property_id) VALUES (?, ?)
+		ON CONFLICT DO NOTHING`, userID, propertyID)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *Properties) RemoveFavorite(ctx context.Context, userID, propertyID uint) error {
+	return r.DB.WithContext(ctx).
+		Where("user_id = ? AND property_id = ?", userID, propertyID).Delete(&core.Favorite{}).Error
+}
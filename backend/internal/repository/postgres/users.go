@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+type Users struct {
+	DB *gorm.DB
+}
+
+func (r *Users) Create(ctx context.Context, u *core.User) error {
+	return translate(r.DB.WithContext(ctx).Create(u).Error)
+}
+
+func (r *Users) ByID(ctx context.Context, id uint) (*core.User, error) {
+	var u core.User
+	if err := r.DB.WithContext(ctx).First(&u, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &u, nil
+}
+
+func (r *Users) ByEmail(ctx context.Context, email string) (*core.User, error) {
+	var u core.User
+	if err := r.DB.WithContext(ctx).Where("email = ?", email).First(&u).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &u, nil
+}
+
+func (r *Users) UpdateRole(ctx context.Context, id uint, role string) error {
+	return r.DB.WithContext(ctx).Model(&core.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+func (r *Users) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&core.User{}).Count(&n).Error
+	return n, err
+}
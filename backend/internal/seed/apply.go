@@ -0,0 +1,242 @@
+package seed
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/auth"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+//go:embed images/*.jpg
+var images embed.FS
+
+// fixtures are the listing photos shipped with the binary.
+var fixtures = []string{"flat_1.jpg", "flat_2.jpg"}
+
+// ErrAlreadySeeded is returned by Apply when seeded accounts exist. Reset
+// them first to load a different dataset.
+var ErrAlreadySeeded = errors.New("seed: database already seeded")
+
+// Summary counts what Apply stored.
+type Summary struct {
+	Users, Properties, Images, Promotions, Conversations, Messages int
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d users, %d properties, %d images, %d promotions, %d conversations, %d messages",
+		s.Users, s.Properties, s.Images, s.Promotions, s.Conversations, s.Messages)
+}
+
+// Apply stores ds in one transaction and writes the listing photos to
+// uploadsDir. Timestamps are relative to now. Running it on a seeded
+// database does nothing and returns ErrAlreadySeeded.
+func Apply(ctx context.Context, db *gorm.DB, ds Dataset, uploadsDir string, now time.Time) (Summary, error) {
+	var sum Summary
+	seeded, err := Seeded(ctx, db)
+	if err != nil {
+		return sum, err
+	}
+	if seeded {
+		return sum, ErrAlreadySeeded
+	}
+	hash, err := auth.HashPassword(Password)
+	if err != nil {
+		return sum, err
+	}
+
+	type upload struct{ fixture, name string }
+	var uploads []upload
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := make([]core.User, len(ds.Users))
+		props := make([][]core.Property, len(ds.Users))
+		for i, u := range ds.Users {
+			users[i] = u.User
+			users[i].PasswordHash = hash
+			users[i].CreatedAt = now.Add(-30 * 24 * time.Hour)
+			if err := tx.Create(&users[i]).Error; err != nil {
+				return err
+			}
+			sum.Users++
+
+			if u.Plan != nil {
+				plan := *u.Plan
+				plan.UserID = users[i].ID
+				if u.ExpiresIn > 0 {
+					expires := now.Add(u.ExpiresIn)
+					plan.ExpiresAt = &expires
+				}
+				if err := tx.Create(&plan).Error; err != nil {
+					return err
+				}
+			}
+
+			for j, p := range u.Properties {
+				prop := p.Property
+				prop.OwnerID = users[i].ID
+				prop.CreatedAt = now.Add(-time.Duration(len(u.Properties)-j) * 24 * time.Hour)
+				if err := tx.Create(&prop).Error; err != nil {
+					return err
+				}
+				if len(prop.Amenities) > 0 {
+					err := tx.Exec(`INSERT INTO property_amenities (property_id, amenity_id)
+						SELECT ?, id FROM amenities WHERE code IN ?`, prop.ID, prop.Amenities).Error
+					if err != nil {
+						return err
+					}
+				}
+				sum.Properties++
+				for k, fixture := range p.Images {
+					name := fmt.Sprintf("seed_%d_%d.jpg", prop.ID, k)
+					img := core.PropertyImage{PropertyID: prop.ID, URL: "/uploads/" + name, Order: k}
+					if err := tx.Create(&img).Error; err != nil {
+						return err
+					}
+					uploads = append(uploads, upload{fixture, name})
+					sum.Images++
+				}
+				if p.Promoted > 0 {
+					promo := core.PropertyPromotion{PropertyID: prop.ID, UserID: prop.OwnerID, Product: "top_city_7d",
+						Tier: core.PromotionTopCity, StartsAt: now.Add(p.Promoted - 7*24*time.Hour), ExpiresAt: now.Add(p.Promoted)}
+					if err := tx.Create(&promo).Error; err != nil {
+						return err
+					}
+					sum.Promotions++
+				}
+				props[i] = append(props[i], prop)
+			}
+		}
+
+		for _, c := range ds.Conversations {
+			prop := props[c.Landlord][c.Property]
+			conv := core.Conversation{
+				PropertyID:  &prop.ID,
+				InitiatorID: users[c.Tenant].ID,
+				RecipientID: users[c.Landlord].ID,
+			}
+			if len(c.Messages) > 0 {
+				conv.CreatedAt = now.Add(-c.Messages[0].Ago)
+				last := now.Add(-c.Messages[len(c.Messages)-1].Ago)
+				conv.LastMessageAt = &last
+			}
+			if err := tx.Create(&conv).Error; err != nil {
+				return err
+			}
+			sum.Conversations++
+			for _, m := range c.Messages {
+				sender := conv.RecipientID
+				if m.FromTenant {
+					sender = conv.InitiatorID
+				}
+				at := now.Add(-m.Ago)
+				msg := core.Message{
+					ConversationID: conv.ID,
+					SenderID:       sender,
+					Type:           "text",
+					Content:        m.Content,
+					CreatedAt:      at,
+					UpdatedAt:      at,
+				}
+				if err := tx.Create(&msg).Error; err != nil {
+					return err
+				}
+				sum.Messages++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+
+	if uploadsDir != "" {
+		if err := os.MkdirAll(uploadsDir, 0o755); err != nil {
+			return sum, err
+		}
+		for _, u := range uploads {
+			data, err := images.ReadFile("images/" + u.fixture)
+			if err != nil {
+				return sum, err
+			}
+			if err := os.WriteFile(filepath.Join(uploadsDir, u.name), data, 0o644); err != nil {
+				return sum, err
+			}
+		}
+	}
+	return sum, nil
+}
+
+// Seeded reports whether seeded accounts exist.
+func Seeded(ctx context.Context, db *gorm.DB) (bool, error) {
+	var n int64
+	err := db.WithContext(ctx).Model(&core.User{}).Where("email LIKE ?", "%@"+Domain).Count(&n).Error
+	return n > 0, err
+}
+
+// Reset deletes the seeded accounts with everything they own or took part
+// in, and the seeded photos in uploadsDir. Other data is left alone.
+func Reset(ctx context.Context, db *gorm.DB, uploadsDir string) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := func() *gorm.DB {
+			return tx.Model(&core.User{}).Select("id").Where("email LIKE ?", "%@"+Domain)
+		}
+		props := func() *gorm.DB {
+			return tx.Model(&core.Property{}).Select("id").Where("owner_id IN (?)", users())
+		}
+		convs := func() *gorm.DB {
+			return tx.Model(&core.Conversation{}).Select("id").
+				Where("initiator_id IN (?) OR recipient_id IN (?) OR property_id IN (?)", users(), users(), props())
+		}
+
+		steps := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&core.Message{}, "conversation_id IN (?)", []interface{}{convs()}},
+			{&core.FlaggedMessage{}, "conversation_id IN (?)", []interface{}{convs()}},
+			{&core.Conversation{}, "id IN (?)", []interface{}{convs()}},
+			{&core.Favorite{}, "user_id IN (?) OR property_id IN (?)", []interface{}{users(), props()}},
+			{&core.PropertyImage{}, "property_id IN (?)", []interface{}{props()}},
+			{&core.PropertyAmenity{}, "property_id IN (?)", []interface{}{props()}},
+			{&core.ContactReveal{}, "property_id IN (?) OR viewer_id IN (?)", []interface{}{props(), users()}},
+			{&core.ListingStat{}, "property_id IN (?)", []interface{}{props()}},
+			{&core.ListingView{}, "property_id IN (?)", []interface{}{props()}},
+			{&core.PropertyPromotion{}, "property_id IN (?) OR user_id IN (?)", []interface{}{props(), users()}},
+			{&core.Property{}, "id IN (?)", []interface{}{props()}},
+			{&core.UserPlan{}, "user_id IN (?)", []interface{}{users()}},
+			{&core.UserBlock{}, "blocker_id IN (?) OR blocked_id IN (?)", []interface{}{users(), users()}},
+			{&core.Notification{}, "user_id IN (?)", []interface{}{users()}},
+			{&core.NotificationPreference{}, "user_id IN (?)", []interface{}{users()}},
+			{&core.PushSubscription{}, "user_id IN (?)", []interface{}{users()}},
+			{&core.User{}, "email LIKE ?", []interface{}{"%@" + Domain}},
+		}
+		for _, s := range steps {
+			if err := tx.Where(s.query, s.args...).Delete(s.model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || uploadsDir == "" {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(uploadsDir, "seed_*.jpg"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package seed
+
+// name is a Russian name with the latin spelling used in e-mails.
+type name struct{ ru, lat string }
+
+var maleNames = []name{
+	{"Александр", "alexander"}, {"Дмитрий", "dmitry"}, {"Максим", "maxim"},
+	{"Сергей", "sergey"}, {"Андрей", "andrey"}, {"Алексей", "alexey"},
+	{"Иван", "ivan"}, {"Михаил", "mikhail"}, {"Никита", "nikita"}, {"Артём", "artem"},
+}
+
+var femaleNames = []name{
+	{"Анна", "anna"}, {"Мария", "maria"}, {"Елена", "elena"},
+	{"Ольга", "olga"}, {"Наталья", "natalia"}, {"Екатерина", "ekaterina"},
+	{"Татьяна", "tatiana"}, {"Ирина", "irina"}, {"Дарья", "daria"}, {"Полина", "polina"},
+}
+
+// Surnames take an "а" in the feminine form.
+var surnames = []name{
+	{"Иванов", "ivanov"}, {"Смирнов", "smirnov"}, {"Кузнецов", "kuznetsov"},
+	{"Попов", "popov"}, {"Васильев", "vasiliev"}, {"Петров", "petrov"},
+	{"Соколов", "sokolov"}, {"Михайлов", "mikhailov"}, {"Новиков", "novikov"},
+	{"Фёдоров", "fedorov"}, {"Морозов", "morozov"}, {"Волков", "volkov"},
+	{"Алексеев", "alekseev"}, {"Лебедев", "lebedev"}, {"Семёнов", "semenov"},
+}
+
+type city struct {
+	name     string
+	lat, lng float64
+	streets  []string
+	price    float64 // multiplier on the base monthly rent
+}
+
+var cities = []city{
+	{"Москва", 55.7558, 37.6173, []string{"ул. Тверская", "ул. Арбат", "Ленинский пр.", "ул. Профсоюзная", "Кутузовский пр."}, 1.8},
+	{"Санкт-Петербург", 59.9343, 30.3351, []string{"Невский пр.", "Литейный пр.", "ул. Садовая", "Московский пр.", "ул. Рубинштейна"}, 1.4},
+	{"Казань", 55.7961, 49.1064, []string{"ул. Баумана", "ул. Пушкина", "ул. Чистопольская", "пр. Победы"}, 1.0},
+	{"Новосибирск", 55.0302, 82.9204, []string{"Красный пр.", "ул. Ленина", "ул. Кирова", "ул. Гоголя"}, 0.9},
+	{"Екатеринбург", 56.8389, 60.6057, []string{"ул. Ленина", "ул. Малышева", "ул. 8 Марта", "ул. Вайнера"}, 1.0},
+	{"Сочи", 43.5855, 39.7231, []string{"ул. Навагинская", "Курортный пр.", "ул. Войкова", "ул. Орджоникидзе"}, 1.3},
+}
+
+// amenities are codes of the amenity catalog.
+var amenities = []string{
+	"internet", "fridge", "washer", "dishwasher", "air_conditioning",
+	"tv", "microwave", "elevator", "concierge", "workspace",
+}
+
+var propertyTypes = []string{"apartment", "apartment", "apartment", "studio", "room", "house"}
+
+var titleHooks = []string{
+	"у метро", "в центре", "с ремонтом", "с видом на парк", "в новом доме",
+	"рядом с университетом", "для семьи", "в тихом районе",
+}
+
+var descriptions = []string{
+	"Светлая и уютная, после косметического ремонта. Рядом магазины, школа и остановка.",
+	"Есть всё для жизни: мебель, техника, быстрый интернет. Заезжайте с одним чемоданом.",
+	"Тихий двор, закрытая территория, консьерж. Соседи спокойные.",
+	"Окна во двор, хорошая шумоизоляция. До центра 15 минут на транспорте.",
+	"Просторная кухня, большой балкон, кладовка. Можно с небольшими животными.",
+}
+
+// chat is one scripted exchange; lines alternate starting with the tenant.
+var chats = [][]string{
+	{
+		"Здравствуйте! Квартира ещё сдаётся?",
+		"Добрый день, да, свободна с начала месяца.",
+		"Можно посмотреть в субботу?",
+		"Конечно, давайте в 12:00.",
+	},
+	{
+		"Добрый вечер! Можно с кошкой?",
+		"Здравствуйте. С кошкой можно, если она приучена к лотку.",
+	},
+	{
+		"Здравствуйте, коммунальные платежи входят в стоимость?",
+		"Нет, оплачиваются отдельно по счётчикам, обычно около 4 000 ₽.",
+		"Понял, спасибо. А залог какой?",
+	},
+	{
+		"Привет! Интересует на длительный срок, от года.",
+		"Отлично, на длительный срок могу немного уступить в цене.",
+		"Буду рад, напишу после просмотра.",
+		"Договорились.",
+		"Спасибо!",
+	},
+}
@@ -0,0 +1,268 @@
+// Package seed fills the database with a demo dataset: tenants, landlords
+// on different plans, listings with images and coordinates in several
+// cities, promotions and conversations. The dataset is a pure function of
+// the options, so demos and integration tests can rely on the same data.
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+// Domain is the e-mail domain of every seeded account. It is how Apply and
+// Reset tell seeded rows from real ones.
+const Domain = "seed.test"
+
+// Password is the password of every seeded account.
+const Password = "password123"
+
+// Well-known accounts for logging into a demo.
+const (
+	AdminEmail    = "admin@" + Domain
+	LandlordEmail = "landlord@" + Domain
+	TenantEmail   = "tenant@" + Domain
+)
+
+type Options struct {
+	Seed      int64
+	Landlords int
+	Tenants   int
+}
+
+func DefaultOptions() Options {
+	return Options{Seed: 1, Landlords: 8, Tenants: 20}
+}
+
+// Dataset is the generated data. Rows reference each other by index
+// because IDs are only known once stored.
+type Dataset struct {
+	Users         []User
+	Conversations []Conversation
+}
+
+type User struct {
+	core.User
+	// Plan is nil for users on the default free plan. ExpiresIn is applied
+	// to paid plans relative to the time of Apply.
+	Plan      *core.UserPlan
+	ExpiresIn time.Duration
+
+	Properties []Property
+}
+
+type Property struct {
+	core.Property
+	Images   []string      // fixture names under images/
+	Promoted time.Duration // promotion time left, zero for none
+}
+
+// Conversation is between Users[Tenant] and Users[Landlord] about
+// Users[Landlord].Properties[Property].
+type Conversation struct {
+	Tenant, Landlord, Property int
+	Messages                   []Message
+}
+
+type Message struct {
+	FromTenant bool
+	Content    string
+	Ago        time.Duration // sent this long before Apply
+}
+
+// Generate builds the dataset for opts. Equal options give equal datasets.
+func Generate(opts Options) Dataset {
+	g := &generator{rnd: rand.New(rand.NewSource(opts.Seed)), used: map[string]bool{}}
+	var ds Dataset
+
+	ds.Users = append(ds.Users, User{User: core.User{Email: AdminEmail, Name: "Администратор", Role: "admin"}})
+
+	var landlords, tenants []int
+	for i := 0; i < opts.Landlords; i++ {
+		u := User{User: g.person("landlord")}
+		if i == 0 {
+			u.Email = LandlordEmail
+		}
+		switch i % 3 {
+		case 1:
+			u.Plan = &core.UserPlan{PlanType: "premium", MaxListings: 10}
+			u.ExpiresIn = time.Duration(5+g.rnd.Intn(25)) * 24 * time.Hour
+		case 2:
+			u.Plan = &core.UserPlan{PlanType: "unlimited", MaxListings: 999999}
+			u.ExpiresIn = time.Duration(5+g.rnd.Intn(25)) * 24 * time.Hour
+		}
+		n := 1 + g.rnd.Intn(3)
+		if u.Plan != nil {
+			n = 3 + g.rnd.Intn(4)
+		}
+		for j := 0; j < n; j++ {
+			p := g.property()
+			p.ContactEmail = u.Email
+			u.Properties = append(u.Properties, p)
+		}
+		landlords = append(landlords, len(ds.Users))
+		ds.Users = append(ds.Users, u)
+	}
+	for i := 0; i < opts.Tenants; i++ {
+		u := User{User: g.person("tenant")}
+		if i == 0 {
+			u.Email = TenantEmail
+		}
+		tenants = append(tenants, len(ds.Users))
+		ds.Users = append(ds.Users, u)
+	}
+
+	// every tenant asks about one or two listings
+	for _, t := range tenants {
+		for k, n := 0, 1+g.rnd.Intn(2); k < n && len(landlords) > 0; k++ {
+			l := landlords[g.rnd.Intn(len(landlords))]
+			conv := Conversation{Tenant: t, Landlord: l, Property: g.rnd.Intn(len(ds.Users[l].Properties))}
+			if conv.duplicateOf(ds.Conversations) {
+				continue
+			}
+			script := chats[g.rnd.Intn(len(chats))]
+			start := time.Duration(1+g.rnd.Intn(72)) * time.Hour
+			for m, line := range script {
+				conv.Messages = append(conv.Messages, Message{
+					FromTenant: m%2 == 0,
+					Content:    line,
+					Ago:        start - time.Duration(m)*7*time.Minute,
+				})
+			}
+			ds.Conversations = append(ds.Conversations, conv)
+		}
+	}
+	return ds
+}
+
+func (c Conversation) duplicateOf(convs []Conversation) bool {
+	for _, o := range convs {
+		if o.Tenant == c.Tenant && o.Landlord == c.Landlord && o.Property == c.Property {
+			return true
+		}
+	}
+	return false
+}
+
+type generator struct {
+	rnd  *rand.Rand
+	used map[string]bool // e-mails handed out
+}
+
+func (g *generator) person(role string) core.User {
+	var first name
+	last := surnames[g.rnd.Intn(len(surnames))]
+	if g.rnd.Intn(2) == 0 {
+		first = maleNames[g.rnd.Intn(len(maleNames))]
+	} else {
+		first = femaleNames[g.rnd.Intn(len(femaleNames))]
+		last = name{last.ru + "а", last.lat + "a"}
+	}
+	local := first.lat + "." + last.lat
+	email := local + "@" + Domain
+	for i := 2; g.used[email]; i++ {
+		email = fmt.Sprintf("%s%d@%s", local, i, Domain)
+	}
+	g.used[email] = true
+	return core.User{Email: email, Name: first.ru + " " + last.ru, Role: role}
+}
+
+// flag is true with odds 1 in n, false otherwise.
+func (g *generator) flag(n int) *bool {
+	v := g.rnd.Intn(n) == 0
+	return &v
+}
+
+func (g *generator) property() Property {
+	c := cities[g.rnd.Intn(len(cities))]
+	typ := propertyTypes[g.rnd.Intn(len(propertyTypes))]
+
+	rooms := 1 + g.rnd.Intn(3)
+	var title string
+	switch typ {
+	case "studio":
+		rooms = 0
+		title = "Студия"
+	case "room":
+		rooms = 1
+		title = fmt.Sprintf("Комната в %d-комнатной квартире", 2+g.rnd.Intn(3))
+	case "house":
+		rooms = 3 + g.rnd.Intn(3)
+		title = fmt.Sprintf("Дом, %d комн.", rooms)
+	default:
+		title = fmt.Sprintf("%d-комнатная квартира", rooms)
+	}
+	title += " " + titleHooks[g.rnd.Intn(len(titleHooks))]
+
+	area := 18 + rooms*18 + g.rnd.Intn(15)
+	price := (12000 + float64(rooms)*9000 + float64(g.rnd.Intn(8))*1000) * c.price
+	priceType := "month"
+	if c.name == "Сочи" && g.rnd.Intn(2) == 0 {
+		priceType = "day"
+		price = price / 12
+	}
+	price = math.Round(price/500) * 500
+
+	var am []string
+	for _, a := range amenities {
+		if g.rnd.Intn(2) == 0 {
+			am = append(am, a)
+		}
+	}
+	visibility := "public"
+	if g.rnd.Intn(10) == 0 {
+		visibility = "registered"
+	}
+	var floor, totalFloors *int
+	if typ != "house" {
+		total := 5 + g.rnd.Intn(20)
+		totalFloors = &total
+		f := 1 + g.rnd.Intn(total)
+		floor = &f
+	}
+	deposit := price
+	if priceType == "day" {
+		deposit = 0
+	}
+
+	p := Property{Property: core.Property{
+		Title:        title,
+		Description:  descriptions[g.rnd.Intn(len(descriptions))],
+		Price:        price,
+		PriceType:    priceType,
+		City:         c.name,
+		Address:      fmt.Sprintf("%s, %s, %d", c.name, c.streets[g.rnd.Intn(len(c.streets))], 1+g.rnd.Intn(120)),
+		Lat:          round6(c.lat + (g.rnd.Float64()-0.5)*0.12),
+		Lng:          round6(c.lng + (g.rnd.Float64()-0.5)*0.2),
+		Rooms:        rooms,
+		Area:         area,
+		Amenities:    am,
+		PropertyType: typ,
+		ContactPhone: fmt.Sprintf("+79%09d", g.rnd.Intn(1e9)),
+		IsUrgent:     g.rnd.Intn(7) == 0,
+		Visibility:   visibility,
+
+		Floor:             floor,
+		TotalFloors:       totalFloors,
+		Furnished:         g.flag(4),
+		PetsAllowed:       g.flag(3),
+		Balcony:           g.flag(2),
+		Parking:           g.flag(2),
+		Deposit:           &deposit,
+		UtilitiesIncluded: g.flag(3),
+	}}
+	for i, n := 0, 1+g.rnd.Intn(3); i < n; i++ {
+		p.Images = append(p.Images, fixtures[g.rnd.Intn(len(fixtures))])
+	}
+	if g.rnd.Intn(4) == 0 {
+		p.Promoted = time.Duration(1+g.rnd.Intn(6*24)) * time.Hour
+	}
+	return p
+}
+
+func round6(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
@@ -0,0 +1,65 @@
+package seed_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/seed"
+	"gofuckbiz/snimayprosto-rent-easy/internal/testdb"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	opts := seed.DefaultOptions()
+	a, b := seed.Generate(opts), seed.Generate(opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same options gave different datasets")
+	}
+	opts.Seed++
+	if reflect.DeepEqual(a, seed.Generate(opts)) {
+		t.Fatal("different seeds gave the same dataset")
+	}
+
+	emails := map[string]bool{}
+	for _, u := range a.Users {
+		if emails[u.Email] {
+			t.Fatalf("duplicate e-mail %s", u.Email)
+		}
+		emails[u.Email] = true
+	}
+	for _, want := range []string{seed.AdminEmail, seed.LandlordEmail, seed.TenantEmail} {
+		if !emails[want] {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestApplyIdempotentAndReset(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	ds := seed.Generate(seed.DefaultOptions())
+
+	sum, err := seed.Apply(ctx, db, ds, dir, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Users != len(ds.Users) || sum.Conversations != len(ds.Conversations) {
+		t.Fatalf("summary %v does not match dataset", sum)
+	}
+	if _, err := seed.Apply(ctx, db, ds, dir, time.Now()); !errors.Is(err, seed.ErrAlreadySeeded) {
+		t.Fatalf("second apply: %v", err)
+	}
+
+	if err := seed.Reset(ctx, db, dir); err != nil {
+		t.Fatal(err)
+	}
+	if seeded, err := seed.Seeded(ctx, db); err != nil || seeded {
+		t.Fatalf("seeded after reset: %v, %v", seeded, err)
+	}
+	if _, err := seed.Apply(ctx, db, ds, dir, time.Now()); err != nil {
+		t.Fatalf("apply after reset: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+// MaxAnalyticsDays is the longest period a report covers.
+const MaxAnalyticsDays = 366
+
+// AnalyticsService counts what happens to listings by day and reports it
+// to their owners.
+type AnalyticsService struct {
+	Analytics  AnalyticsRepository
+	Properties PropertyRepository
+
+	Now func() time.Time
+}
+
+func NewAnalyticsService(analytics AnalyticsRepository, properties PropertyRepository) *AnalyticsService {
+	return &AnalyticsService{Analytics: analytics, Properties: properties, Now: time.Now}
+}
+
+// Viewer is who opened a listing. Signed-in users are told apart by ID,
+// anonymous visitors by a hash of their IP and user agent.
+type Viewer struct {
+	UserID    uint // zero when anonymous
+	IP        string
+	UserAgent string
+}
+
+func (v Viewer) key() string {
+	if v.UserID > 0 {
+		return "u:" + strconv.FormatUint(uint64(v.UserID), 10)
+	}
+	sum := sha256.Sum256([]byte(v.IP + "\n" + v.UserAgent))
+	return "a:" + hex.EncodeToString(sum[:16])
+}
+
+// Day returns the UTC date of t, the bucket events at t go to.
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// View counts a view of p, once per viewer and day. Owners looking at
+// their own listings are not counted. Like AuditService.Record it never
+// fails the caller, and a nil service counts nothing.
+func (s *AnalyticsService) View(ctx context.Context, p *core.Property, v Viewer) {
+	if s == nil || v.UserID == p.OwnerID {
+		return
+	}
+	if err := s.Analytics.AddView(ctx, p.ID, Day(s.Now()), v.key()); err != nil {
+		slog.ErrorContext(ctx, "analytics: view not counted", "property_id", p.ID, "err", err)
+	}
+}
+
+// Count adds one event of metric to the listing's day, the same way View
+// does.
+func (s *AnalyticsService) Count(ctx context.Context, propertyID uint, metric string) {
+	if s == nil {
+		return
+	}
+	if err := s.Analytics.Add(ctx, propertyID, Day(s.Now()), metric); err != nil {
+		slog.ErrorContext(ctx, "analytics: event not counted", "property_id", propertyID, "metric", metric, "err", err)
+	}
+}
+
+// Counters are the events of a listing or an account.
+type Counters struct {
+	Views         int64 `json:"views"`
+	Reveals       int64 `json:"reveals"`
+	Favorites     int64 `json:"favorites"`
+	Conversations int64 `json:"conversations"`
+}
+
+func (c *Counters) add(st core.ListingStat) {
+	c.Views += st.Views
+	c.Reveals += st.Reveals
+	c.Favorites += st.Favorites
+	c.Conversations += st.Conversations
+}
+
+// DayStats is one day of a report.
+type DayStats struct {
+	Date string `json:"date"` // YYYY-MM-DD, UTC
+	Counters
+	Promoted int `json:"promoted"` // listings promoted that day
+}
+
+// Rates are Counters per listing-day.
+type Rates struct {
+	Views         float64 `json:"views"`
+	Reveals       float64 `json:"reveals"`
+	Favorites     float64 `json:"favorites"`
+	Conversations float64 `json:"conversations"`
+}
+
+// Period sums the listing-days of a report that were, or were not,
+// promoted.
+type Period struct {
+	Days   int      `json:"days"` // listing-days
+	Totals Counters `json:"totals"`
+	PerDay Rates    `json:"perDay"`
+}
+
+func (p *Period) add(st core.ListingStat) {
+	p.Days++
+	p.Totals.add(st)
+}
+
+func (p *Period) finish() {
+	if p.Days == 0 {
+		return
+	}
+	d := float64(p.Days)
+	p.PerDay = Rates{
+		Views:         float64(p.Totals.Views) / d,
+		Reveals:       float64(p.Totals.Reveals) / d,
+		Favorites:     float64(p.Totals.Favorites) / d,
+		Conversations: float64(p.Totals.Conversations) / d,
+	}
+}
+
+// Report is the analytics of one listing or of all listings of an owner
+// over From..To. Promoted and Regular compare the listing-days with and
+// without a running promotion; days before a listing was created count
+// in neither.
+type Report struct {
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Listings int        `json:"listings"`
+	Totals   Counters   `json:"totals"`
+	Series   []DayStats `json:"series"` // every day, oldest first
+	Promoted Period     `json:"promoted"`
+	Regular  Period     `json:"regular"`
+}
+
+// Listing reports on one of the owner's listings.
+func (s *AnalyticsService) Listing(ctx context.Context, ownerID, propertyID uint, from, to time.Time) (*Report, error) {
+	p, err := s.Properties.ByID(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if p.OwnerID != ownerID {
+		return nil, ErrNotOwner
+	}
+	return s.report(ctx, []core.Property{*p}, from, to)
+}
+
+// Account reports on all listings of the owner together.
+func (s *AnalyticsService) Account(ctx context.Context, ownerID uint, from, to time.Time) (*Report, error) {
+	props, err := s.Properties.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.report(ctx, props, from, to)
+}
+
+// report checks the period and puts the days of props together.
+func (s *AnalyticsService) report(ctx context.Context, props []core.Property, from, to time.Time) (*Report, error) {
+	from, to = Day(from), Day(to)
+	days := int(to.Sub(from)/(24*time.Hour)) + 1
+	if days < 1 || days > MaxAnalyticsDays {
+		return nil, ErrInvalidPeriod
+	}
+
+	ids := make([]uint, 0, len(props))
+	for _, p := range props {
+		ids = append(ids, p.ID)
+	}
+	stats, err := s.Analytics.Daily(ctx, ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		propertyID uint
+		day        string
+	}
+	byDay := make(map[key]core.ListingStat, len(stats))
+	for _, st := range stats {
+		byDay[key{st.PropertyID, st.Day.Format(time.DateOnly)}] = st
+	}
+
+	r := &Report{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Listings: len(props),
+		Series:   make([]DayStats, days),
+	}
+	for i := range r.Series {
+		r.Series[i].Date = from.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+	for _, p := range props {
+		promos, err := s.Properties.Promotions(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		created := Day(p.CreatedAt)
+		for i := range r.Series {
+			day := from.AddDate(0, 0, i)
+			if day.Before(created) {
+				continue
+			}
+			st := byDay[key{p.ID, r.Series[i].Date}]
+			r.Series[i].add(st)
+			r.Totals.add(st)
+			if promotedOn(promos, day) {
+				r.Series[i].Promoted++
+				r.Promoted.add(st)
+			} else {
+				r.Regular.add(st)
+			}
+		}
+	}
+	r.Promoted.finish()
+	r.Regular.finish()
+	return r, nil
+}
+
+// promotedOn reports whether any of promos ran during day.
+func promotedOn(promos []core.PropertyPromotion, day time.Time) bool {
+	end := day.Add(24 * time.Hour)
+	for _, p := range promos {
+		if p.Tier == core.PromotionTopCity && p.StartsAt.Before(end) && p.ExpiresAt.After(day) {
+			return true
+		}
+	}
+	return false
+}
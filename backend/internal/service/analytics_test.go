@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestListingAnalytics(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := memory.NewStore()
+	store.Now = clock
+	s := service.New(store.Repositories(), config.Defaults())
+	s.Properties.Now, s.Analytics.Now = clock, clock
+
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	tenant, _ := s.Users.Register(ctx, "tenant@example.com", "secret1", "Tenant")
+	p := &core.Property{OwnerID: owner.ID, Title: "flat", ContactPhone: "+79990001122"}
+	if _, err := s.Properties.Create(ctx, p, service.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []service.Viewer{
+		{UserID: tenant.ID}, {UserID: tenant.ID, IP: "10.0.0.1"}, // the same user
+		{IP: "10.0.0.2", UserAgent: "a"}, {IP: "10.0.0.2", UserAgent: "a"}, // the same visitor
+		{UserID: owner.ID}, // not counted
+	} {
+		if _, err := s.Properties.Get(ctx, p.ID, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Properties.RevealContacts(ctx, tenant.ID, p.ID, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.Properties.Favorite(ctx, tenant.ID, p.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Chat.Start(ctx, tenant.ID, p.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, err := s.Properties.Promote(ctx, owner.ID, p.ID, service.PromoteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	s.Properties.Get(ctx, p.ID, service.Viewer{UserID: tenant.ID})
+
+	from := now.AddDate(0, 0, -2)
+	r, err := s.Analytics.Listing(ctx, owner.ID, p.ID, from, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Series) != 3 || r.From != "2026-09-30" || r.To != "2026-10-02" {
+		t.Fatalf("period: %s..%s, %d days", r.From, r.To, len(r.Series))
+	}
+	want := service.Counters{Views: 3, Reveals: 1, Favorites: 1, Conversations: 1}
+	if r.Totals != want {
+		t.Fatalf("totals = %+v, want %+v", r.Totals, want)
+	}
+	if d := r.Series[1]; d.Views != 2 || d.Promoted != 0 || r.Series[2].Views != 1 || r.Series[2].Promoted != 1 {
+		t.Fatalf("series = %+v", r.Series)
+	}
+	// the day before the listing existed is neither promoted nor regular
+	if r.Regular.Days != 1 || r.Regular.PerDay.Views != 2 || r.Promoted.Days != 1 || r.Promoted.PerDay.Views != 1 {
+		t.Fatalf("promoted %+v, regular %+v", r.Promoted, r.Regular)
+	}
+
+	acc, err := s.Analytics.Account(ctx, owner.ID, from, now)
+	if err != nil || acc.Listings != 1 || acc.Totals != want {
+		t.Fatalf("account: %+v, %v", acc, err)
+	}
+	if _, err := s.Analytics.Listing(ctx, tenant.ID, p.ID, from, now); !errors.Is(err, service.ErrNotOwner) {
+		t.Fatalf("someone else's listing: %v", err)
+	}
+	if _, err := s.Analytics.Account(ctx, owner.ID, now.AddDate(-2, 0, 0), now); !errors.Is(err, service.ErrInvalidPeriod) {
+		t.Fatalf("two years: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/logging"
+)
+
+// AuditService writes and reads the security audit log.
+type AuditService struct {
+	Audit AuditRepository
+}
+
+func NewAuditService(audit AuditRepository) *AuditService {
+	return &AuditService{Audit: audit}
+}
+
+// Record stores e with the IP, user agent and request ID of the request
+// in ctx. It never fails the caller: if the event cannot be stored it goes
+// to the error log instead. A nil service records nothing.
+func (s *AuditService) Record(ctx context.Context, e core.AuditEvent) {
+	if s == nil {
+		return
+	}
+	r := logging.FromContext(ctx)
+	e.IP, e.UserAgent, e.RequestID = r.IP, r.UserAgent, r.ID
+	if err := s.Audit.Create(ctx, &e); err != nil {
+		slog.ErrorContext(ctx, "audit: event not stored", "action", e.Action, "actor_id", e.ActorID,
+			"target_type", e.TargetType, "target_id", e.TargetID, "details", e.Details, "err", err)
+	}
+}
+
+// List returns audit events, newest first: 50 by default, at most 200.
+func (s *AuditService) List(ctx context.Context, f AuditFilter) ([]core.AuditEvent, error) {
+	switch {
+	case f.Limit <= 0:
+		f.Limit = 50
+	case f.Limit > 200:
+		f.Limit = 200
+	}
+	return s.Audit.List(ctx, f)
+}
+
+// ref returns a pointer for the optional ID fields of an event.
+func ref(id uint) *uint {
+	return &id
+}
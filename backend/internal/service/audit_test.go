@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/logging"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestAuditTrail(t *testing.T) {
+	ctx := logging.WithRequest(context.Background(), logging.Request{ID: "req-1", IP: "203.0.113.7", UserAgent: "test"})
+	s := newServices(t)
+	owner, err := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Users.Authenticate(ctx, "owner@example.com", "wrong"); err == nil {
+		t.Fatal("wrong password accepted")
+	}
+	if _, err := s.Users.Authenticate(ctx, "nobody@example.com", "secret1"); err == nil {
+		t.Fatal("unknown e-mail accepted")
+	}
+	if _, err := s.Users.Authenticate(ctx, "owner@example.com", "secret1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Users.UpdateRole(ctx, owner.ID, "landlord"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Plans.Upgrade(ctx, owner.ID, "premium"); err != nil {
+		t.Fatal(err)
+	}
+	l, err := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID, Title: "flat"}, service.CreateOptions{Promote: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Properties.Delete(ctx, owner.ID+1, l.ID); err == nil {
+		t.Fatal("deleted someone else's listing")
+	}
+	if _, err := s.Properties.Delete(ctx, owner.ID, l.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Properties.Get(ctx, l.ID, service.Viewer{}); err == nil {
+		t.Fatal("listing still there after delete")
+	}
+
+	events, err := s.Audit.List(ctx, service.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		action string
+		actor  bool
+		detail string
+	}{
+		{core.AuditListingDelete, true, "flat"},
+		{core.AuditPlanChange, true, "free>premium"},
+		{core.AuditRoleChange, true, "user>landlord"},
+		{core.AuditLoginSuccess, true, ""},
+		{core.AuditLoginFailure, false, "unknown_email"},
+		{core.AuditLoginFailure, false, "wrong_password"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		got := e.Details["title"] + e.Details["reason"]
+		if e.Details["from"] != "" {
+			got = e.Details["from"] + ">" + e.Details["to"]
+		}
+		if e.Action != w.action || (e.ActorID != nil) != w.actor || got != w.detail {
+			t.Errorf("event %d = %s actor=%v details=%v, want %s %q", i, e.Action, e.ActorID, e.Details, w.action, w.detail)
+		}
+		if e.RequestID != "req-1" || e.IP != "203.0.113.7" || e.UserAgent != "test" {
+			t.Errorf("event %d request metadata = %q %q %q", i, e.RequestID, e.IP, e.UserAgent)
+		}
+	}
+
+	failures, err := s.Audit.List(ctx, service.AuditFilter{Action: core.AuditLoginFailure, Limit: 1})
+	if err != nil || len(failures) != 1 || failures[0].Details["reason"] != "unknown_email" {
+		t.Fatalf("filtered list = %+v, %v", failures, err)
+	}
+	mine, err := s.Audit.List(ctx, service.AuditFilter{ActorID: owner.ID, Before: events[1].ID})
+	if err != nil || len(mine) != 2 {
+		t.Fatalf("actor page = %+v, %v", mine, err)
+	}
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/chatfilter"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+type ChatService struct {
+	Chat       ChatRepository
+	Properties PropertyRepository
+	Users      UserRepository
+
+	// Filter runs on every message before it is stored
+	Filter *chatfilter.Pipeline
+	// EditWindow is how long a sender may edit or delete a message
+	EditWindow time.Duration
+	Analytics  *AnalyticsService // optional
+
+	Now func() time.Time
+}
+
+func NewChatService(chat ChatRepository, properties PropertyRepository, users UserRepository, filter *chatfilter.Pipeline, editWindow time.Duration) *ChatService {
+	return &ChatService{
+		Chat:       chat,
+		Properties: properties,
+		Users:      users,
+		Filter:     filter,
+		EditWindow: editWindow,
+		Now:        time.Now,
+	}
+}
+
+// Start returns the conversation between the user and the property owner,
+// creating it on first contact.
+func (s *ChatService) Start(ctx context.Context, userID, propertyID uint) (*core.Conversation, error) {
+	p, err := s.Properties.ByID(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := s.eitherBlocked(ctx, userID, p.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrBlocked
+	}
+
+	conv, err := s.Chat.FindConversation(ctx, p.ID, userID, p.OwnerID)
+	if err == nil {
+		return conv, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	conv = &core.Conversation{
+		PropertyID:  &p.ID,
+		InitiatorID: userID,
+		RecipientID: p.OwnerID,
+	}
+	if err := s.Chat.CreateConversation(ctx, conv); err != nil {
+		return nil, err
+	}
+	s.Analytics.Count(ctx, p.ID, core.MetricConversations)
+	return conv, nil
+}
+
+// Participant loads the conversation and checks the user is one of its
+// two sides.
+func (s *ChatService) Participant(ctx context.Context, convID, userID uint) (*core.Conversation, error) {
+	conv, err := s.Chat.ConversationByID(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if conv.InitiatorID != userID && conv.RecipientID != userID {
+		return nil, ErrNotParticipant
+	}
+	return conv, nil
+}
+
+// History returns a page of messages older than before (0 for the latest
+// page) in chronological order, already redacted.
+func (s *ChatService) History(ctx context.Context, userID, convID, before uint, limit int) ([]core.Message, bool, error) {
+	if _, err := s.Participant(ctx, convID, userID); err != nil {
+		return nil, false, err
+	}
+	msgs, err := s.Chat.MessagesBefore(ctx, convID, before, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+	}
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	redact(msgs)
+	return msgs, hasMore, nil
+}
+
+// Changes returns messages created, edited or deleted after since. The
+// returned time is where the client should resume from.
+func (s *ChatService) Changes(ctx context.Context, userID, convID uint, since time.Time, limit int) ([]core.Message, bool, time.Time, error) {
+	if _, err := s.Participant(ctx, convID, userID); err != nil {
+		return nil, false, time.Time{}, err
+	}
+	until := s.Now()
+	msgs, err := s.Chat.MessagesChanged(ctx, convID, since, until, limit+1)
+	if err != nil {
+		return nil, false, time.Time{}, err
+	}
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+		// let the client continue from the last change it received
+		until = msgs[len(msgs)-1].UpdatedAt
+	}
+	redact(msgs)
+	return msgs, hasMore, until, nil
+}
+
+func redact(msgs []core.Message) {
+	for i := range msgs {
+		msgs[i] = msgs[i].Redacted()
+	}
+}
+
+// ownMessage loads a message the user sent and may still change.
+func (s *ChatService) ownMessage(ctx context.Context, userID, convID, msgID uint) (*core.Message, error) {
+	msg, err := s.Chat.MessageByID(ctx, convID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	if msg.SenderID != userID {
+		return nil, ErrNotSender
+	}
+	if msg.DeletedAt != nil {
+		return nil, ErrMessageDeleted
+	}
+	if s.Now().Sub(msg.CreatedAt) > s.EditWindow {
+		return nil, ErrEditWindowExpired
+	}
+	return msg, nil
+}
+
+// Edit runs the new text through the filter pipeline like Send. A held or
+// rejected edit leaves the message as it was; held ones replace it once a
+// moderator releases them.
+func (s *ChatService) Edit(ctx context.Context, userID, convID, msgID uint, content string) (SendResult, error) {
+	msg, err := s.ownMessage(ctx, userID, convID, msgID)
+	if err != nil {
+		return SendResult{}, err
+	}
+	now := s.Now()
+	res := s.Filter.Run(chatfilter.Message{
+		SenderID:       userID,
+		ConversationID: convID,
+		Content:        content,
+		SentAt:         now,
+	})
+	if res.Action == chatfilter.Hold || res.Action == chatfilter.Reject {
+		err := s.flag(ctx, convID, userID, content, res, &msg.ID)
+		return SendResult{Verdict: res}, err
+	}
+
+	msg.Content = res.Content
+	msg.EditedAt = &now
+	if err := s.Chat.UpdateMessage(ctx, msg); err != nil {
+		return SendResult{}, err
+	}
+	return SendResult{Message: msg, Verdict: res}, nil
+}
+
+// Delete soft-deletes the message; the row stays for moderation.
+func (s *ChatService) Delete(ctx context.Context, userID, convID, msgID uint) (*core.Message, error) {
+	msg, err := s.ownMessage(ctx, userID, convID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.Now()
+	msg.DeletedAt = &now
+	if err := s.Chat.UpdateMessage(ctx, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// SendResult says what happened to a message sent over the socket.
+type SendResult struct {
+	Message *core.Message // stored message, nil unless delivered
+	Verdict chatfilter.Result
+	Blocked bool // the peer blocked the sender
+}
+
+// Send checks blocks and the filter pipeline, then stores the message.
+// Held and rejected messages are recorded for moderators instead.
+func (s *ChatService) Send(ctx context.Context, conv *core.Conversation, senderID uint, content string) (SendResult, error) {
+	blocked, err := s.Chat.HasBlocked(ctx, conv.Peer(senderID), senderID)
+	if err != nil {
+		return SendResult{}, err
+	}
+	if blocked {
+		return SendResult{Blocked: true}, nil
+	}
+
+	res := s.Filter.Run(chatfilter.Message{
+		SenderID:       senderID,
+		ConversationID: conv.ID,
+		Content:        content,
+		SentAt:         s.Now(),
+	})
+	if res.Action == chatfilter.Hold || res.Action == chatfilter.Reject {
+		err := s.flag(ctx, conv.ID, senderID, content, res, nil)
+		return SendResult{Verdict: res}, err
+	}
+
+	msg := &core.Message{
+		ConversationID: conv.ID,
+		SenderID:       senderID,
+		Type:           "text",
+		Content:        res.Content,
+	}
+	if err := s.Chat.CreateMessage(ctx, msg); err != nil {
+		return SendResult{}, err
+	}
+	return SendResult{Message: msg, Verdict: res}, nil
+}
+
+// flag records a held or rejected message for moderators. editOf is the
+// message a held edit would change, nil for new messages.
+func (s *ChatService) flag(ctx context.Context, convID, senderID uint, content string, res chatfilter.Result, editOf *uint) error {
+	status := "pending"
+	if res.Action == chatfilter.Reject {
+		status = "rejected"
+	}
+	return s.Chat.CreateFlagged(ctx, &core.FlaggedMessage{
+		ConversationID: convID,
+		SenderID:       senderID,
+		Content:        content,
+		Action:         res.Action.String(),
+		Detectors:      res.Detectors(),
+		Reason:         res.Reason(),
+		Status:         status,
+		EditOf:         editOf,
+	})
+}
+
+// InboxItem is a conversation as shown in the landlord's inbox.
+type InboxItem struct {
+	ID            uint         `json:"id"`
+	PropertyID    uint         `json:"propertyId"`
+	PropertyTitle string       `json:"propertyTitle"`
+	PropertyPrice float64      `json:"propertyPrice"`
+	InitiatorID   uint         `json:"initiatorId"`
+	OwnerID       uint         `json:"ownerId"`
+	InitiatorName string       `json:"initiatorName"`
+	LastMessage   core.Message `json:"lastMessage"`
+	UnreadCount   int64        `json:"unreadCount"`
+}
+
+func (s *ChatService) Inbox(ctx context.Context, userID uint) ([]InboxItem, error) {
+	convs, err := s.Chat.Inbox(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := []InboxItem{}
+	for _, conv := range convs {
+		// Skip conversations without property
+		if conv.PropertyID == nil {
+			continue
+		}
+		p, err := s.Properties.ByID(ctx, *conv.PropertyID)
+		if err != nil {
+			continue // Skip if property not found
+		}
+		initiator, err := s.Users.ByID(ctx, conv.InitiatorID)
+		if err != nil {
+			continue // Skip if initiator not found
+		}
+		item := InboxItem{
+			ID:            conv.ID,
+			PropertyID:    *conv.PropertyID,
+			PropertyTitle: p.Title,
+			PropertyPrice: p.Price,
+			InitiatorID:   conv.InitiatorID,
+			OwnerID:       conv.RecipientID,
+			InitiatorName: initiator.Name,
+		}
+		if last, err := s.Chat.LastMessage(ctx, conv.ID); err == nil {
+			item.LastMessage = last.Redacted()
+		}
+		if item.UnreadCount, err = s.Chat.CountUnread(ctx, conv.ID, userID); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func (s *ChatService) CountConversations(ctx context.Context) (int64, error) {
+	return s.Chat.CountConversations(ctx)
+}
+
+func (s *ChatService) eitherBlocked(ctx context.Context, a, b uint) (bool, error) {
+	if blocked, err := s.Chat.HasBlocked(ctx, a, b); err != nil || blocked {
+		return blocked, err
+	}
+	return s.Chat.HasBlocked(ctx, b, a)
+}
+
+func (s *ChatService) Block(ctx context.Context, blockerID, blockedID uint) error {
+	if blockerID == blockedID {
+		return ErrCannotBlockSelf
+	}
+	if _, err := s.Users.ByID(ctx, blockedID); err != nil {
+		return err
+	}
+	err := s.Chat.Block(ctx, &core.UserBlock{BlockerID: blockerID, BlockedID: blockedID})
+	if errors.Is(err, ErrConflict) {
+		return nil // already blocked
+	}
+	return err
+}
+
+func (s *ChatService) Unblock(ctx context.Context, blockerID, blockedID uint) error {
+	return s.Chat.Unblock(ctx, blockerID, blockedID)
+}
+
+func (s *ChatService) Blocked(ctx context.Context, blockerID uint) ([]BlockedUser, error) {
+	return s.Chat.ListBlocked(ctx, blockerID)
+}
+
+// Flagged lists filtered messages; status "all" returns every status.
+func (s *ChatService) Flagged(ctx context.Context, status string, before uint, limit int) ([]core.FlaggedMessage, error) {
+	if status == "all" {
+		status = ""
+	}
+	return s.Chat.ListFlagged(ctx, status, before, limit)
+}
+
+func (s *ChatService) pendingFlagged(ctx context.Context, id uint) (*core.FlaggedMessage, error) {
+	f, err := s.Chat.FlaggedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if f.Status != "pending" {
+		return nil, ErrAlreadyReviewed
+	}
+	return f, nil
+}
+
+// Release delivers a held message as the sender wrote it, or applies a held
+// edit. It returns the stored message and the conversation it belongs to.
+func (s *ChatService) Release(ctx context.Context, reviewerID, flaggedID uint) (*core.Message, *core.Conversation, error) {
+	f, err := s.pendingFlagged(ctx, flaggedID)
+	if err != nil {
+		return nil, nil, err
+	}
+	conv, err := s.Chat.ConversationByID(ctx, f.ConversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.Now()
+	f.Status = "released"
+	f.ReviewerID = &reviewerID
+	f.ReviewedAt = &now
+	msg := &core.Message{
+		ConversationID: f.ConversationID,
+		SenderID:       f.SenderID,
+		Type:           "text",
+		Content:        f.Content,
+	}
+	if f.EditOf != nil {
+		if msg, err = s.Chat.MessageByID(ctx, f.ConversationID, *f.EditOf); err != nil {
+			return nil, nil, err
+		}
+		if msg.DeletedAt != nil {
+			return nil, nil, ErrMessageDeleted
+		}
+		msg.Content = f.Content
+		msg.EditedAt = &now
+	}
+	if err := s.Chat.ReleaseFlagged(ctx, f, msg); err != nil {
+		return nil, nil, err
+	}
+	return msg, conv, nil
+}
+
+// Dismiss drops a held message without delivering it.
+func (s *ChatService) Dismiss(ctx context.Context, reviewerID, flaggedID uint) error {
+	f, err := s.pendingFlagged(ctx, flaggedID)
+	if err != nil {
+		return err
+	}
+	now := s.Now()
+	f.Status = "dismissed"
+	f.ReviewerID = &reviewerID
+	f.ReviewedAt = &now
+	return s.Chat.ReviewFlagged(ctx, f)
+}
+
+// UserName returns the display name of a user, or "" if unknown.
+func (s *ChatService) UserName(ctx context.Context, id uint) string {
+	u, err := s.Users.ByID(ctx, id)
+	if err != nil {
+		return ""
+	}
+	return u.Name
+}
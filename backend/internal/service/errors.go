@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+
+	ErrEmailTaken         = errors.New("email taken")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidPlan        = errors.New("invalid plan type")
+	ErrNotOwner           = errors.New("not the owner")
+	ErrAlreadyPromoted    = errors.New("already promoted")
+	ErrNotParticipant     = errors.New("not a participant")
+	ErrNotSender          = errors.New("not the sender")
+	ErrMessageDeleted     = errors.New("message deleted")
+	ErrEditWindowExpired  = errors.New("edit window expired")
+	ErrBlocked            = errors.New("user blocked")
+	ErrCannotBlockSelf    = errors.New("cannot block self")
+	ErrAlreadyReviewed    = errors.New("already reviewed")
+	ErrContactsHidden     = errors.New("contacts hidden")
+	ErrInvalidPeriod      = errors.New("invalid period")
+	ErrInvalidPromotion   = errors.New("unknown promotion product")
+)
+
+// ListingLimitError is returned when the owner's plan allows no more
+// listings.
+type ListingLimitError struct {
+	PlanType       string
+	MaxListings    int
+	ActiveListings int64
+}
+
+func (e *ListingLimitError) Error() string {
+	return fmt.Sprintf("listing limit exceeded: %d of %d on %s plan", e.ActiveListings, e.MaxListings, e.PlanType)
+}
+
+// PromotionLimitError is returned when the promotion costs more credits
+// than the owner's plan has left this month.
+type PromotionLimitError struct {
+	PlanType string
+	Credits  int // included per month
+	Used     int
+	Cost     int
+}
+
+func (e *PromotionLimitError) Error() string {
+	return fmt.Sprintf("promotion credits exceeded: %d used of %d on %s plan, %d needed", e.Used, e.Credits, e.PlanType, e.Cost)
+}
+
+// UnknownAmenityError is returned when a listing names an amenity that is
+// not in the catalog. Index is its position in Property.Amenities.
+type UnknownAmenityError struct {
+	Index int
+	Code  string
+}
+
+func (e *UnknownAmenityError) Error() string {
+	return fmt.Sprintf("unknown amenity %q", e.Code)
+}
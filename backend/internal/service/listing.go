@@ -0,0 +1,76 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Cities are the cities listings are searched by. A listing's city is
+// taken from this list when its address names one of them.
+var Cities = []string{
+	"Москва", "Санкт-Петербург", "Новосибирск", "Екатеринбург", "Казань",
+	"Нижний Новгород", "Челябинск", "Самара", "Омск", "Ростов-на-Дону",
+	"Уфа", "Красноярск", "Воронеж", "Пермь", "Волгоград", "Краснодар",
+	"Саратов", "Тюмень", "Калининград", "Сочи",
+}
+
+// CanonicalCity returns name as spelled in Cities, or trimmed if it is not
+// there.
+func CanonicalCity(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	for _, c := range Cities {
+		if strings.EqualFold(c, name) {
+			return c
+		}
+	}
+	return name
+}
+
+// CityFromAddress finds a city of Cities among the comma-separated parts of
+// an address, as in "Москва, ул. Тверская, 12" or the geocoder's
+// "12, Тверская улица, ..., Москва, 125009, Россия". It returns "" when no
+// part names one.
+func CityFromAddress(address string) string {
+	for _, part := range strings.Split(address, ",") {
+		part = strings.TrimSpace(part)
+		for _, prefix := range []string{"город ", "г. ", "г."} {
+			part = strings.TrimPrefix(part, prefix)
+		}
+		for _, c := range Cities {
+			if strings.EqualFold(c, strings.TrimSpace(part)) {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// NormalizePhone writes a phone number in E.164. Russian numbers may come
+// the local way, "8 (999) 123-45-67" or "999 123 45 67". A number it
+// cannot read is returned trimmed, for the e164 rule to reject.
+func NormalizePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+	var digits strings.Builder
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case unicode.IsSpace(r) || strings.ContainsRune("-().", r):
+		default:
+			return phone
+		}
+	}
+	d := digits.String()
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		return "+" + d
+	case strings.HasPrefix(d, "00"):
+		return "+" + d[2:]
+	case len(d) == 11 && (d[0] == '8' || d[0] == '7'):
+		return "+7" + d[1:]
+	case len(d) == 10 && d[0] == '9':
+		return "+7" + d
+	}
+	return phone
+}
@@ -0,0 +1,46 @@
+package service_test
+
+import (
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	for in, want := range map[string]string{
+		"+7 (999) 123-45-67": "+79991234567",
+		"8 999 123 45 67":    "+79991234567",
+		"79991234567":        "+79991234567",
+		"999-123-45-67":      "+79991234567",
+		"0049 30 1234567":    "+49301234567",
+		" +44 20 7946 0958 ": "+442079460958",
+		"123":                "123",
+		"call me":            "call me",
+		"+7 999 12x":         "+7 999 12x",
+	} {
+		if got := service.NormalizePhone(in); got != want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCityFromAddress(t *testing.T) {
+	for in, want := range map[string]string{
+		"Москва, ул. Тверская, 12": "Москва",
+		"12, Тверская улица, Тверской район, Москва, Центральный федеральный округ, 125009, Россия": "Москва",
+		"г. Санкт-Петербург, Невский пр., 1": "Санкт-Петербург",
+		"казань, ул. Баумана":                "Казань",
+		"Подольск, ул. Ленина, 1":            "",
+		"": "",
+	} {
+		if got := service.CityFromAddress(in); got != want {
+			t.Errorf("CityFromAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := service.CanonicalCity("  санкт-петербург "); got != "Санкт-Петербург" {
+		t.Errorf("CanonicalCity: %q", got)
+	}
+	if got := service.CanonicalCity(" Подольск "); got != "Подольск" {
+		t.Errorf("CanonicalCity of an unknown city: %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+// NotificationService serves the in-app notifications of a user and their
+// delivery settings. notify.Center emits the notifications.
+type NotificationService struct {
+	Notifications NotificationRepository
+
+	Now func() time.Time
+}
+
+func NewNotificationService(notifications NotificationRepository) *NotificationService {
+	return &NotificationService{Notifications: notifications, Now: time.Now}
+}
+
+// List returns a page of f and whether more follow.
+func (s *NotificationService) List(ctx context.Context, f NotificationFilter) ([]core.Notification, bool, error) {
+	limit := f.Limit
+	f.Limit++
+	items, err := s.Notifications.List(ctx, f)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	return items, hasMore, nil
+}
+
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uint) (int64, error) {
+	return s.Notifications.CountUnread(ctx, userID)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id uint) error {
+	return s.Notifications.MarkRead(ctx, userID, id, s.Now())
+}
+
+// MarkAllRead returns how many notifications were unread.
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	return s.Notifications.MarkAllRead(ctx, userID, s.Now())
+}
+
+func (s *NotificationService) Preferences(ctx context.Context, userID uint) (*core.NotificationPreference, error) {
+	return s.Notifications.Preferences(ctx, userID)
+}
+
+// UpdatePreferences changes the channels that are not nil.
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uint, email, push *bool) (*core.NotificationPreference, error) {
+	prefs, err := s.Notifications.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if email != nil {
+		prefs.EmailEnabled = *email
+	}
+	if push != nil {
+		prefs.PushEnabled = *push
+	}
+	if err := s.Notifications.SavePreferences(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (s *NotificationService) SubscribePush(ctx context.Context, sub *core.PushSubscription) error {
+	return s.Notifications.SavePushSubscription(ctx, sub)
+}
+
+func (s *NotificationService) UnsubscribePush(ctx context.Context, userID uint, endpoint string) error {
+	return s.Notifications.DeletePushSubscription(ctx, userID, endpoint)
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories()
+	s := service.NewNotificationService(repos.Notifications)
+
+	key := "plan:1"
+	for i := 0; i < 3; i++ {
+		n := core.Notification{UserID: 1, Type: core.NotificationPlanExpiring, DedupKey: &key}
+		if i > 0 {
+			n.DedupKey = nil
+		}
+		if created, err := repos.Notifications.Create(ctx, &n); err != nil || !created {
+			t.Fatalf("create %d: %v", i, err)
+		}
+	}
+	if created, _ := repos.Notifications.Create(ctx, &core.Notification{UserID: 1, DedupKey: &key}); created {
+		t.Fatal("duplicate dedup key stored")
+	}
+	if created, _ := repos.Notifications.Create(ctx, &core.Notification{UserID: 2, DedupKey: &key}); !created {
+		t.Fatal("dedup key shared between users")
+	}
+
+	page, hasMore, err := s.List(ctx, service.NotificationFilter{UserID: 1, Limit: 2})
+	if err != nil || len(page) != 2 || !hasMore || page[0].ID < page[1].ID {
+		t.Fatalf("first page = %+v, %v, %v", page, hasMore, err)
+	}
+	rest, hasMore, _ := s.List(ctx, service.NotificationFilter{UserID: 1, Limit: 2, Before: page[1].ID})
+	if len(rest) != 1 || hasMore {
+		t.Fatalf("second page = %+v, %v", rest, hasMore)
+	}
+
+	if err := s.MarkRead(ctx, 2, page[0].ID); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("marked someone else's notification: %v", err)
+	}
+	if err := s.MarkRead(ctx, 1, page[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := s.UnreadCount(ctx, 1); n != 2 {
+		t.Fatalf("unread = %d", n)
+	}
+	if unread, _, _ := s.List(ctx, service.NotificationFilter{UserID: 1, UnreadOnly: true, Limit: 10}); len(unread) != 2 {
+		t.Fatalf("unread list = %+v", unread)
+	}
+	if n, _ := s.MarkAllRead(ctx, 1); n != 2 {
+		t.Fatalf("marked %d", n)
+	}
+
+	prefs, err := s.Preferences(ctx, 1)
+	if err != nil || !prefs.EmailEnabled || !prefs.PushEnabled {
+		t.Fatalf("defaults = %+v, %v", prefs, err)
+	}
+	off := false
+	if _, err := s.UpdatePreferences(ctx, 1, &off, nil); err != nil {
+		t.Fatal(err)
+	}
+	if prefs, _ = s.Preferences(ctx, 1); prefs.EmailEnabled || !prefs.PushEnabled {
+		t.Fatalf("after update = %+v", prefs)
+	}
+
+	sub := core.PushSubscription{UserID: 1, Endpoint: "https://push.example.com/a", P256dh: "k", Auth: "a"}
+	if err := s.SubscribePush(ctx, &sub); err != nil {
+		t.Fatal(err)
+	}
+	// the browser signs in as another user
+	if err := s.SubscribePush(ctx, &core.PushSubscription{UserID: 2, Endpoint: sub.Endpoint, P256dh: "k2", Auth: "a2"}); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := repos.Notifications.PushSubscriptions(ctx, 1); len(subs) != 0 {
+		t.Fatalf("old owner keeps %+v", subs)
+	}
+	if err := s.UnsubscribePush(ctx, 2, sub.Endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := repos.Notifications.PushSubscriptions(ctx, 2); len(subs) != 0 {
+		t.Fatalf("still subscribed: %+v", subs)
+	}
+}
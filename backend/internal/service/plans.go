@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
+)
+
+// PlanLimits describes what a plan type allows.
+type PlanLimits struct {
+	MaxListings int
+	Months      int // billing period, zero means the plan never expires
+	// PromotionCredits are included per calendar month and pay for
+	// PromotionProducts.
+	PromotionCredits int
+}
+
+// Plans is the plan catalog. "free" is what every user starts on.
+var Plans = map[string]PlanLimits{
+	"free":      {MaxListings: 3, PromotionCredits: 6}, // one week at the top
+	"premium":   {MaxListings: 10, Months: 1, PromotionCredits: 30},
+	"unlimited": {MaxListings: 999999, Months: 1, PromotionCredits: 100}, // effectively unlimited listings
+}
+
+type PlanService struct {
+	Plans      PlanRepository
+	Properties PropertyRepository
+	Tx         Transactor
+	Audit      *AuditService // optional
+
+	Now func() time.Time
+}
+
+func NewPlanService(plans PlanRepository, properties PropertyRepository, tx Transactor) *PlanService {
+	return &PlanService{Plans: plans, Properties: properties, Tx: tx, Now: time.Now}
+}
+
+// freePlan is the plan every user starts on.
+func freePlan(userID uint) *core.UserPlan {
+	return &core.UserPlan{
+		UserID:      userID,
+		PlanType:    "free",
+		MaxListings: Plans["free"].MaxListings,
+	}
+}
+
+// effectivePlan returns the plan in force at now: a paid plan past its
+// ExpiresAt counts as free until the user upgrades again.
+func effectivePlan(p *core.UserPlan, now time.Time) *core.UserPlan {
+	if p.ExpiresAt == nil || now.Before(*p.ExpiresAt) {
+		return p
+	}
+	free := *p
+	free.PlanType = "free"
+	free.MaxListings = Plans["free"].MaxListings
+	free.ExpiresAt = nil
+	return &free
+}
+
+// Current returns the user's plan, creating the free plan on first use.
+func (s *PlanService) Current(ctx context.Context, userID uint) (*core.UserPlan, error) {
+	return s.Plans.FirstOrCreate(ctx, freePlan(userID))
+}
+
+// Usage is a plan with the listings and this month's promotion credits
+// counted against it.
+type Usage struct {
+	Plan                 *core.UserPlan
+	ActiveListings       int64
+	PromotionCreditsUsed int
+}
+
+// PromotionCredits returns the credits the plan includes per month.
+func (u Usage) PromotionCredits() int {
+	return Plans[u.Plan.PlanType].PromotionCredits
+}
+
+func (u Usage) CanCreateMore() bool {
+	return u.ActiveListings < int64(u.Plan.MaxListings)
+}
+
+func (s *PlanService) Usage(ctx context.Context, userID uint) (Usage, error) {
+	p, err := s.Current(ctx, userID)
+	if err != nil {
+		return Usage{}, err
+	}
+	n, err := s.Properties.CountByOwner(ctx, userID)
+	if err != nil {
+		return Usage{}, err
+	}
+	used, err := s.Properties.PromotionCredits(ctx, userID, creditPeriod(s.Now()))
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{Plan: effectivePlan(p, s.Now()), ActiveListings: n, PromotionCreditsUsed: used}, nil
+}
+
+// Upgrade switches the user to a paid plan for one period.
+func (s *PlanService) Upgrade(ctx context.Context, userID uint, planType string) (*core.UserPlan, error) {
+	limits, ok := Plans[planType]
+	if !ok || planType == "free" {
+		return nil, ErrInvalidPlan
+	}
+	var p *core.UserPlan
+	var from string
+	err := s.Tx.WithinTx(ctx, func(repos Repositories) error {
+		var err error
+		if p, err = repos.Plans.FirstOrCreate(ctx, freePlan(userID)); err != nil {
+			return err
+		}
+		from = p.PlanType
+		p.PlanType = planType
+		p.MaxListings = limits.MaxListings
+		p.ExpiresAt = nil
+		if limits.Months > 0 {
+			expires := s.Now().AddDate(0, limits.Months, 0)
+			p.ExpiresAt = &expires
+		}
+		return repos.Plans.Save(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	telemetry.PlanUpgrades.WithLabelValues(planType).Inc()
+	details := core.AuditDetails{"from": from, "to": planType}
+	if p.ExpiresAt != nil {
+		details["expiresAt"] = p.ExpiresAt.Format(time.RFC3339)
+	}
+	s.Audit.Record(ctx, core.AuditEvent{
+		ActorID:    ref(userID),
+		Action:     core.AuditPlanChange,
+		TargetType: "plan",
+		TargetID:   ref(p.ID),
+		Details:    details,
+	})
+	return p, nil
+}
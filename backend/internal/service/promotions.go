@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
+)
+
+// PromotionProduct is a promotion a landlord can buy for a listing with
+// the promotion credits of their plan.
+type PromotionProduct struct {
+	Code    string
+	Tier    string
+	Days    int
+	Credits int
+	LabelRU string
+	LabelEN string
+}
+
+func (p PromotionProduct) Label(lang string) string {
+	if lang == "en" {
+		return p.LabelEN
+	}
+	return p.LabelRU
+}
+
+// PromotionProducts is the promotion catalog.
+var PromotionProducts = []PromotionProduct{
+	{Code: "top_city_3d", Tier: core.PromotionTopCity, Days: 3, Credits: 3, LabelRU: "Топ города на 3 дня", LabelEN: "Top of the city for 3 days"},
+	{Code: "top_city_7d", Tier: core.PromotionTopCity, Days: 7, Credits: 6, LabelRU: "Топ города на 7 дней", LabelEN: "Top of the city for 7 days"},
+	{Code: "top_city_30d", Tier: core.PromotionTopCity, Days: 30, Credits: 20, LabelRU: "Топ города на 30 дней", LabelEN: "Top of the city for 30 days"},
+	{Code: "urgent_7d", Tier: core.PromotionUrgent, Days: 7, Credits: 2, LabelRU: "Срочно: выделение на 7 дней", LabelEN: "Urgent highlight for 7 days"},
+	{Code: "urgent_30d", Tier: core.PromotionUrgent, Days: 30, Credits: 6, LabelRU: "Срочно: выделение на 30 дней", LabelEN: "Urgent highlight for 30 days"},
+}
+
+// DefaultPromotion is bought when the request names no product.
+const DefaultPromotion = "top_city_7d"
+
+// MaxScheduleAhead is how far ahead a promotion may be scheduled.
+const MaxScheduleAhead = 90 * 24 * time.Hour
+
+func PromotionProductByCode(code string) (PromotionProduct, bool) {
+	for _, p := range PromotionProducts {
+		if p.Code == code {
+			return p, true
+		}
+	}
+	return PromotionProduct{}, false
+}
+
+// creditPeriod returns the start of the calendar month (UTC) of now. Plan
+// credits are counted per calendar month.
+func creditPeriod(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// PromoteOptions choose what to buy.
+type PromoteOptions struct {
+	Product string // DefaultPromotion when empty
+	// StartsAt schedules the promotion. When nil it starts now, or extends
+	// a running promotion of the same tier.
+	StartsAt *time.Time
+}
+
+// Promote buys a promotion for an owned listing with the credits of the
+// owner's plan. The plan row is locked while the credits are counted, like
+// in Create.
+func (s *PropertyService) Promote(ctx context.Context, ownerID, propertyID uint, opts PromoteOptions) (*core.PropertyPromotion, error) {
+	if opts.Product == "" {
+		opts.Product = DefaultPromotion
+	}
+	product, ok := PromotionProductByCode(opts.Product)
+	if !ok {
+		return nil, ErrInvalidPromotion
+	}
+	if _, err := s.Owned(ctx, ownerID, propertyID); err != nil {
+		return nil, err
+	}
+	var promo *core.PropertyPromotion
+	err := s.Tx.WithinTx(ctx, func(repos Repositories) error {
+		plan, err := repos.Plans.FirstOrCreate(ctx, freePlan(ownerID))
+		if err != nil {
+			return err
+		}
+		promo, err = s.buy(ctx, repos, plan, propertyID, product, opts.StartsAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	telemetry.Promotions.Inc()
+	return promo, nil
+}
+
+// buy books product for the listing and charges plan. It runs inside the
+// transaction that locked plan.
+func (s *PropertyService) buy(ctx context.Context, repos Repositories, plan *core.UserPlan, propertyID uint, product PromotionProduct, startsAt *time.Time) (*core.PropertyPromotion, error) {
+	now := s.Now()
+	plan = effectivePlan(plan, now)
+	used, err := repos.Properties.PromotionCredits(ctx, plan.UserID, creditPeriod(now))
+	if err != nil {
+		return nil, err
+	}
+	if included := Plans[plan.PlanType].PromotionCredits; used+product.Credits > included {
+		return nil, &PromotionLimitError{PlanType: plan.PlanType, Credits: included, Used: used, Cost: product.Credits}
+	}
+
+	history, err := repos.Properties.Promotions(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	start := coveredUntil(history, product.Tier, now)
+	if startsAt != nil {
+		if startsAt.Before(now) || startsAt.After(now.Add(MaxScheduleAhead)) {
+			return nil, ErrInvalidPeriod
+		}
+		start = *startsAt
+	}
+	end := start.AddDate(0, 0, product.Days)
+	for _, h := range history {
+		if h.Tier == product.Tier && start.Before(h.ExpiresAt) && end.After(h.StartsAt) {
+			return nil, ErrAlreadyPromoted
+		}
+	}
+
+	promo := &core.PropertyPromotion{
+		PropertyID: propertyID,
+		UserID:     plan.UserID,
+		Product:    product.Code,
+		Tier:       product.Tier,
+		Credits:    product.Credits,
+		StartsAt:   start,
+		ExpiresAt:  end,
+	}
+	if err := repos.Properties.CreatePromotion(ctx, promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+// coveredUntil returns when the promotions of tier running at now end,
+// following extensions that start as the previous one ends. It returns now
+// when none runs.
+func coveredUntil(history []core.PropertyPromotion, tier string, now time.Time) time.Time {
+	end := now
+	for extended := true; extended; {
+		extended = false
+		for _, h := range history {
+			if h.Tier == tier && !h.StartsAt.After(end) && h.ExpiresAt.After(end) {
+				end, extended = h.ExpiresAt, true
+			}
+		}
+	}
+	return end
+}
+
+// Promotion statuses
+const (
+	PromotionScheduled = "scheduled"
+	PromotionActive    = "active"
+	PromotionExpired   = "expired"
+)
+
+// PromotionEntry is a promotion in a listing's history.
+type PromotionEntry struct {
+	core.PropertyPromotion
+	Status string `json:"status"`
+}
+
+// Promotions returns the promotion history of an owned listing, by start.
+func (s *PropertyService) Promotions(ctx context.Context, ownerID, propertyID uint) ([]PromotionEntry, error) {
+	if _, err := s.Owned(ctx, ownerID, propertyID); err != nil {
+		return nil, err
+	}
+	history, err := s.Properties.Promotions(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.Now()
+	out := make([]PromotionEntry, 0, len(history))
+	for _, h := range history {
+		e := PromotionEntry{PropertyPromotion: h, Status: PromotionExpired}
+		switch {
+		case h.StartsAt.After(now):
+			e.Status = PromotionScheduled
+		case h.Running(now):
+			e.Status = PromotionActive
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
@@ -0,0 +1,150 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+func TestPromotionCatalog(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := memory.NewStore()
+	store.Now = clock
+	s := service.New(store.Repositories(), config.Defaults())
+	s.Properties.Now, s.Plans.Now = clock, clock
+
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	p := &core.Property{OwnerID: owner.ID, Title: "flat"}
+	if _, err := s.Properties.Create(ctx, p, service.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	promote := func(product string, startsAt *time.Time) (*core.PropertyPromotion, error) {
+		return s.Properties.Promote(ctx, owner.ID, p.ID, service.PromoteOptions{Product: product, StartsAt: startsAt})
+	}
+
+	if _, err := promote("gold", nil); !errors.Is(err, service.ErrInvalidPromotion) {
+		t.Fatalf("unknown product: %v", err)
+	}
+	first, err := promote("top_city_3d", nil)
+	if err != nil || !first.StartsAt.Equal(now) || first.Credits != 3 {
+		t.Fatalf("first = %+v, %v", first, err)
+	}
+	// buying the same tier again extends it
+	ext, err := promote("top_city_3d", nil)
+	if err != nil || !ext.StartsAt.Equal(first.ExpiresAt) {
+		t.Fatalf("extension = %+v, %v", ext, err)
+	}
+	later := now.AddDate(0, 0, 3)
+	var limit *service.PromotionLimitError
+	if _, err := promote("urgent_7d", &later); !errors.As(err, &limit) || limit.Used != 6 || limit.Credits != 6 {
+		t.Fatalf("over the free credits: %v", err)
+	}
+
+	if _, err := s.Plans.Upgrade(ctx, owner.ID, "premium"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := promote("top_city_7d", &later); !errors.Is(err, service.ErrAlreadyPromoted) {
+		t.Fatalf("overlapping: %v", err)
+	}
+	past := now.Add(-time.Hour)
+	if _, err := promote("urgent_7d", &past); !errors.Is(err, service.ErrInvalidPeriod) {
+		t.Fatalf("in the past: %v", err)
+	}
+	if _, err := promote("urgent_7d", &later); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.Properties.Promotions(ctx, owner.ID, p.ID)
+	if err != nil || len(entries) != 3 || entries[0].Status != service.PromotionActive || entries[2].Status != service.PromotionScheduled {
+		t.Fatalf("history = %+v, %v", entries, err)
+	}
+	if got, _ := s.Properties.Get(ctx, p.ID, service.Viewer{}); got.IsHighlighted {
+		t.Fatalf("highlighted before it starts: %+v", got)
+	}
+
+	now = later.Add(time.Hour)
+	mine, _ := s.Properties.OwnerListings(ctx, owner.ID)
+	if len(mine) != 1 || !mine[0].IsPromoted || !mine[0].IsHighlighted || !mine[0].ExpiresAt.Equal(ext.ExpiresAt) {
+		t.Fatalf("on day 4: %+v", mine)
+	}
+	if got, _ := s.Properties.Get(ctx, p.ID, service.Viewer{}); !got.IsHighlighted {
+		t.Fatalf("not highlighted on day 4: %+v", got)
+	}
+	now = ext.ExpiresAt
+	if entries, _ = s.Properties.Promotions(ctx, owner.ID, p.ID); entries[1].Status != service.PromotionExpired {
+		t.Fatalf("history = %+v", entries)
+	}
+	usage, _ := s.Plans.Usage(ctx, owner.ID)
+	if usage.PromotionCreditsUsed != 8 || usage.PromotionCredits() != 30 {
+		t.Fatalf("usage = %+v", usage)
+	}
+}
+
+func TestExpiredPlanIsFree(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := memory.NewStore()
+	store.Now = clock
+	s := service.New(store.Repositories(), config.Defaults())
+	s.Properties.Now, s.Plans.Now = clock, clock
+
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	if _, err := s.Plans.Upgrade(ctx, owner.ID, "premium"); err != nil {
+		t.Fatal(err)
+	}
+	var listings []*service.OwnerListing
+	for i := 0; i < 4; i++ {
+		l, err := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID, Title: "flat"}, service.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		listings = append(listings, l)
+	}
+
+	// the premium month ran out and was not renewed
+	now = now.AddDate(0, 1, 1)
+	usage, err := s.Plans.Usage(ctx, owner.ID)
+	if err != nil || usage.Plan.PlanType != "free" || usage.Plan.MaxListings != 3 || usage.CanCreateMore() || usage.PromotionCredits() != 6 {
+		t.Fatalf("usage = %+v, %v", usage, err)
+	}
+	var limit *service.ListingLimitError
+	if _, err := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID, Title: "flat"}, service.CreateOptions{}); !errors.As(err, &limit) || limit.PlanType != "free" {
+		t.Fatalf("create on a lapsed plan: %v", err)
+	}
+	var credits *service.PromotionLimitError
+	_, err = s.Properties.Promote(ctx, owner.ID, listings[0].ID, service.PromoteOptions{Product: "top_city_30d"})
+	if !errors.As(err, &credits) || credits.PlanType != "free" || credits.Credits != 6 {
+		t.Fatalf("promote on a lapsed plan: %v", err)
+	}
+}
+
+func TestDeletedListingKeepsSpentCredits(t *testing.T) {
+	ctx := context.Background()
+	s := newServices(t)
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	l, err := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID, Title: "flat"}, service.CreateOptions{Promote: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Properties.Delete(ctx, owner.ID, l.ID); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := s.Plans.Usage(ctx, owner.ID)
+	if err != nil || usage.ActiveListings != 0 || usage.PromotionCreditsUsed != 6 {
+		t.Fatalf("usage = %+v, %v", usage, err)
+	}
+	again, _ := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID, Title: "flat"}, service.CreateOptions{})
+	var limit *service.PromotionLimitError
+	if _, err := s.Properties.Promote(ctx, owner.ID, again.ID, service.PromoteOptions{}); !errors.As(err, &limit) {
+		t.Fatalf("credits came back with the deleted listing: %v", err)
+	}
+}
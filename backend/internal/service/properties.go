@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
+)
+
+type PropertyService struct {
+	Properties PropertyRepository
+	Plans      *PlanService
+	Tx         Transactor
+	Audit      *AuditService     // optional
+	Analytics  *AnalyticsService // optional
+
+	Now func() time.Time
+}
+
+func NewPropertyService(properties PropertyRepository, plans *PlanService, tx Transactor) *PropertyService {
+	return &PropertyService{Properties: properties, Plans: plans, Tx: tx, Now: time.Now}
+}
+
+// CreateOptions are side effects applied together with a new listing.
+type CreateOptions struct {
+	Promote bool // buy DefaultPromotion right away
+}
+
+// Create stores a new listing for p.OwnerID, with its images and an
+// optional promotion, if the owner's plan allows both. The owner's plan row
+// is locked for the whole transaction, so parallel requests are counted
+// one after another and cannot overshoot the limit.
+func (s *PropertyService) Create(ctx context.Context, p *core.Property, opts CreateOptions) (*OwnerListing, error) {
+	if err := s.checkAmenities(ctx, p); err != nil {
+		return nil, err
+	}
+	if p.PhoneVisibility == "" {
+		p.PhoneVisibility = core.ContactOnRequest
+	}
+	if p.EmailVisibility == "" {
+		p.EmailVisibility = core.ContactOnRequest
+	}
+	p.ModerationStatus, p.ModerationReason = core.ListingApproved, ""
+	l := &OwnerListing{}
+	err := s.Tx.WithinTx(ctx, func(repos Repositories) error {
+		plan, err := repos.Plans.FirstOrCreate(ctx, freePlan(p.OwnerID))
+		if err != nil {
+			return err
+		}
+		plan = effectivePlan(plan, s.Now())
+		n, err := repos.Properties.CountByOwner(ctx, p.OwnerID)
+		if err != nil {
+			return err
+		}
+		if n >= int64(plan.MaxListings) {
+			return &ListingLimitError{
+				PlanType:       plan.PlanType,
+				MaxListings:    plan.MaxListings,
+				ActiveListings: n,
+			}
+		}
+		if err := repos.Properties.Create(ctx, p); err != nil {
+			return err
+		}
+		if opts.Promote {
+			product, _ := PromotionProductByCode(DefaultPromotion)
+			promo, err := s.buy(ctx, repos, plan, p.ID, product, nil)
+			if err != nil {
+				return err
+			}
+			l.IsPromoted = true
+			l.ExpiresAt = &promo.ExpiresAt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	telemetry.ListingsCreated.Inc()
+	if l.IsPromoted {
+		telemetry.Promotions.Inc()
+	}
+	l.Property = *p
+	return l, nil
+}
+
+// checkAmenities makes sure p names catalog amenities only, and each once.
+func (s *PropertyService) checkAmenities(ctx context.Context, p *core.Property) error {
+	if len(p.Amenities) == 0 {
+		p.Amenities = []string{}
+		return nil
+	}
+	catalog, err := s.Properties.Amenities(ctx)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(catalog))
+	for _, a := range catalog {
+		known[a.Code] = true
+	}
+	codes := make([]string, 0, len(p.Amenities))
+	seen := map[string]bool{}
+	for i, code := range p.Amenities {
+		if !known[code] {
+			return &UnknownAmenityError{Index: i, Code: code}
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	p.Amenities = codes
+	return nil
+}
+
+// Amenities returns the amenity catalog.
+func (s *PropertyService) Amenities(ctx context.Context) ([]core.Amenity, error) {
+	return s.Properties.Amenities(ctx)
+}
+
+// List returns the public feed, redacted.
+func (s *PropertyService) List(ctx context.Context, f PropertyFilter) ([]core.Property, error) {
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 100
+	}
+	// a listing has each amenity once, so repeated codes would match nothing
+	f.Amenities = slices.Compact(slices.Sorted(slices.Values(f.Amenities)))
+	items, err := s.Properties.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.highlight(ctx, items); err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i] = items[i].Redacted()
+	}
+	return items, nil
+}
+
+// highlight marks the items with an urgent highlight running.
+func (s *PropertyService) highlight(ctx context.Context, items []core.Property) error {
+	ids := make([]uint, len(items))
+	for i, p := range items {
+		ids[i] = p.ID
+	}
+	running, err := s.Properties.RunningPromotions(ctx, ids, s.Now())
+	if err != nil {
+		return err
+	}
+	urgent := map[uint]bool{}
+	for _, promo := range running {
+		if promo.Tier == core.PromotionUrgent {
+			urgent[promo.PropertyID] = true
+		}
+	}
+	for i := range items {
+		items[i].IsHighlighted = urgent[items[i].ID]
+	}
+	return nil
+}
+
+// Get returns a listing, redacted, and counts the view.
+func (s *PropertyService) Get(ctx context.Context, id uint, v Viewer) (*core.Property, error) {
+	p, err := s.Properties.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.ModerationStatus == core.ListingRejected && p.OwnerID != v.UserID {
+		return nil, ErrNotFound
+	}
+	s.Analytics.View(ctx, p, v)
+	items := []core.Property{*p}
+	if err := s.highlight(ctx, items); err != nil {
+		return nil, err
+	}
+	r := items[0].Redacted()
+	return &r, nil
+}
+
+// Contacts are the contacts of a listing shown to one user.
+type Contacts struct {
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// RevealContacts gives viewerID the contacts the landlord did not hide and
+// records that they were revealed. Owners see their own contacts without
+// a record. ErrContactsHidden means there is nothing to show.
+func (s *PropertyService) RevealContacts(ctx context.Context, viewerID, propertyID uint, ip string) (*Contacts, error) {
+	p, err := s.Properties.ByID(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if p.OwnerID == viewerID {
+		return &Contacts{Phone: p.ContactPhone, Email: p.ContactEmail}, nil
+	}
+	var out Contacts
+	var fields []string
+	if p.PhoneVisibility != core.ContactHidden && p.ContactPhone != "" {
+		out.Phone = p.ContactPhone
+		fields = append(fields, "phone")
+	}
+	if p.EmailVisibility != core.ContactHidden && p.ContactEmail != "" {
+		out.Email = p.ContactEmail
+		fields = append(fields, "email")
+	}
+	if len(fields) == 0 {
+		return nil, ErrContactsHidden
+	}
+	reveal := &core.ContactReveal{PropertyID: p.ID, ViewerID: viewerID, Fields: strings.Join(fields, ","), IP: ip}
+	if err := s.Properties.CreateReveal(ctx, reveal); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "contacts revealed", "property_id", p.ID, "viewer_id", viewerID, "fields", reveal.Fields)
+	s.Analytics.Count(ctx, p.ID, core.MetricReveals)
+	return &out, nil
+}
+
+// SetContactVisibility changes who may see the contacts of an owned
+// listing.
+func (s *PropertyService) SetContactVisibility(ctx context.Context, ownerID, propertyID uint, phone, email string) (*core.Property, error) {
+	p, err := s.Owned(ctx, ownerID, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Properties.SetContactVisibility(ctx, propertyID, phone, email); err != nil {
+		return nil, err
+	}
+	p.PhoneVisibility, p.EmailVisibility = phone, email
+	return p, nil
+}
+
+// Favorite adds the listing to the user's favorites. Adding it again
+// changes nothing.
+func (s *PropertyService) Favorite(ctx context.Context, userID, propertyID uint) error {
+	if _, err := s.Properties.ByID(ctx, propertyID); err != nil {
+		return err
+	}
+	added, err := s.Properties.AddFavorite(ctx, userID, propertyID)
+	if err != nil {
+		return err
+	}
+	if added {
+		s.Analytics.Count(ctx, propertyID, core.MetricFavorites)
+	}
+	return nil
+}
+
+func (s *PropertyService) Unfavorite(ctx context.Context, userID, propertyID uint) error {
+	return s.Properties.RemoveFavorite(ctx, userID, propertyID)
+}
+
+func (s *PropertyService) Count(ctx context.Context) (int64, error) {
+	return s.Properties.Count(ctx)
+}
+
+// Owned returns the property if ownerID owns it.
+func (s *PropertyService) Owned(ctx context.Context, ownerID, propertyID uint) (*core.Property, error) {
+	p, err := s.Properties.ByID(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if p.OwnerID != ownerID {
+		return nil, ErrNotOwner
+	}
+	return p, nil
+}
+
+// Delete removes an owned listing with its images and promotions and
+// returns what was deleted, so the caller can clean up the image files.
+func (s *PropertyService) Delete(ctx context.Context, ownerID, propertyID uint) (*core.Property, error) {
+	p, err := s.Owned(ctx, ownerID, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Properties.Delete(ctx, propertyID); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(ctx, core.AuditEvent{
+		ActorID:    ref(ownerID),
+		Action:     core.AuditListingDelete,
+		TargetType: "property",
+		TargetID:   ref(propertyID),
+		Details:    core.AuditDetails{"title": p.Title, "city": p.City},
+	})
+	return p, nil
+}
+
+// Review records a moderator's decision on a listing. A rejected listing
+// leaves the public feed until a moderator approves it again.
+func (s *PropertyService) Review(ctx context.Context, reviewerID, propertyID uint, approved bool, reason string) (*core.Property, error) {
+	p, err := s.Properties.ByID(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	p.ModerationStatus, p.ModerationReason = core.ListingApproved, ""
+	if !approved {
+		p.ModerationStatus, p.ModerationReason = core.ListingRejected, reason
+	}
+	if err := s.Properties.SetModeration(ctx, p.ID, p.ModerationStatus, p.ModerationReason); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(ctx, core.AuditEvent{
+		ActorID:    ref(reviewerID),
+		Action:     core.AuditListingReview,
+		TargetType: "property",
+		TargetID:   ref(p.ID),
+		Details:    core.AuditDetails{"status": p.ModerationStatus, "reason": p.ModerationReason},
+	})
+	return p, nil
+}
+
+func (s *PropertyService) AddImage(ctx context.Context, img *core.PropertyImage) error {
+	return s.Properties.AddImage(ctx, img)
+}
+
+// OwnerListing is a listing as its owner sees it.
+type OwnerListing struct {
+	core.Property
+	IsPromoted bool `json:"isPromoted"`
+	// ExpiresAt is when the top placement ends, extensions included.
+	ExpiresAt *time.Time `json:"promotionExpiresAt,omitempty"`
+	// ContactReveals counts the users who revealed the contacts.
+	ContactReveals int64 `json:"contactReveals"`
+}
+
+func (s *PropertyService) OwnerListings(ctx context.Context, ownerID uint) ([]OwnerListing, error) {
+	props, err := s.Properties.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.Now()
+	out := make([]OwnerListing, 0, len(props))
+	for _, p := range props {
+		l := OwnerListing{Property: p}
+		history, err := s.Properties.Promotions(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		if end := coveredUntil(history, core.PromotionTopCity, now); end.After(now) {
+			l.IsPromoted = true
+			l.ExpiresAt = &end
+		}
+		l.IsHighlighted = coveredUntil(history, core.PromotionUrgent, now).After(now)
+		if l.ContactReveals, err = s.Properties.CountRevealers(ctx, p.ID); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
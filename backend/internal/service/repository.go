@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+// Repositories bundles the storage the services need. Postgres and
+// in-memory implementations live under internal/repository.
+type Repositories struct {
+	Users      UserRepository
+	Properties PropertyRepository
+	Plans      PlanRepository
+	Chat       ChatRepository
+	Audit      AuditRepository
+	Analytics  AnalyticsRepository
+
+	Notifications NotificationRepository
+
+	Tx Transactor
+}
+
+// Transactor runs fn with repositories bound to one transaction. The
+// transaction commits when fn returns nil and rolls back otherwise.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// Lookups return ErrNotFound when nothing matches, and inserts that hit a
+// unique constraint return ErrConflict.
+
+type UserRepository interface {
+	Create(ctx context.Context, u *core.User) error
+	ByID(ctx context.Context, id uint) (*core.User, error)
+	ByEmail(ctx context.Context, email string) (*core.User, error)
+	UpdateRole(ctx context.Context, id uint, role string) error
+	Count(ctx context.Context) (int64, error)
+}
+
+// PropertyFilter narrows the public listing feed.
+type PropertyFilter struct {
+	City        string   // matches city or address, case-insensitive
+	Amenities   []string // amenity codes, all of them required
+	PetsAllowed bool     // only listings that allow pets
+	Furnished   bool     // only furnished listings
+	Limit       int
+}
+
+// Properties are loaded with their images and amenity codes.
+type PropertyRepository interface {
+	// Create stores the property together with p.Images and p.Amenities,
+	// which must be codes of the catalog.
+	Create(ctx context.Context, p *core.Property) error
+	// ByID loads the property with its images sorted by Order.
+	ByID(ctx context.Context, id uint) (*core.Property, error)
+	// List returns promoted listings first, then the newest. Rejected
+	// listings are left out.
+	List(ctx context.Context, f PropertyFilter) ([]core.Property, error)
+	ListByOwner(ctx context.Context, ownerID uint) ([]core.Property, error)
+	CountByOwner(ctx context.Context, ownerID uint) (int64, error)
+	Count(ctx context.Context) (int64, error)
+	AddImage(ctx context.Context, img *core.PropertyImage) error
+	// Delete removes the property with its images, favorites and
+	// analytics. Conversations about it stay, and so do its promotions:
+	// they record the credits spent.
+	Delete(ctx context.Context, id uint) error
+
+	// RunningPromotions returns the promotions of the properties running
+	// at now.
+	RunningPromotions(ctx context.Context, propertyIDs []uint, now time.Time) ([]core.PropertyPromotion, error)
+	CreatePromotion(ctx context.Context, p *core.PropertyPromotion) error
+	// Promotions returns every promotion of the property, past and
+	// scheduled, by StartsAt.
+	Promotions(ctx context.Context, propertyID uint) ([]core.PropertyPromotion, error)
+	// PromotionCredits sums the credits of the promotions the user bought
+	// since then.
+	PromotionCredits(ctx context.Context, userID uint, since time.Time) (int, error)
+	// ExpiringPromotions returns the promotions of existing listings ending
+	// in (from, until].
+	ExpiringPromotions(ctx context.Context, from, until time.Time) ([]ExpiringPromotion, error)
+
+	// Amenities returns the amenity catalog sorted by Order.
+	Amenities(ctx context.Context) ([]core.Amenity, error)
+
+	SetContactVisibility(ctx context.Context, id uint, phone, email string) error
+	SetModeration(ctx context.Context, id uint, status, reason string) error
+	CreateReveal(ctx context.Context, r *core.ContactReveal) error
+	// CountRevealers returns how many users revealed the contacts.
+	CountRevealers(ctx context.Context, propertyID uint) (int64, error)
+
+	// AddFavorite reports whether the favorite is new.
+	AddFavorite(ctx context.Context, userID, propertyID uint) (bool, error)
+	RemoveFavorite(ctx context.Context, userID, propertyID uint) error
+}
+
+// ExpiringPromotion is a promotion with the title of its listing.
+type ExpiringPromotion struct {
+	core.PropertyPromotion
+	Title string
+}
+
+type PlanRepository interface {
+	ByUser(ctx context.Context, userID uint) (*core.UserPlan, error)
+	// FirstOrCreate returns the plan of def.UserID, inserting def if the
+	// user has none. Inside a transaction the row stays locked until the
+	// transaction ends.
+	FirstOrCreate(ctx context.Context, def *core.UserPlan) (*core.UserPlan, error)
+	Create(ctx context.Context, p *core.UserPlan) error
+	Save(ctx context.Context, p *core.UserPlan) error
+	// Expiring returns the paid plans ending in (from, until].
+	Expiring(ctx context.Context, from, until time.Time) ([]core.UserPlan, error)
+}
+
+// BlockedUser is an entry of a user's block list.
+type BlockedUser struct {
+	UserID    uint      `json:"userId"`
+	Name      string    `json:"name"`
+	BlockedAt time.Time `json:"blockedAt"`
+}
+
+type ChatRepository interface {
+	ConversationByID(ctx context.Context, id uint) (*core.Conversation, error)
+	// FindConversation looks for a conversation about the property between
+	// the two users, whoever started it.
+	FindConversation(ctx context.Context, propertyID, a, b uint) (*core.Conversation, error)
+	CreateConversation(ctx context.Context, c *core.Conversation) error
+	// Inbox lists conversations the user received, skipping initiators
+	// the user blocked.
+	Inbox(ctx context.Context, recipientID uint) ([]core.Conversation, error)
+	CountConversations(ctx context.Context) (int64, error)
+
+	CreateMessage(ctx context.Context, m *core.Message) error
+	MessageByID(ctx context.Context, conversationID, id uint) (*core.Message, error)
+	UpdateMessage(ctx context.Context, m *core.Message) error
+	// MessagesBefore returns up to limit messages with ID < before (0 means
+	// from the newest), newest first.
+	MessagesBefore(ctx context.Context, conversationID, before uint, limit int) ([]core.Message, error)
+	// MessagesChanged returns up to limit messages with since < UpdatedAt
+	// <= until, oldest change first.
+	MessagesChanged(ctx context.Context, conversationID uint, since, until time.Time, limit int) ([]core.Message, error)
+	LastMessage(ctx context.Context, conversationID uint) (*core.Message, error)
+	CountUnread(ctx context.Context, conversationID, readerID uint) (int64, error)
+	// MissedMessages returns the messages of ids that are not deleted, by
+	// ID, with the names of their senders.
+	MissedMessages(ctx context.Context, ids []uint) ([]MissedMessage, error)
+
+	Block(ctx context.Context, b *core.UserBlock) error
+	Unblock(ctx context.Context, blockerID, blockedID uint) error
+	HasBlocked(ctx context.Context, blockerID, blockedID uint) (bool, error)
+	ListBlocked(ctx context.Context, blockerID uint) ([]BlockedUser, error)
+
+	CreateFlagged(ctx context.Context, f *core.FlaggedMessage) error
+	FlaggedByID(ctx context.Context, id uint) (*core.FlaggedMessage, error)
+	// ListFlagged filters by status unless it is empty; before works like
+	// MessagesBefore.
+	ListFlagged(ctx context.Context, status string, before uint, limit int) ([]core.FlaggedMessage, error)
+	// ReviewFlagged records the review of f if it is still pending, and
+	// returns ErrAlreadyReviewed otherwise.
+	ReviewFlagged(ctx context.Context, f *core.FlaggedMessage) error
+	// ReleaseFlagged reviews f like ReviewFlagged and stores msg in one
+	// transaction. A msg with an ID is an existing message getting a held
+	// edit.
+	ReleaseFlagged(ctx context.Context, f *core.FlaggedMessage, msg *core.Message) error
+}
+
+// MissedMessage is a chat message as a notification digest shows it.
+type MissedMessage struct {
+	ConversationID uint
+	SenderName     string
+	Content        string
+}
+
+// AuditFilter narrows the audit log; zero fields match everything.
+type AuditFilter struct {
+	Action  string
+	ActorID uint
+	Before  uint // works like MessagesBefore
+	Limit   int
+}
+
+// AnalyticsRepository keeps the daily listing counters. Days are UTC
+// dates.
+type AnalyticsRepository interface {
+	// AddView counts a view on day unless viewer was already counted for
+	// the listing that day.
+	AddView(ctx context.Context, propertyID uint, day time.Time, viewer string) error
+	// Add adds one to a metric of the listing's day.
+	Add(ctx context.Context, propertyID uint, day time.Time, metric string) error
+	// Daily returns the days from <= Day <= to of the listings that have
+	// any events, by day.
+	Daily(ctx context.Context, propertyIDs []uint, from, to time.Time) ([]core.ListingStat, error)
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, e *core.AuditEvent) error
+	// List returns matching events, newest first.
+	List(ctx context.Context, f AuditFilter) ([]core.AuditEvent, error)
+}
+
+// NotificationFilter narrows a user's notifications.
+type NotificationFilter struct {
+	UserID     uint
+	Before     uint // works like MessagesBefore
+	UnreadOnly bool
+	Limit      int
+}
+
+type NotificationRepository interface {
+	// Create stores n and reports whether it is new: a notification with a
+	// DedupKey the user already has is skipped.
+	Create(ctx context.Context, n *core.Notification) (bool, error)
+	// List returns matching notifications, newest first.
+	List(ctx context.Context, f NotificationFilter) ([]core.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	// MarkRead returns ErrNotFound unless the user has the notification.
+	// Notifications read before keep their ReadAt.
+	MarkRead(ctx context.Context, userID, id uint, at time.Time) error
+	MarkAllRead(ctx context.Context, userID uint, at time.Time) (int64, error)
+
+	// Preferences returns the defaults, everything enabled, for users who
+	// never changed them.
+	Preferences(ctx context.Context, userID uint) (*core.NotificationPreference, error)
+	SavePreferences(ctx context.Context, p *core.NotificationPreference) error
+
+	// SavePushSubscription registers s.Endpoint for s.UserID, taking it
+	// over from whoever had it: the same browser may sign in as someone
+	// else.
+	SavePushSubscription(ctx context.Context, s *core.PushSubscription) error
+	DeletePushSubscription(ctx context.Context, userID uint, endpoint string) error
+	PushSubscriptions(ctx context.Context, userID uint) ([]core.PushSubscription, error)
+}
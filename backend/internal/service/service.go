@@ -0,0 +1,65 @@
+package service
+
+import (
+	"log/slog"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/chatfilter"
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+)
+
+// Services is what the HTTP handlers depend on.
+type Services struct {
+	Users      *UserService
+	Plans      *PlanService
+	Properties *PropertyService
+	Chat       *ChatService
+	Audit      *AuditService
+	Analytics  *AnalyticsService
+
+	Notifications *NotificationService
+}
+
+func New(repos Repositories, cfg *config.Config) *Services {
+	audit := NewAuditService(repos.Audit)
+	users := NewUserService(repos.Users)
+	users.Audit = audit
+	plans := NewPlanService(repos.Plans, repos.Properties, repos.Tx)
+	plans.Audit = audit
+	properties := NewPropertyService(repos.Properties, plans, repos.Tx)
+	properties.Audit = audit
+	analytics := NewAnalyticsService(repos.Analytics, repos.Properties)
+	properties.Analytics = analytics
+	chat := NewChatService(repos.Chat, repos.Properties, repos.Users, NewChatFilter(cfg), cfg.Chat.EditWindow)
+	chat.Analytics = analytics
+	return &Services{
+		Users:      users,
+		Plans:      plans,
+		Properties: properties,
+		Chat:       chat,
+		Audit:      audit,
+		Analytics:  analytics,
+
+		Notifications: NewNotificationService(repos.Notifications),
+	}
+}
+
+// NewChatFilter builds the message filter pipeline from config. Rate and
+// duplicate checks run first so spammers are stopped before content rules.
+func NewChatFilter(cfg *config.Config) *chatfilter.Pipeline {
+	f := cfg.Chat.Filter
+	action := func(name, value string, def chatfilter.Action) chatfilter.Action {
+		a, err := chatfilter.ParseAction(value)
+		if err != nil {
+			slog.Warn("chat filter: invalid action, using the default", "rule", name, "err", err, "default", def)
+			return def
+		}
+		return a
+	}
+	return chatfilter.NewPipeline(
+		chatfilter.Rule{Detector: chatfilter.NewRateLimiter(f.RateLimit, f.RateWindow), Action: action("rate", f.RateAction, chatfilter.Reject)},
+		chatfilter.Rule{Detector: chatfilter.NewDuplicateDetector(f.DupThreshold, f.DupWindow), Action: action("duplicates", f.DupAction, chatfilter.Hold)},
+		chatfilter.Rule{Detector: chatfilter.NewPhoneDetector(), Action: action("phones", f.Phones, chatfilter.Mask)},
+		chatfilter.Rule{Detector: chatfilter.NewLinkDetector(), Action: action("links", f.Links, chatfilter.Mask)},
+		chatfilter.Rule{Detector: chatfilter.NewMessengerDetector(), Action: action("messengers", f.Messengers, chatfilter.Mask)},
+	)
+}
@@ -0,0 +1,268 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/chatfilter"
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/repository/memory"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service/servicetest"
+)
+
+func newServices(t *testing.T) *service.Services {
+	t.Helper()
+	cfg := config.Defaults()
+	return service.New(memory.NewRepositories(), cfg)
+}
+
+func TestListingLimit(t *testing.T) {
+	ctx := context.Background()
+	s := newServices(t)
+	owner, err := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < service.Plans["free"].MaxListings; i++ {
+		if _, err := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID, Title: "flat"}, service.CreateOptions{}); err != nil {
+			t.Fatalf("listing %d: %v", i, err)
+		}
+	}
+	var limitErr *service.ListingLimitError
+	if _, err := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID}, service.CreateOptions{}); !errors.As(err, &limitErr) {
+		t.Fatalf("got %v, want ListingLimitError", err)
+	}
+
+	if _, err := s.Plans.Upgrade(ctx, owner.ID, "premium"); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := s.Plans.Usage(ctx, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !usage.CanCreateMore() || usage.ActiveListings != 3 {
+		t.Fatalf("usage after upgrade = %+v", usage)
+	}
+}
+
+func TestListingLimitConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s := newServices(t)
+	owner, err := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := servicetest.CreateListingsConcurrently(t, s, owner.ID, 20)
+	if want := int64(service.Plans["free"].MaxListings); created != want {
+		t.Fatalf("created %d listings in parallel, want %d", created, want)
+	}
+}
+
+func TestCreateRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	s := service.New(store.Repositories(), config.Defaults())
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+
+	listing, err := s.Properties.Create(ctx, &core.Property{
+		OwnerID: owner.ID,
+		Title:   "flat",
+		Images:  []core.PropertyImage{{URL: "/uploads/a.jpg"}, {URL: "/uploads/b.jpg", Order: 1}},
+	}, service.CreateOptions{Promote: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !listing.IsPromoted || len(listing.Images) != 2 {
+		t.Fatalf("listing = %+v", listing)
+	}
+
+	// a failing promotion must take the listing down with it
+	repos := store.Repositories()
+	err = repos.Tx.WithinTx(ctx, func(tx service.Repositories) error {
+		if err := tx.Properties.Create(ctx, &core.Property{OwnerID: owner.ID}); err != nil {
+			return err
+		}
+		if err := tx.Properties.CreatePromotion(ctx, &core.PropertyPromotion{PropertyID: listing.ID, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			return err
+		}
+		return service.ErrAlreadyPromoted
+	})
+	if !errors.Is(err, service.ErrAlreadyPromoted) {
+		t.Fatalf("got %v, want ErrAlreadyPromoted", err)
+	}
+	if history, _ := repos.Properties.Promotions(ctx, listing.ID); len(history) != 1 {
+		t.Fatalf("%d promotions after rollback, want 1", len(history))
+	}
+	if n, _ := repos.Properties.CountByOwner(ctx, owner.ID); n != 1 {
+		t.Fatalf("owner has %d listings after rollback, want 1", n)
+	}
+}
+
+func TestChatBlockAndEditWindow(t *testing.T) {
+	ctx := context.Background()
+	s := newServices(t)
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	tenant, _ := s.Users.Register(ctx, "tenant@example.com", "secret1", "Tenant")
+	p := &core.Property{OwnerID: owner.ID, Title: "flat"}
+	if _, err := s.Properties.Create(ctx, p, service.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	conv, err := s.Chat.Start(ctx, tenant.ID, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.Chat.Start(ctx, tenant.ID, p.ID)
+	if err != nil || again.ID != conv.ID {
+		t.Fatalf("second start: %+v, %v", again, err)
+	}
+
+	res, err := s.Chat.Send(ctx, conv, tenant.ID, "hello, is it free?")
+	if err != nil || res.Message == nil {
+		t.Fatalf("send: %+v, %v", res, err)
+	}
+
+	now := time.Now()
+	s.Chat.Now = func() time.Time { return now.Add(time.Hour) }
+	if _, err := s.Chat.Edit(ctx, tenant.ID, conv.ID, res.Message.ID, "changed"); !errors.Is(err, service.ErrEditWindowExpired) {
+		t.Fatalf("edit after window: %v", err)
+	}
+	s.Chat.Now = time.Now
+
+	if err := s.Chat.Block(ctx, owner.ID, tenant.ID); err != nil {
+		t.Fatal(err)
+	}
+	res, err = s.Chat.Send(ctx, conv, tenant.ID, "are you there?")
+	if err != nil || !res.Blocked {
+		t.Fatalf("send after block: %+v, %v", res, err)
+	}
+	inbox, err := s.Chat.Inbox(ctx, owner.ID)
+	if err != nil || len(inbox) != 0 {
+		t.Fatalf("inbox after block: %v, %v", inbox, err)
+	}
+}
+
+// Two moderators may both load a pending message before either reviews it.
+func TestReleaseFlaggedOnce(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	repos := store.Repositories()
+	held := &core.FlaggedMessage{ConversationID: 1, SenderID: 2, Content: "call me", Action: "hold", Status: "pending"}
+	if err := repos.Chat.CreateFlagged(ctx, held); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := repos.Chat.FlaggedByID(ctx, held.ID)
+	second, _ := repos.Chat.FlaggedByID(ctx, held.ID)
+
+	first.Status = "released"
+	if err := repos.Chat.ReleaseFlagged(ctx, first, &core.Message{ConversationID: 1, SenderID: 2, Content: "call me"}); err != nil {
+		t.Fatal(err)
+	}
+	second.Status = "released"
+	if err := repos.Chat.ReleaseFlagged(ctx, second, &core.Message{ConversationID: 1, SenderID: 2, Content: "call me"}); !errors.Is(err, service.ErrAlreadyReviewed) {
+		t.Fatalf("second release: %v", err)
+	}
+	second.Status = "dismissed"
+	if err := repos.Chat.ReviewFlagged(ctx, second); !errors.Is(err, service.ErrAlreadyReviewed) {
+		t.Fatalf("dismiss after release: %v", err)
+	}
+	if msgs, _ := repos.Chat.MessagesBefore(ctx, 1, 0, 10); len(msgs) != 1 {
+		t.Fatalf("%d messages delivered, want 1", len(msgs))
+	}
+}
+
+func TestChatEditFiltered(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	s := service.New(memory.NewRepositories(), cfg)
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	tenant, _ := s.Users.Register(ctx, "tenant@example.com", "secret1", "Tenant")
+	p := &core.Property{OwnerID: owner.ID, Title: "flat"}
+	if _, err := s.Properties.Create(ctx, p, service.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	conv, _ := s.Chat.Start(ctx, tenant.ID, p.ID)
+	sent, err := s.Chat.Send(ctx, conv, tenant.ID, "is it free?")
+	if err != nil || sent.Message == nil {
+		t.Fatalf("send: %+v, %v", sent, err)
+	}
+
+	// phones are masked by default, in edits as in new messages
+	res, err := s.Chat.Edit(ctx, tenant.ID, conv.ID, sent.Message.ID, "call me +7 999 123-45-67")
+	if err != nil || res.Message == nil || res.Message.Content != "call me ***" {
+		t.Fatalf("masked edit: %+v, %v", res, err)
+	}
+
+	// held edits wait for a moderator and leave the message alone
+	cfg.Chat.Filter.Phones = "hold"
+	s.Chat.Filter = service.NewChatFilter(cfg)
+	res, err = s.Chat.Edit(ctx, tenant.ID, conv.ID, sent.Message.ID, "call me 8 (999) 123-45-67")
+	if err != nil || res.Message != nil || res.Verdict.Action != chatfilter.Hold {
+		t.Fatalf("held edit: %+v, %v", res, err)
+	}
+	msgs, _, _ := s.Chat.History(ctx, owner.ID, conv.ID, 0, 10)
+	if len(msgs) != 1 || msgs[0].Content != "call me ***" {
+		t.Fatalf("messages while held: %+v", msgs)
+	}
+	flagged, _ := s.Chat.Flagged(ctx, "pending", 0, 10)
+	if len(flagged) != 1 || flagged[0].EditOf == nil || *flagged[0].EditOf != sent.Message.ID {
+		t.Fatalf("flagged = %+v", flagged)
+	}
+	released, _, err := s.Chat.Release(ctx, owner.ID, flagged[0].ID)
+	if err != nil || released.ID != sent.Message.ID || released.Content != "call me 8 (999) 123-45-67" {
+		t.Fatalf("release: %+v, %v", released, err)
+	}
+	if msgs, _, _ = s.Chat.History(ctx, owner.ID, conv.ID, 0, 10); len(msgs) != 1 {
+		t.Fatalf("release added a message: %+v", msgs)
+	}
+
+	cfg.Chat.Filter.Phones = "reject"
+	s.Chat.Filter = service.NewChatFilter(cfg)
+	res, err = s.Chat.Edit(ctx, tenant.ID, conv.ID, sent.Message.ID, "+79991234567")
+	if err != nil || res.Message != nil || res.Verdict.Action != chatfilter.Reject {
+		t.Fatalf("rejected edit: %+v, %v", res, err)
+	}
+}
+
+func TestListingReview(t *testing.T) {
+	ctx := context.Background()
+	s := newServices(t)
+	owner, _ := s.Users.Register(ctx, "owner@example.com", "secret1", "Owner")
+	l, err := s.Properties.Create(ctx, &core.Property{OwnerID: owner.ID, Title: "flat", City: "Москва"}, service.CreateOptions{})
+	if err != nil || l.ModerationStatus != core.ListingApproved {
+		t.Fatalf("created %+v, %v", l, err)
+	}
+
+	p, err := s.Properties.Review(ctx, 99, l.ID, false, "нет фото")
+	if err != nil || p.ModerationStatus != core.ListingRejected || p.ModerationReason != "нет фото" {
+		t.Fatalf("rejected %+v, %v", p, err)
+	}
+	if feed, _ := s.Properties.List(ctx, service.PropertyFilter{}); len(feed) != 0 {
+		t.Fatalf("rejected listing in the feed: %+v", feed)
+	}
+	if _, err := s.Properties.Get(ctx, l.ID, service.Viewer{UserID: owner.ID + 1}); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("rejected listing shown: %v", err)
+	}
+	if got, err := s.Properties.Get(ctx, l.ID, service.Viewer{UserID: owner.ID}); err != nil || got.ModerationReason != "нет фото" {
+		t.Fatalf("owner view = %+v, %v", got, err)
+	}
+	events, _ := s.Audit.List(ctx, service.AuditFilter{Action: core.AuditListingReview})
+	if len(events) != 1 || events[0].Details["status"] != core.ListingRejected {
+		t.Fatalf("audit = %+v", events)
+	}
+
+	if p, err = s.Properties.Review(ctx, 99, l.ID, true, "ignored"); err != nil || p.ModerationReason != "" {
+		t.Fatalf("approved %+v, %v", p, err)
+	}
+	if feed, _ := s.Properties.List(ctx, service.PropertyFilter{}); len(feed) != 1 {
+		t.Fatalf("approved listing missing: %+v", feed)
+	}
+	if _, err := s.Properties.Review(ctx, 99, l.ID+100, true, ""); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("missing listing: %v", err)
+	}
+}
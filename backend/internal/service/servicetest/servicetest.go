@@ -0,0 +1,61 @@
+// Package servicetest holds checks shared by the tests of every
+// repository implementation.
+package servicetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/service"
+)
+
+// CreateListingsConcurrently fires n listing creations for ownerID at once
+// and returns how many got through. Every other request must fail with
+// ListingLimitError, and the stored count must match.
+func CreateListingsConcurrently(t *testing.T, s *service.Services, ownerID uint, n int) int64 {
+	t.Helper()
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int64
+		start   = make(chan struct{})
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, err := s.Properties.Create(ctx, &core.Property{
+				OwnerID: ownerID,
+				Title:   fmt.Sprintf("listing %d", i),
+				Images:  []core.PropertyImage{{URL: fmt.Sprintf("/uploads/%d.jpg", i)}},
+			}, service.CreateOptions{Promote: i%2 == 0})
+			var limitErr *service.ListingLimitError
+			switch {
+			case err == nil:
+				mu.Lock()
+				created++
+				mu.Unlock()
+			case !errors.As(err, &limitErr):
+				t.Errorf("listing %d: %v", i, err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	usage, err := s.Plans.Usage(ctx, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.ActiveListings != created {
+		t.Errorf("stored %d listings, %d requests succeeded", usage.ActiveListings, created)
+	}
+	return created
+}
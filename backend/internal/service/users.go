@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/auth"
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+	"gofuckbiz/snimayprosto-rent-easy/internal/telemetry"
+)
+
+type UserService struct {
+	Users UserRepository
+	Audit *AuditService // optional
+}
+
+func NewUserService(users UserRepository) *UserService {
+	return &UserService{Users: users}
+}
+
+// Register creates an account with the default "user" role.
+func (s *UserService) Register(ctx context.Context, email, password, name string) (*core.User, error) {
+	if _, err := s.Users.ByEmail(ctx, email); err == nil {
+		return nil, ErrEmailTaken
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	u := &core.User{
+		Email:        email,
+		PasswordHash: hash,
+		Name:         strings.TrimSpace(name),
+		Role:         "user",
+	}
+	if err := s.Users.Create(ctx, u); err != nil {
+		if errors.Is(err, ErrConflict) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+	telemetry.Registrations.Inc()
+	return u, nil
+}
+
+// Authenticate checks the password and returns the user. Every attempt
+// goes to the audit log.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (*core.User, error) {
+	u, err := s.Users.ByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.Audit.Record(ctx, core.AuditEvent{
+				Action:  core.AuditLoginFailure,
+				Details: core.AuditDetails{"email": email, "reason": "unknown_email"},
+			})
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if err := auth.CheckPassword(u.PasswordHash, password); err != nil {
+		s.Audit.Record(ctx, core.AuditEvent{
+			Action:     core.AuditLoginFailure,
+			TargetType: "user",
+			TargetID:   ref(u.ID),
+			Details:    core.AuditDetails{"email": email, "reason": "wrong_password"},
+		})
+		return nil, ErrInvalidCredentials
+	}
+	s.Audit.Record(ctx, core.AuditEvent{
+		ActorID:    ref(u.ID),
+		Action:     core.AuditLoginSuccess,
+		TargetType: "user",
+		TargetID:   ref(u.ID),
+	})
+	return u, nil
+}
+
+func (s *UserService) Get(ctx context.Context, id uint) (*core.User, error) {
+	return s.Users.ByID(ctx, id)
+}
+
+// UpdateRole sets the user's role and records the change.
+func (s *UserService) UpdateRole(ctx context.Context, id uint, role string) error {
+	u, err := s.Users.ByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Users.UpdateRole(ctx, id, role); err != nil {
+		return err
+	}
+	if u.Role != role {
+		s.Audit.Record(ctx, core.AuditEvent{
+			ActorID:    ref(id),
+			Action:     core.AuditRoleChange,
+			TargetType: "user",
+			TargetID:   ref(id),
+			Details:    core.AuditDetails{"from": u.Role, "to": role},
+		})
+	}
+	return nil
+}
+
+// HasRole reports whether the user has one of roles.
+func (s *UserService) HasRole(ctx context.Context, id uint, roles ...string) (bool, error) {
+	u, err := s.Users.ByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if u.Role == r {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *UserService) Count(ctx context.Context) (int64, error) {
+	return s.Users.Count(ctx)
+}
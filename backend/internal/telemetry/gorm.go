@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// GormPlugin times every statement into DBQueries and wraps it in a span,
+// a child of the request span when the query runs with a request context.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string { return "telemetry" }
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("telemetry:before_create", before("create")),
+		cb.Create().After("gorm:create").Register("telemetry:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("telemetry:before_query", before("select")),
+		cb.Query().After("gorm:query").Register("telemetry:after_query", after("select")),
+		cb.Update().Before("gorm:update").Register("telemetry:before_update", before("update")),
+		cb.Update().After("gorm:update").Register("telemetry:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("telemetry:before_delete", before("delete")),
+		cb.Delete().After("gorm:delete").Register("telemetry:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("telemetry:before_row", before("row")),
+		cb.Row().After("gorm:row").Register("telemetry:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("telemetry:before_raw", before("raw")),
+		cb.Raw().After("gorm:raw").Register("telemetry:after_raw", after("raw")),
+	)
+}
+
+const (
+	spanKey  = "telemetry:span"
+	startKey = "telemetry:start"
+)
+
+func before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := Tracer().Start(db.Statement.Context, "db."+op, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+		db.InstanceSet(startKey, time.Now())
+	}
+}
+
+func after(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		status := "ok"
+		err := db.Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			status = "error"
+		}
+		if v, ok := db.InstanceGet(startKey); ok {
+			DBQueries.WithLabelValues(op, table, status).Observe(time.Since(v.(time.Time)).Seconds())
+		}
+
+		v, ok := db.InstanceGet(spanKey)
+		if !ok {
+			return
+		}
+		span := v.(trace.Span)
+		if span.IsRecording() {
+			// the statement has placeholders, not the values
+			span.SetAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.operation", op),
+				attribute.String("db.sql.table", table),
+				attribute.String("db.statement", db.Statement.SQL.String()),
+				attribute.Int64("db.rows_affected", db.RowsAffected),
+			)
+			if status == "error" {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/core"
+)
+
+func TestGormPlugin(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	// dry run builds the SQL and runs the callbacks without a server
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	before := selectsOnUsers(t)
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	var users []core.User
+	db.WithContext(ctx).Where("role = ?", "admin").Find(&users)
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 || spans[0].Name() != "db.select" || spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("spans = %v", spans)
+	}
+
+	if got := selectsOnUsers(t) - before; got != 1 {
+		t.Fatalf("select on users observed %d times", got)
+	}
+}
+
+// selectsOnUsers reads the query histogram of Registry, which other tests
+// and earlier runs (-count) add to as well.
+func selectsOnUsers(t *testing.T) uint64 {
+	t.Helper()
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != "snimayprosto_db_query_duration_seconds" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["operation"] == "select" && labels["table"] == "users" && labels["status"] == "ok" {
+				return m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
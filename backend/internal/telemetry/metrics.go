@@ -0,0 +1,90 @@
+// Package telemetry holds the Prometheus metrics and the OpenTelemetry
+// tracing setup. Metrics are package-level, like the default Prometheus
+// registry, so every part of the app counts into the same series.
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "snimayprosto"
+
+// Registry holds every metric below plus the Go runtime and process ones.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being served, including open streams.",
+	})
+
+	DBQueries = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM statement latency by operation, table and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	ChatConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chat_connections",
+		Help:      "Open chat WebSocket connections.",
+	})
+
+	ChatRooms = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chat_rooms",
+		Help:      "Conversations with at least one open chat socket.",
+	})
+
+	Registrations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Accounts registered.",
+	})
+
+	ListingsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "listings_created_total",
+		Help:      "Listings created.",
+	})
+
+	Promotions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "promotions_total",
+		Help:      "Listing promotions started.",
+	})
+
+	PlanUpgrades = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "plan_upgrades_total",
+		Help:      "Plan upgrades by the plan bought.",
+	}, []string{"plan"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPInFlight, DBQueries,
+		ChatConnections, ChatRooms,
+		Registrations, ListingsCreated, Promotions, PlanUpgrades,
+	)
+}
+
+// Handler serves Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
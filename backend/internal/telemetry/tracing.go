@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/config"
+)
+
+// instrumentation is the name tracers are created under.
+const instrumentation = "gofuckbiz/snimayprosto-rent-easy"
+
+// Tracer returns the tracer of the globally installed provider. Until
+// SetupTracing runs, spans are no-ops.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// SetupTracing installs the global tracer provider and W3C trace context
+// propagation. With the "none" exporter spans still get IDs, which end up
+// in the logs, but are not sent anywhere. "otlp" reads the standard
+// OTEL_EXPORTER_OTLP_* variables. The returned function flushes pending
+// spans on shutdown.
+func SetupTracing(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", cfg.Tracing.ServiceName),
+			attribute.String("deployment.environment", cfg.AppEnv),
+		)),
+	}
+	switch cfg.Tracing.Exporter {
+	case "none":
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case "otlp":
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Tracing.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,83 @@
+// Package testdb gives tests a migrated Postgres schema of their own.
+// Tests using it are skipped unless TEST_DATABASE_DSN points at a server
+// the tests may create schemas on.
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gofuckbiz/snimayprosto-rent-easy/internal/migrate"
+)
+
+const EnvDSN = "TEST_DATABASE_DSN"
+
+// Open creates a throwaway schema, applies every migration to it and
+// returns a connection pool whose search_path points there. The schema is
+// dropped when the test ends.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(EnvDSN)
+	if dsn == "" {
+		t.Skipf("%s not set", EnvDSN)
+	}
+	cfg := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+
+	admin, err := gorm.Open(postgres.Open(dsn), cfg)
+	if err != nil {
+		t.Fatalf("testdb: connect: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d_%d", time.Now().UnixNano(), rand.Intn(1000))
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("testdb: create schema: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(WithSearchPath(dsn, schema)), cfg)
+	if err != nil {
+		t.Fatalf("testdb: connect to schema: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if a, err := admin.DB(); err == nil {
+			a.Close()
+		}
+	})
+
+	m, err := migrate.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("testdb: %v", err)
+	}
+	return db
+}
+
+// WithSearchPath adds search_path to a URL or keyword/value DSN.
+func WithSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
@@ -13,7 +13,7 @@ import {
   Mail,
   Heart
 } from "lucide-react";
-import { BACKEND_URL } from "@/lib/api";
+import { API_URL, BACKEND_URL, csrfHeaders } from "@/lib/api";
 
 interface Message {
   id: number;
@@ -21,6 +21,8 @@ interface Message {
   content: string;
   type: string;
   createdAt: string;
+  editedAt?: string | null;
+  deletedAt?: string | null;
 }
 
 interface ChatModalProps {
@@ -86,11 +88,13 @@ const ChatModal = ({
       const token = localStorage.getItem('accessToken');
       console.log("Token exists:", !!token);
       
-      const response = await fetch(`${BACKEND_URL}/chat/start/${propertyId}`, {
+      const response = await fetch(`${API_URL}/chat/start/${propertyId}`, {
         method: 'POST',
+        credentials: 'include',
         headers: {
           'Authorization': `Bearer ${token}`,
-          'Content-Type': 'application/json'
+          'Content-Type': 'application/json',
+          ...(await csrfHeaders()),
         }
       });
       
@@ -108,7 +112,7 @@ const ChatModal = ({
       
       // Load messages
       console.log("Loading messages for conversation:", conv.id);
-      const messagesResponse = await fetch(`${BACKEND_URL}/chat/${conv.id}/messages`, {
+      const messagesResponse = await fetch(`${API_URL}/chat/${conv.id}/messages`, {
         headers: { 'Authorization': `Bearer ${token}` }
       });
       
@@ -125,7 +129,7 @@ const ChatModal = ({
       
       // Connect WebSocket
       console.log("Connecting to WebSocket...");
-      const wsUrl = `${BACKEND_URL.replace('http', 'ws')}/ws/chat/${conv.id}?token=${encodeURIComponent(token || '')}`;
+      const wsUrl = `${API_URL.replace('http', 'ws')}/ws/chat/${conv.id}?token=${encodeURIComponent(token || '')}`;
       console.log("WebSocket URL:", wsUrl);
       
       const ws = new WebSocket(wsUrl);
@@ -139,7 +143,11 @@ const ChatModal = ({
         try {
           const msg = JSON.parse(event.data);
           console.log("Parsed message:", msg);
-          setMessages(prev => [...prev, msg]);
+          if (msg.event === 'message.updated' || msg.event === 'message.deleted') {
+            setMessages(prev => prev.map(m => (m.id === msg.id ? { ...m, ...msg } : m)));
+          } else {
+            setMessages(prev => [...prev, msg]);
+          }
         } catch (error) {
           console.error('Failed to parse WebSocket message:', error);
         }
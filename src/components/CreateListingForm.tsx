@@ -1,4 +1,5 @@
 import { useState } from "react";
+import { useQuery } from "@tanstack/react-query";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
@@ -8,7 +9,7 @@ import { Checkbox } from "@/components/ui/checkbox";
 import { Badge } from "@/components/ui/badge";
 import { Separator } from "@/components/ui/separator";
 import { X, Upload, MapPin, Home, Bed, RussianRuble as Ruble, Phone, Mail, Star, Eye, Save, Image as ImageIcon, Trash2, Plus } from "lucide-react";
-import { createProperty, uploadPropertyImages } from "@/lib/api";
+import { createProperty, listAmenities, uploadPropertyImages, type ContactVisibility } from "@/lib/api";
 import { useToast } from "@/hooks/use-toast";
 import { useAuth } from "@/lib/auth-context";
 import MapComponent from "./MapComponent";
@@ -24,17 +25,42 @@ interface FormData {
   address: string;
   propertyType: string;
   rooms: string;
+  area: string;
   price: string;
   priceType: string;
   phone: string;
   email: string;
+  phoneVisibility: ContactVisibility;
+  emailVisibility: ContactVisibility;
   amenities: string[];
   isUrgent: boolean;
   visibility: string;
   latitude: number;
   longitude: number;
+  floor: string;
+  totalFloors: string;
+  deposit: string;
+  furnished: boolean;
+  petsAllowed: boolean;
+  balcony: boolean;
+  parking: boolean;
+  utilitiesIncluded: boolean;
 }
 
+const attributeOptions: Array<{ field: "furnished" | "petsAllowed" | "balcony" | "parking" | "utilitiesIncluded"; label: string }> = [
+  { field: "furnished", label: "С мебелью" },
+  { field: "petsAllowed", label: "Можно с животными" },
+  { field: "balcony", label: "Балкон" },
+  { field: "parking", label: "Парковка" },
+  { field: "utilitiesIncluded", label: "Коммунальные включены" },
+];
+
+const contactVisibilityOptions: Array<{ value: ContactVisibility; label: string }> = [
+  { value: "public", label: "Показывать всем" },
+  { value: "on_request", label: "По запросу" },
+  { value: "hidden", label: "Скрыть" },
+];
+
 interface Photo {
   id: string;
   file: File;
@@ -50,13 +76,24 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
     address: "",
     propertyType: "",
     rooms: "",
+    area: "",
     price: "",
     priceType: "month",
     phone: "",
     email: "",
+    phoneVisibility: "on_request",
+    emailVisibility: "on_request",
     amenities: [],
     isUrgent: false,
     visibility: "public",
+    floor: "",
+    totalFloors: "",
+    deposit: "",
+    furnished: false,
+    petsAllowed: false,
+    balcony: false,
+    parking: false,
+    utilitiesIncluded: false,
     latitude: 0,
     longitude: 0
   });
@@ -66,10 +103,12 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
   const { toast } = useToast();
   const { user } = useAuth();
 
-  const amenitiesList = [
-    "Балкон", "Мебель", "Холодильник", "Стиральная машина", 
-    "Интернет", "Парковка", "Лифт", "Кондиционер", "Посудомоечная машина"
-  ];
+  const { data: amenityCatalog } = useQuery({
+    queryKey: ["amenities"],
+    queryFn: listAmenities,
+    staleTime: 60 * 60 * 1000,
+  });
+  const amenitiesList = amenityCatalog?.items ?? [];
 
   const propertyTypes = [
     { value: "apartment", label: "Квартира" },
@@ -79,12 +118,12 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
   ];
 
   const roomOptions = [
-    { value: "studio", label: "Студия" },
+    { value: "0", label: "Студия" },
     { value: "1", label: "1 комната" },
     { value: "2", label: "2 комнаты" },
     { value: "3", label: "3 комнаты" },
     { value: "4", label: "4 комнаты" },
-    { value: "5+", label: "5+ комнат" }
+    { value: "5", label: "5+ комнат" }
   ];
 
   const handleInputChange = (field: keyof FormData, value: string | boolean | string[] | number) => {
@@ -153,9 +192,13 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
     if (!formData.address.trim()) newErrors.address = "Адрес обязателен";
     if (!formData.propertyType) newErrors.propertyType = "Выберите тип недвижимости";
     if (!formData.rooms) newErrors.rooms = "Укажите количество комнат";
+    if (!formData.area || parseInt(formData.area, 10) <= 0) newErrors.area = "Укажите площадь";
     if (!formData.price || parseFloat(formData.price) <= 0) newErrors.price = "Укажите корректную цену";
     if (!formData.latitude || !formData.longitude) newErrors.address = "Укажите точное местоположение на карте";
     if (!formData.phone.trim()) newErrors.phone = "Телефон обязателен";
+    if (formData.floor && formData.totalFloors && parseInt(formData.floor, 10) > parseInt(formData.totalFloors, 10)) {
+      newErrors.floor = "Этаж не может быть выше этажности дома";
+    }
     if (photos.length === 0) newErrors.photos = "Добавьте хотя бы одну фотографию";
 
     // Phone validation
@@ -183,7 +226,7 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
     }
     if (!validateForm()) {
       // move user to first step containing error
-      if (!formData.title || !formData.propertyType || !formData.rooms || !formData.price) {
+      if (!formData.title || !formData.propertyType || !formData.rooms || !formData.area || !formData.price) {
         setCurrentStep(1);
       } else if (photos.length === 0 || !formData.address) {
         setCurrentStep(2);
@@ -201,16 +244,27 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
         description: formData.description,
         address: formData.address,
         propertyType: formData.propertyType,
-        rooms: formData.rooms,
-        price: formData.price,
+        rooms: parseInt(formData.rooms, 10),
+        area: parseInt(formData.area, 10),
+        price: parseFloat(formData.price),
         priceType: formData.priceType,
         phone: formData.phone,
         email: formData.email,
+        phoneVisibility: formData.phoneVisibility,
+        emailVisibility: formData.emailVisibility,
         amenities: formData.amenities,
         isUrgent: formData.isUrgent,
         visibility: formData.visibility,
         latitude: formData.latitude,
         longitude: formData.longitude,
+        floor: formData.floor ? parseInt(formData.floor, 10) : undefined,
+        totalFloors: formData.totalFloors ? parseInt(formData.totalFloors, 10) : undefined,
+        deposit: formData.deposit ? parseFloat(formData.deposit) : undefined,
+        furnished: formData.furnished,
+        petsAllowed: formData.petsAllowed,
+        balcony: formData.balcony,
+        parking: formData.parking,
+        utilitiesIncluded: formData.utilitiesIncluded,
       };
       
       const property = await createProperty(payload);
@@ -235,6 +289,15 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
         // This would need to be passed as a prop or handled via context
         return;
       }
+      if (err?.data?.error === 'invalid_request' && Array.isArray(err.data.fields)) {
+        const fieldErrors: Record<string, string> = {};
+        for (const f of err.data.fields) {
+          const field = f.field.startsWith('amenities[') ? 'amenities' : f.field;
+          fieldErrors[field === 'latitude' || field === 'longitude' || field === 'city' ? 'address' : field] = f.message;
+        }
+        setErrors(fieldErrors);
+        setCurrentStep(fieldErrors.phone || fieldErrors.email ? 3 : fieldErrors.address ? 2 : 1);
+      }
       const message = err?.status === 401 ? "Требуется вход" : (err?.message || "Не удалось создать объявление");
       toast({ title: "Ошибка", description: message, variant: "destructive" });
     } finally {
@@ -370,6 +433,23 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
                 </div>
               </div>
 
+              {/* Area */}
+              <div className="space-y-2">
+                <Label htmlFor="area" className="text-sm font-medium">Площадь, м² *</Label>
+                <Input
+                  id="area"
+                  type="number"
+                  min={1}
+                  placeholder="Например: 45"
+                  value={formData.area}
+                  onChange={(e) => handleInputChange("area", e.target.value)}
+                  className={`hover:shadow-subtle transition-spring ${
+                    errors.area ? 'border-destructive' : ''
+                  }`}
+                />
+                {errors.area && <p className="text-sm text-destructive">{errors.area}</p>}
+              </div>
+
               {/* Price */}
               <div className="space-y-2">
                 <Label className="text-sm font-medium">Цена *</Label>
@@ -510,6 +590,16 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
                       />
                     </div>
                     {errors.phone && <p className="text-sm text-destructive">{errors.phone}</p>}
+                    <Select value={formData.phoneVisibility} onValueChange={(value) => handleInputChange("phoneVisibility", value)}>
+                      <SelectTrigger>
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent>
+                        {contactVisibilityOptions.map((o) => (
+                          <SelectItem key={o.value} value={o.value}>{o.label}</SelectItem>
+                        ))}
+                      </SelectContent>
+                    </Select>
                   </div>
 
                   <div className="space-y-2">
@@ -530,6 +620,16 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
                       />
                     </div>
                     {errors.email && <p className="text-sm text-destructive">{errors.email}</p>}
+                    <Select value={formData.emailVisibility} onValueChange={(value) => handleInputChange("emailVisibility", value)}>
+                      <SelectTrigger>
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent>
+                        {contactVisibilityOptions.map((o) => (
+                          <SelectItem key={o.value} value={o.value}>{o.label}</SelectItem>
+                        ))}
+                      </SelectContent>
+                    </Select>
                   </div>
                 </div>
               </div>
@@ -541,15 +641,74 @@ const CreateListingForm = ({ isOpen, onClose }: CreateListingFormProps) => {
                 <h4 className="font-medium">Удобства</h4>
                 <div className="grid grid-cols-2 md:grid-cols-3 gap-3">
                   {amenitiesList.map((amenity) => (
-                    <div key={amenity} className="flex items-center space-x-2">
+                    <div key={amenity.code} className="flex items-center space-x-2">
+                      <Checkbox
+                        id={`amenity-${amenity.code}`}
+                        checked={formData.amenities.includes(amenity.code)}
+                        onCheckedChange={() => toggleAmenity(amenity.code)}
+                        className="hover:scale-110 transition-spring"
+                      />
+                      <Label htmlFor={`amenity-${amenity.code}`} className="text-sm cursor-pointer">
+                        {amenity.label}
+                      </Label>
+                    </div>
+                  ))}
+                </div>
+                {errors.amenities && <p className="text-sm text-destructive">{errors.amenities}</p>}
+              </div>
+
+              <Separator />
+
+              {/* Attributes */}
+              <div className="space-y-4">
+                <h4 className="font-medium">Условия и характеристики</h4>
+                <div className="grid grid-cols-1 md:grid-cols-3 gap-4">
+                  <div className="space-y-2">
+                    <Label htmlFor="floor" className="text-sm font-medium">Этаж</Label>
+                    <Input
+                      id="floor"
+                      type="number"
+                      value={formData.floor}
+                      onChange={(e) => handleInputChange("floor", e.target.value)}
+                      className={`hover:shadow-subtle transition-spring ${errors.floor ? 'border-destructive' : ''}`}
+                    />
+                    {errors.floor && <p className="text-sm text-destructive">{errors.floor}</p>}
+                  </div>
+                  <div className="space-y-2">
+                    <Label htmlFor="totalFloors" className="text-sm font-medium">Этажей в доме</Label>
+                    <Input
+                      id="totalFloors"
+                      type="number"
+                      min={1}
+                      value={formData.totalFloors}
+                      onChange={(e) => handleInputChange("totalFloors", e.target.value)}
+                      className="hover:shadow-subtle transition-spring"
+                    />
+                  </div>
+                  <div className="space-y-2">
+                    <Label htmlFor="deposit" className="text-sm font-medium">Залог, ₽</Label>
+                    <Input
+                      id="deposit"
+                      type="number"
+                      min={0}
+                      value={formData.deposit}
+                      onChange={(e) => handleInputChange("deposit", e.target.value)}
+                      className={`hover:shadow-subtle transition-spring ${errors.deposit ? 'border-destructive' : ''}`}
+                    />
+                    {errors.deposit && <p className="text-sm text-destructive">{errors.deposit}</p>}
+                  </div>
+                </div>
+                <div className="grid grid-cols-2 md:grid-cols-3 gap-3">
+                  {attributeOptions.map(({ field, label }) => (
+                    <div key={field} className="flex items-center space-x-2">
                       <Checkbox
-                        id={amenity}
-                        checked={formData.amenities.includes(amenity)}
-                        onCheckedChange={() => toggleAmenity(amenity)}
+                        id={field}
+                        checked={formData[field]}
+                        onCheckedChange={(checked) => handleInputChange(field, checked === true)}
                         className="hover:scale-110 transition-spring"
                       />
-                      <Label htmlFor={amenity} className="text-sm cursor-pointer">
-                        {amenity}
+                      <Label htmlFor={field} className="text-sm cursor-pointer">
+                        {label}
                       </Label>
                     </div>
                   ))}
@@ -0,0 +1,110 @@
+import { useQuery } from "@tanstack/react-query";
+import { Button } from "@/components/ui/button";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Separator } from "@/components/ui/separator";
+import { X, Eye, Phone, Heart, MessageCircle, Flame } from "lucide-react";
+import { getAnalytics, type AnalyticsCounters } from "@/lib/api";
+
+interface ListingAnalyticsModalProps {
+  isOpen: boolean;
+  onClose: () => void;
+  propertyId?: number; // all listings when missing
+  title: string;
+}
+
+const metrics: Array<{ key: keyof AnalyticsCounters; label: string; icon: JSX.Element }> = [
+  { key: "views", label: "Просмотры", icon: <Eye className="h-4 w-4" /> },
+  { key: "reveals", label: "Показы контактов", icon: <Phone className="h-4 w-4" /> },
+  { key: "favorites", label: "В избранном", icon: <Heart className="h-4 w-4" /> },
+  { key: "conversations", label: "Новые чаты", icon: <MessageCircle className="h-4 w-4" /> },
+];
+
+const ListingAnalyticsModal = ({ isOpen, onClose, propertyId, title }: ListingAnalyticsModalProps) => {
+  const { data: report, isLoading, error } = useQuery({
+    queryKey: ["analytics", propertyId ?? "account"],
+    queryFn: () => getAnalytics(propertyId),
+    enabled: isOpen,
+  });
+
+  if (!isOpen) return null;
+
+  const maxViews = Math.max(1, ...(report?.series || []).map((d) => d.views));
+  const perDay = (n: number) => n.toLocaleString("ru-RU", { maximumFractionDigits: 1 });
+
+  return (
+    <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/60 backdrop-blur-md p-4">
+      <Card className="w-full max-w-2xl shadow-elegant border-0 animate-scale-in">
+        <CardHeader className="relative">
+          <Button
+            variant="ghost"
+            size="sm"
+            onClick={onClose}
+            className="absolute top-4 right-4 h-8 w-8 p-0 rounded-full"
+          >
+            <X className="h-4 w-4" />
+          </Button>
+          <CardTitle className="text-2xl font-bold">Статистика</CardTitle>
+          <CardDescription>
+            {title}
+            {report && ` · ${new Date(report.from).toLocaleDateString("ru-RU")} — ${new Date(report.to).toLocaleDateString("ru-RU")}`}
+          </CardDescription>
+        </CardHeader>
+
+        <CardContent className="space-y-6">
+          {isLoading && <p className="text-muted-foreground">Загрузка...</p>}
+          {error && <p className="text-destructive">{(error as Error).message}</p>}
+          {report && (
+            <>
+              <div className="grid grid-cols-2 md:grid-cols-4 gap-3">
+                {metrics.map((m) => (
+                  <div key={m.key} className="p-3 rounded-lg bg-muted/30">
+                    <div className="flex items-center text-muted-foreground text-xs mb-1">
+                      {m.icon}
+                      <span className="ml-1">{m.label}</span>
+                    </div>
+                    <div className="text-2xl font-bold">{report.totals[m.key]}</div>
+                  </div>
+                ))}
+              </div>
+
+              {/* Views by day; promoted days are orange */}
+              <div className="flex items-end h-24 gap-px">
+                {report.series.map((d) => (
+                  <div
+                    key={d.date}
+                    title={`${new Date(d.date).toLocaleDateString("ru-RU")}: ${d.views}`}
+                    className={`flex-1 rounded-t ${d.promoted > 0 ? "bg-orange-400" : "bg-primary/60"}`}
+                    style={{ height: `${Math.max(2, (d.views / maxViews) * 100)}%` }}
+                  />
+                ))}
+              </div>
+
+              <Separator />
+
+              <div>
+                <h3 className="font-semibold mb-3 flex items-center">
+                  <Flame className="h-4 w-4 mr-2 text-orange-500" />
+                  С продвижением и без (в среднем за день)
+                </h3>
+                <div className="grid grid-cols-3 gap-2 text-sm">
+                  <div />
+                  <div className="font-medium">С продвижением</div>
+                  <div className="font-medium">Без продвижения</div>
+                  {metrics.map((m) => (
+                    <div key={m.key} className="contents">
+                      <div className="text-muted-foreground">{m.label}</div>
+                      <div>{report.promoted.days ? perDay(report.promoted.perDay[m.key]) : "—"}</div>
+                      <div>{report.regular.days ? perDay(report.regular.perDay[m.key]) : "—"}</div>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            </>
+          )}
+        </CardContent>
+      </Card>
+    </div>
+  );
+};
+
+export default ListingAnalyticsModal;
@@ -15,7 +15,7 @@ import {
   Square,
   ChevronLeft
 } from "lucide-react";
-import { BACKEND_URL, listConversations, listMessages } from "@/lib/api";
+import { API_URL, listConversations, listMessages } from "@/lib/api";
 import { useAuth } from "@/lib/auth-context";
 import { authService } from "@/lib/auth-service";
 
@@ -107,7 +107,7 @@ const MessagesModal = ({ isOpen, onClose }: MessagesModalProps) => {
     }
 
     const token = authService.getAccessToken(); // Get current access token from AuthService
-    const wsUrl = `${BACKEND_URL.replace('http', 'ws')}/ws/chat/${conversationId}?token=${encodeURIComponent(token || '')}`;
+    const wsUrl = `${API_URL.replace('http', 'ws')}/ws/chat/${conversationId}?token=${encodeURIComponent(token || '')}`;
     const ws = new WebSocket(wsUrl);
     
     ws.onopen = () => {
@@ -25,6 +25,7 @@ import Header from "./Header";
 import Footer from "./Footer";
 import PlanUpgradeModal from "./PlanUpgradeModal";
 import PromoteModal from "./PromoteModal";
+import ListingAnalyticsModal from "./ListingAnalyticsModal";
 import CreateListingForm from "./CreateListingForm";
 
 interface PropertyWithPromotion {
@@ -37,7 +38,9 @@ interface PropertyWithPromotion {
   createdAt: string;
   images: Array<{ url: string; order: number }>;
   isPromoted: boolean;
+  isHighlighted: boolean;
   promotionExpiresAt?: string;
+  contactReveals: number;
 }
 
 const MyListingsPage = () => {
@@ -48,6 +51,7 @@ const MyListingsPage = () => {
   const [createListingOpen, setCreateListingOpen] = useState(false);
   const [selectedPropertyId, setSelectedPropertyId] = useState<number | null>(null);
   const [selectedPropertyTitle, setSelectedPropertyTitle] = useState<string>("");
+  const [analyticsFor, setAnalyticsFor] = useState<{ id?: number; title: string } | null>(null);
 
   const { data: planData, refetch: refetchPlan } = useQuery({
     queryKey: ['myPlan'],
@@ -129,14 +133,22 @@ const MyListingsPage = () => {
             <h1 className="text-3xl font-bold mb-2">Мои объявления</h1>
             <p className="text-muted-foreground">Управляйте своими объявлениями и тарифным планом</p>
           </div>
-          <Button
-            onClick={handleCreateListing}
-            className="bg-gradient-primary hover:shadow-elegant hover:scale-105 transition-spring"
-            size="lg"
-          >
-            <Plus className="h-4 w-4 mr-2" />
-            Создать объявление
-          </Button>
+          <div className="flex space-x-2">
+            {listings.length > 0 && (
+              <Button variant="outline" size="lg" onClick={() => setAnalyticsFor({ title: "Все объявления" })}>
+                <TrendingUp className="h-4 w-4 mr-2" />
+                Статистика
+              </Button>
+            )}
+            <Button
+              onClick={handleCreateListing}
+              className="bg-gradient-primary hover:shadow-elegant hover:scale-105 transition-spring"
+              size="lg"
+            >
+              <Plus className="h-4 w-4 mr-2" />
+              Создать объявление
+            </Button>
+          </div>
         </div>
 
         {/* Plan Status */}
@@ -258,6 +270,7 @@ const MyListingsPage = () => {
                         <span>
                           Продвижение до {new Date(listing.promotionExpiresAt).toLocaleDateString('ru-RU')}
                         </span>
+                        {listing.isHighlighted && <span className="ml-2 font-semibold text-red-700">⚡ Срочно</span>}
                       </div>
                     </div>
                   )}
@@ -271,22 +284,25 @@ const MyListingsPage = () => {
                         <Edit className="h-3 w-3 mr-1" />
                         Редактировать
                       </Button>
-                      <Button variant="outline" size="sm" className="flex-1">
+                      <Button
+                        variant="outline"
+                        size="sm"
+                        className="flex-1"
+                        onClick={() => setAnalyticsFor({ id: listing.id, title: listing.title })}
+                      >
                         <Eye className="h-3 w-3 mr-1" />
                         Просмотры
                       </Button>
                     </div>
                     
-                    {!listing.isPromoted && (
-                      <Button
-                        onClick={() => handlePromote(listing.id, listing.title)}
-                        className="w-full bg-gradient-to-r from-orange-500 to-red-500 hover:from-orange-600 hover:to-red-600 text-white hover:shadow-lg transition-all"
-                        size="sm"
-                      >
-                        <Flame className="h-3 w-3 mr-1" />
-                        Продвинуть в топ
-                      </Button>
-                    )}
+                    <Button
+                      onClick={() => handlePromote(listing.id, listing.title)}
+                      className="w-full bg-gradient-to-r from-orange-500 to-red-500 hover:from-orange-600 hover:to-red-600 text-white hover:shadow-lg transition-all"
+                      size="sm"
+                    >
+                      <Flame className="h-3 w-3 mr-1" />
+                      {listing.isPromoted ? 'Продлить продвижение' : 'Продвинуть в топ'}
+                    </Button>
                     
                     <Button variant="ghost" size="sm" className="w-full text-destructive hover:text-destructive hover:bg-destructive/10">
                       <Trash2 className="h-3 w-3 mr-1" />
@@ -356,6 +372,13 @@ const MyListingsPage = () => {
         }}
       />
 
+      <ListingAnalyticsModal
+        isOpen={!!analyticsFor}
+        onClose={() => setAnalyticsFor(null)}
+        propertyId={analyticsFor?.id}
+        title={analyticsFor?.title || ""}
+      />
+
       <CreateListingForm
         isOpen={createListingOpen}
         onClose={() => setCreateListingOpen(false)}
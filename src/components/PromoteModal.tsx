@@ -1,7 +1,10 @@
 import { useState } from "react";
+import { useQuery } from "@tanstack/react-query";
 import { Button } from "@/components/ui/button";
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
 import { Badge } from "@/components/ui/badge";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
 import { Separator } from "@/components/ui/separator";
 import { 
   X, 
@@ -10,11 +13,11 @@ import {
   Eye, 
   Star,
   CreditCard,
-  Shield,
   Clock,
-  Target
+  Target,
+  Zap
 } from "lucide-react";
-import { promoteProperty } from "@/lib/api";
+import { getMyPlan, getPromotions, listPromotionProducts, promoteProperty } from "@/lib/api";
 import { useToast } from "@/hooks/use-toast";
 
 interface PromoteModalProps {
@@ -33,8 +36,30 @@ const PromoteModal = ({
   onPromoteSuccess 
 }: PromoteModalProps) => {
   const [isLoading, setIsLoading] = useState(false);
+  const [product, setProduct] = useState("top_city_7d");
+  const [startDate, setStartDate] = useState(""); // YYYY-MM-DD; now or after the running one when empty
   const { toast } = useToast();
 
+  const { data: products } = useQuery({
+    queryKey: ["promotionProducts"],
+    queryFn: listPromotionProducts,
+    enabled: isOpen,
+  });
+  const { data: plan, refetch: refetchPlan } = useQuery({
+    queryKey: ["myPlan"],
+    queryFn: getMyPlan,
+    enabled: isOpen,
+  });
+  const { data: history, refetch: refetchHistory } = useQuery({
+    queryKey: ["promotions", propertyId],
+    queryFn: () => getPromotions(propertyId),
+    enabled: isOpen && propertyId > 0,
+  });
+
+  const selected = products?.items.find((p) => p.code === product);
+  const creditsLeft = plan ? plan.promotionCredits - plan.promotionCreditsUsed : undefined;
+  const statusLabels = { scheduled: "Запланировано", active: "Активно", expired: "Завершено" };
+
   const benefits = [
     {
       icon: <TrendingUp className="h-5 w-5" />,
@@ -61,11 +86,16 @@ const PromoteModal = ({
   const handlePromote = async () => {
     setIsLoading(true);
     try {
-      await promoteProperty(propertyId);
+      await promoteProperty(propertyId, {
+        product,
+        startsAt: startDate ? new Date(`${startDate}T00:00:00`).toISOString() : undefined,
+      });
       toast({
-        title: "Объявление продвинуто!",
-        description: "Ваше объявление теперь показывается первым в поиске",
+        title: startDate ? "Продвижение запланировано" : "Объявление продвинуто!",
+        description: selected?.label,
       });
+      refetchPlan();
+      refetchHistory();
       onPromoteSuccess();
       onClose();
     } catch (error: any) {
@@ -134,25 +164,74 @@ const PromoteModal = ({
 
           <Separator />
 
-          {/* Pricing */}
-          <div className="text-center space-y-4">
-            <div className="p-6 bg-gradient-to-br from-orange-50 to-red-50 rounded-xl border border-orange-200">
-              <div className="flex items-center justify-center space-x-2 mb-2">
-                <Clock className="h-5 w-5 text-orange-600" />
-                <span className="text-sm font-medium text-orange-800">Продвижение на 7 дней</span>
-              </div>
-              <div className="text-4xl font-bold text-orange-900 mb-2">299 ₽</div>
-              <p className="text-sm text-orange-700">
-                Специальная цена для первого продвижения
-              </p>
+          {/* Products */}
+          <div className="space-y-4">
+            <div className="flex items-center justify-between">
+              <h3 className="text-xl font-semibold flex items-center">
+                <Clock className="h-5 w-5 mr-2 text-orange-600" />
+                Вариант продвижения
+              </h3>
+              {creditsLeft !== undefined && (
+                <Badge variant="secondary">Кредитов в этом месяце: {creditsLeft} из {plan?.promotionCredits}</Badge>
+              )}
             </div>
-
-            <div className="flex items-center justify-center space-x-2 text-green-600">
-              <Shield className="h-4 w-4" />
-              <span className="text-sm font-medium">Гарантия возврата средств</span>
+            <div className="grid grid-cols-1 md:grid-cols-2 gap-3">
+              {products?.items.map((p) => (
+                <button
+                  key={p.code}
+                  type="button"
+                  onClick={() => setProduct(p.code)}
+                  className={`text-left p-4 rounded-xl border transition-colors ${
+                    p.code === product
+                      ? "border-orange-400 bg-gradient-to-br from-orange-50 to-red-50"
+                      : "border-border hover:bg-muted/50"
+                  }`}
+                >
+                  <div className="flex items-center font-medium">
+                    {p.tier === "urgent" ? <Zap className="h-4 w-4 mr-2 text-red-500" /> : <TrendingUp className="h-4 w-4 mr-2 text-orange-500" />}
+                    {p.label}
+                  </div>
+                  <div className="text-sm text-muted-foreground mt-1">{p.credits} кредитов</div>
+                </button>
+              ))}
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="promotion-start">Начало (необязательно)</Label>
+              <Input
+                id="promotion-start"
+                type="date"
+                value={startDate}
+                min={new Date().toISOString().slice(0, 10)}
+                onChange={(e) => setStartDate(e.target.value)}
+              />
+              <p className="text-xs text-muted-foreground">
+                Без даты продвижение начнётся сразу или продлит действующее
+              </p>
             </div>
           </div>
 
+          {history && history.items.length > 0 && (
+            <>
+              <Separator />
+              <div>
+                <h3 className="font-semibold mb-3">История продвижения</h3>
+                <div className="space-y-2 text-sm max-h-40 overflow-y-auto">
+                  {history.items.map((h) => (
+                    <div key={h.id} className="flex items-center justify-between p-2 rounded-lg bg-muted/30">
+                      <span>
+                        {products?.items.find((p) => p.code === h.product)?.label ?? h.product}
+                        <span className="text-muted-foreground ml-2">
+                          {new Date(h.startsAt).toLocaleDateString("ru-RU")} — {new Date(h.expiresAt).toLocaleDateString("ru-RU")}
+                        </span>
+                      </span>
+                      <Badge variant={h.status === "active" ? "default" : "outline"}>{statusLabels[h.status]}</Badge>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            </>
+          )}
+
           <Separator />
 
           {/* Action Buttons */}
@@ -167,19 +246,19 @@ const PromoteModal = ({
             </Button>
             <Button
               onClick={handlePromote}
-              disabled={isLoading}
+              disabled={isLoading || !selected || (creditsLeft !== undefined && selected.credits > creditsLeft)}
               className="flex-1 bg-gradient-to-r from-orange-500 to-red-500 hover:from-orange-600 hover:to-red-600 text-white hover:shadow-elegant"
               size="lg"
             >
               <CreditCard className="h-4 w-4 mr-2" />
-              {isLoading ? 'Обработка...' : 'Оплатить и продвинуть'}
+              {isLoading ? 'Обработка...' : selected ? `Продвинуть за ${selected.credits} кредитов` : 'Продвинуть'}
             </Button>
           </div>
 
           {/* Additional Info */}
           <div className="text-center text-xs text-muted-foreground">
-            <p>Продвижение активируется сразу после оплаты</p>
-            <p>Деньги списываются только при успешной активации</p>
+            <p>Продвижение оплачивается кредитами вашего тарифа</p>
+            <p>Кредиты обновляются в начале каждого месяца</p>
           </div>
         </CardContent>
       </Card>
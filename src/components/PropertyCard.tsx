@@ -16,6 +16,7 @@ interface PropertyCardProps {
   isNew?: boolean;
   isFavorite?: boolean;
   isPromoted?: boolean;
+  isHighlighted?: boolean; // urgent
   onPromote?: () => void;
 }
 
@@ -30,7 +31,8 @@ const PropertyCard = ({
   imageUrl,
   isNew = false,
   isFavorite = false,
-  isPromoted = false
+  isPromoted = false,
+  isHighlighted = false
 }: PropertyCardProps) => {
   const [imageError, setImageError] = useState(false);
   const [imageLoading, setImageLoading] = useState(true);
@@ -56,7 +58,7 @@ const PropertyCard = ({
       isPromoted 
         ? 'bg-gradient-to-br from-orange-50 to-red-50 border-orange-200 shadow-lg' 
         : 'bg-gradient-card border-border/30'
-    }`}>
+    } ${isHighlighted ? 'ring-2 ring-red-400' : ''}`}>
       {/* Image */}
       <div className="relative overflow-hidden">
         {!imageError && imageUrl ? (
@@ -98,6 +100,11 @@ const PropertyCard = ({
               🔥 Топ
             </Badge>
           )}
+          {isHighlighted && (
+            <Badge className="bg-red-600 text-white font-semibold shadow-lg backdrop-blur-sm">
+              ⚡ Срочно
+            </Badge>
+          )}
           {isNew && (
             <Badge className="bg-gradient-primary text-primary-foreground font-semibold shadow-lg backdrop-blur-sm">
               Новое
@@ -1,10 +1,35 @@
 import { authService } from './auth-service';
 
-const API_URL = (import.meta as any)?.env?.VITE_API_URL || "https://localhost:8080";
-export const BACKEND_URL = API_URL;
+// BACKEND_URL serves uploads; the API itself is versioned under /api/v1.
+export const BACKEND_URL = (import.meta as any)?.env?.VITE_API_URL || "https://localhost:8080";
+export const API_URL = `${BACKEND_URL}/api/v1`;
 
 type Json = Record<string, unknown>;
 
+// CSRF token from GET /auth/csrf. The backend keeps the same token in an
+// HttpOnly cookie and expects it in a header on state-changing requests.
+let csrfToken: string | null = null;
+
+export async function csrfHeaders(refresh = false): Promise<Record<string, string>> {
+  if (!csrfToken || refresh) {
+    const res = await fetch(`${API_URL}/auth/csrf`, { credentials: 'include' });
+    const data = await res.json().catch(() => ({}));
+    csrfToken = data?.csrfToken || null;
+  }
+  return csrfToken ? { 'X-CSRF-Token': csrfToken } : {};
+}
+
+function isCsrfError(status: number, data: any) {
+  return status === 403 && typeof data?.error === 'string' && data.error.startsWith('csrf_token_');
+}
+
+// Error bodies are {error: code, message, fields?, requestId?}. The code is
+// for branching, the message is already translated for the user.
+function errorMessage(data: any, fallback: string): string {
+  const message = data?.message || data?.error || fallback;
+  return typeof message === 'string' ? message : fallback;
+}
+
 export interface Property {
   id: number;
   ownerId: number;
@@ -18,13 +43,26 @@ export interface Property {
   lng: number;
   rooms: number;
   area: number;
-  amenities: string;
+  amenities: string[]; // codes of the amenity catalog
   propertyType: string;
-  phone: string;
-  email: string;
+  // only when public, or to the owner; see revealContacts
+  phone?: string;
+  email?: string;
+  phoneVisibility: ContactVisibility;
+  emailVisibility: ContactVisibility;
   isUrgent: boolean;
+  isHighlighted: boolean; // an urgent highlight runs
   visibility: string;
   createdAt: string;
+  // null when the landlord did not say
+  floor: number | null;
+  totalFloors: number | null;
+  furnished: boolean | null;
+  petsAllowed: boolean | null;
+  balcony: boolean | null;
+  parking: boolean | null;
+  deposit: number | null;
+  utilitiesIncluded: boolean | null;
   images: Array<{
     id: number;
     propertyId: number;
@@ -33,6 +71,8 @@ export interface Property {
   }>;
 }
 
+export type ContactVisibility = 'public' | 'on_request' | 'hidden';
+
 export interface Conversation {
   id: number;
   propertyId: number;
@@ -49,19 +89,22 @@ export interface Conversation {
   unreadCount: number;
 }
 
-async function request(path: string, options: RequestInit = {}) {
+async function request(path: string, options: RequestInit = {}, csrfRetry = true) {
   // Add authorization header if we have a token
   const token = authService.getAccessToken();
+  const safe = !options.method || options.method.toUpperCase() === 'GET';
+  const csrf = safe ? {} : await csrfHeaders();
   const headers = {
     'Content-Type': 'application/json',
     ...(token ? { Authorization: `Bearer ${token}` } : {}),
+    ...csrf,
     ...(options.headers || {}),
   };
 
   const res = await fetch(`${API_URL}${path}`, {
+    ...options,
     credentials: 'include', // Always include cookies
     headers,
-    ...options,
   });
   
   // Always attempt to parse JSON for successful responses
@@ -80,6 +123,12 @@ async function request(path: string, options: RequestInit = {}) {
   }
   
   if (!res.ok) {
+    // The CSRF cookie expired or was cleared: fetch a new token once
+    if (csrfRetry && isCsrfError(res.status, data)) {
+      await csrfHeaders(true);
+      return request(path, options, false);
+    }
+
     // Handle 401 errors with automatic token refresh
     if (res.status === 401 && path !== '/auth/refresh' && path !== '/auth/login' && path !== '/auth/register') {
       try {
@@ -92,13 +141,15 @@ async function request(path: string, options: RequestInit = {}) {
         const retryHeaders = {
           'Content-Type': 'application/json',
           ...(newToken ? { Authorization: `Bearer ${newToken}` } : {}),
+          ...csrf,
           ...(options.headers || {}),
+          ...(newToken ? { Authorization: `Bearer ${newToken}` } : {}),
         };
         
         const retryRes = await fetch(`${API_URL}${path}`, {
+          ...options,
           credentials: 'include',
           headers: retryHeaders,
-          ...options,
         });
         
         const retryData = retryRes.headers.get('content-type')?.includes('application/json') 
@@ -106,8 +157,7 @@ async function request(path: string, options: RequestInit = {}) {
           : undefined;
         
         if (!retryRes.ok) {
-          const message = (retryData as any)?.error || retryRes.statusText || 'Request failed';
-          const err = new Error(typeof message === 'string' ? message : 'Request failed') as Error & { status?: number; data?: any };
+          const err = new Error(errorMessage(retryData, retryRes.statusText || 'Request failed')) as Error & { status?: number; data?: any };
           err.status = retryRes.status;
           err.data = retryData;
           throw err;
@@ -124,8 +174,7 @@ async function request(path: string, options: RequestInit = {}) {
       }
     }
     
-    const message = (data as any)?.error || res.statusText || 'Request failed';
-    const err = new Error(typeof message === 'string' ? message : 'Request failed') as Error & { status?: number; data?: any };
+    const err = new Error(errorMessage(data, res.statusText || 'Request failed')) as Error & { status?: number; data?: any };
     err.status = res.status;
     err.data = data;
     throw err;
@@ -195,16 +244,28 @@ export async function createProperty(payload: {
   description?: string;
   address: string;
   propertyType: string;
-  rooms: string;
-  price: string;
+  rooms: number;
+  area: number;
+  price: number;
   priceType: string;
   phone: string;
   email?: string;
   amenities: string[];
   isUrgent: boolean;
   visibility: string;
+  city?: string;
   latitude: number;
   longitude: number;
+  floor?: number;
+  totalFloors?: number;
+  furnished?: boolean;
+  petsAllowed?: boolean;
+  balcony?: boolean;
+  parking?: boolean;
+  deposit?: number;
+  utilitiesIncluded?: boolean;
+  phoneVisibility?: ContactVisibility;
+  emailVisibility?: ContactVisibility;
 }) {
   return request('/properties', {
     method: 'POST',
@@ -213,9 +274,24 @@ export async function createProperty(payload: {
   });
 }
 
-export async function listProperties(params?: { city?: string }) {
-  const qs = params?.city ? `?city=${encodeURIComponent(params.city)}` : '';
-  return request(`/properties${qs}`);
+export async function listProperties(params?: { city?: string; amenities?: string[]; pets?: boolean; furnished?: boolean }) {
+  const qs = new URLSearchParams();
+  if (params?.city) qs.set('city', params.city);
+  if (params?.amenities?.length) qs.set('amenities', params.amenities.join(','));
+  if (params?.pets) qs.set('pets', 'true');
+  if (params?.furnished) qs.set('furnished', 'true');
+  const query = qs.toString();
+  return request(`/properties${query ? `?${query}` : ''}`);
+}
+
+export interface Amenity {
+  code: string;
+  label: string;
+  icon: string;
+}
+
+export async function listAmenities(): Promise<{ items: Amenity[] }> {
+  return request('/amenities');
 }
 
 export async function uploadPropertyImages(propertyId: number, files: File[]) {
@@ -226,24 +302,26 @@ export async function uploadPropertyImages(propertyId: number, files: File[]) {
 
   const res = await fetch(`${API_URL}/properties/${propertyId}/images`, {
     method: 'POST',
-    headers: { ...authHeaders() },
+    credentials: 'include',
+    headers: { ...authHeaders(), ...(await csrfHeaders()) },
     body: formData,
   });
 
   if (!res.ok) {
     const data = await res.json().catch(() => ({}));
-    throw new Error(data?.error || 'Failed to upload images');
+    throw new Error(errorMessage(data, 'Failed to upload images'));
   }
 
   return res.json();
 }
 
 export async function getProperty(id: string): Promise<Property> {
-  const res = await fetch(`${API_URL}/properties/${id}`);
+  // the token, when there is one, tells the view counter who is looking
+  const res = await fetch(`${API_URL}/properties/${id}`, { headers: { ...authHeaders() } });
   
   if (!res.ok) {
     const data = await res.json().catch(() => ({}));
-    throw new Error(data?.error || `Failed to fetch property: ${res.status}`);
+    throw new Error(errorMessage(data, `Failed to fetch property: ${res.status}`));
   }
   
   return res.json();
@@ -301,6 +379,9 @@ export interface MyPlanResponse {
   plan: UserPlan;
   activeListings: number;
   canCreateMore: boolean;
+  // included per calendar month, pay for promotions
+  promotionCredits: number;
+  promotionCreditsUsed: number;
 }
 
 export async function getMyPlan(): Promise<MyPlanResponse> {
@@ -323,11 +404,120 @@ export async function getMyListings() {
   });
 }
 
-export async function promoteProperty(propertyId: number) {
+export type PromotionTier = 'top_city' | 'urgent';
+
+export interface PromotionProduct {
+  code: string;
+  tier: PromotionTier;
+  days: number;
+  credits: number; // plan credits it costs
+  label: string;
+}
+
+export interface Promotion {
+  id: number;
+  propertyId: number;
+  product: string;
+  tier: PromotionTier;
+  credits: number;
+  startsAt: string;
+  expiresAt: string;
+  createdAt: string;
+  status: 'scheduled' | 'active' | 'expired';
+}
+
+export async function listPromotionProducts(): Promise<{ items: PromotionProduct[] }> {
+  return request('/promotions/products');
+}
+
+// promoteProperty buys a product with plan credits (top_city_7d by default).
+// Without startsAt a running promotion of the same tier is extended.
+export async function promoteProperty(propertyId: number, opts: { product?: string; startsAt?: string } = {}) {
   return request(`/properties/${propertyId}/promote`, {
     method: 'POST',
     headers: { ...authHeaders() },
+    body: JSON.stringify(opts),
+  });
+}
+
+export async function getPromotions(propertyId: number): Promise<{ items: Promotion[] }> {
+  return request(`/properties/${propertyId}/promotions`, {
+    headers: { ...authHeaders() },
+  });
+}
+
+// revealContacts shows the contacts the landlord did not hide. Reveals are
+// counted and rate limited; a 403 contacts_hidden means chat is the only way.
+export async function revealContacts(propertyId: number): Promise<{ phone?: string; email?: string }> {
+  return request(`/properties/${propertyId}/contacts/reveal`, {
+    method: 'POST',
+    headers: { ...authHeaders() },
+  });
+}
+
+export async function addFavorite(propertyId: number) {
+  return request(`/properties/${propertyId}/favorite`, {
+    method: 'PUT',
+    headers: { ...authHeaders() },
+  });
+}
+
+export async function removeFavorite(propertyId: number) {
+  return request(`/properties/${propertyId}/favorite`, {
+    method: 'DELETE',
+    headers: { ...authHeaders() },
   });
 }
 
+export interface AnalyticsCounters {
+  views: number;
+  reveals: number;
+  favorites: number;
+  conversations: number;
+}
+
+export interface AnalyticsPeriod {
+  days: number; // listing-days
+  totals: AnalyticsCounters;
+  perDay: AnalyticsCounters;
+}
+
+export interface AnalyticsReport {
+  from: string;
+  to: string;
+  listings: number;
+  totals: AnalyticsCounters;
+  series: Array<AnalyticsCounters & { date: string; promoted: number }>;
+  promoted: AnalyticsPeriod;
+  regular: AnalyticsPeriod;
+}
 
+// Analytics cover the last 30 days unless from/to (YYYY-MM-DD) are given;
+// without propertyId they sum up all the caller's listings.
+export async function getAnalytics(propertyId?: number, period: { from?: string; to?: string } = {}): Promise<AnalyticsReport> {
+  const params = new URLSearchParams();
+  if (period.from) params.set('from', period.from);
+  if (period.to) params.set('to', period.to);
+  const path = propertyId ? `/properties/${propertyId}/analytics` : '/properties/my/analytics';
+  const qs = params.toString();
+  return request(qs ? `${path}?${qs}` : path, {
+    headers: { ...authHeaders() },
+  });
+}
+
+export async function setContactVisibility(propertyId: number, settings: { phoneVisibility: ContactVisibility; emailVisibility: ContactVisibility }) {
+  return request(`/properties/${propertyId}/contacts`, {
+    method: 'PUT',
+    headers: { ...authHeaders() },
+    body: JSON.stringify(settings),
+  });
+}
+
+
+
+export async function deleteProperty(propertyId: number) {
+  return request(`/properties/${propertyId}`, {
+    method: 'DELETE',
+    headers: { ...authHeaders() },
+  });
+}
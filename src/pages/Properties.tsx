@@ -16,6 +16,7 @@ type Item = {
   area?: number;
   images?: Array<{ url: string; order: number }>;
   isPromoted?: boolean;
+  isHighlighted?: boolean;
 };
 
 const PropertiesPage = () => {
@@ -101,6 +102,7 @@ const PropertiesPage = () => {
                 area={p.area || 0}
                 imageUrl={getImageUrl(p)}
                 isPromoted={p.isPromoted}
+                isHighlighted={p.isHighlighted}
               />
             ))}
           </div>
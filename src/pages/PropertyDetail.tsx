@@ -1,6 +1,6 @@
 import { useParams, useNavigate } from "react-router-dom";
 import { useQuery } from "@tanstack/react-query";
-import { getProperty, BACKEND_URL } from "@/lib/api";
+import { getProperty, listAmenities, revealContacts, BACKEND_URL } from "@/lib/api";
 import { Property } from "@/lib/api";
 import Header from "@/components/Header";
 import Footer from "@/components/Footer";
@@ -36,12 +36,36 @@ const PropertyDetail = () => {
   const [imageError, setImageError] = useState<Record<number, boolean>>({});
   const [chatOpen, setChatOpen] = useState(false);
   const [isAuthFormOpen, setIsAuthFormOpen] = useState(false);
+  const [revealed, setRevealed] = useState<{ phone?: string; email?: string } | null>(null);
+  const [revealError, setRevealError] = useState("");
+  const [revealing, setRevealing] = useState(false);
 
   const { data: property, isLoading, error } = useQuery({
     queryKey: ["property", id],
     queryFn: () => getProperty(id!),
     enabled: !!id,
   });
+  const { data: amenityCatalog } = useQuery({
+    queryKey: ["amenities"],
+    queryFn: listAmenities,
+    staleTime: 60 * 60 * 1000,
+  });
+
+  const handleReveal = async () => {
+    if (!user) {
+      setIsAuthFormOpen(true);
+      return;
+    }
+    setRevealing(true);
+    setRevealError("");
+    try {
+      setRevealed(await revealContacts(property!.id));
+    } catch (err: any) {
+      setRevealError(err?.message || "Не удалось показать контакты");
+    } finally {
+      setRevealing(false);
+    }
+  };
 
   const handleImageError = (imageIndex: number) => {
     setImageError(prev => ({ ...prev, [imageIndex]: true }));
@@ -108,7 +132,23 @@ const PropertyDetail = () => {
     );
   }
 
-  const amenities = property.amenities ? property.amenities.split(',') : [];
+  const phone = revealed?.phone || property.phone;
+  const email = revealed?.email || property.email;
+  const canReveal = !revealed && (
+    (!property.phone && property.phoneVisibility === 'on_request') ||
+    (!property.email && property.emailVisibility === 'on_request')
+  );
+  const amenityLabels = new Map((amenityCatalog?.items ?? []).map(a => [a.code, a.label]));
+  const amenities = (property.amenities ?? []).map(code => amenityLabels.get(code) ?? code);
+  const attributes = [
+    property.floor != null && `Этаж ${property.floor}${property.totalFloors != null ? ` из ${property.totalFloors}` : ''}`,
+    property.furnished != null && (property.furnished ? 'С мебелью' : 'Без мебели'),
+    property.petsAllowed != null && (property.petsAllowed ? 'Можно с животными' : 'Без животных'),
+    property.balcony && 'Балкон',
+    property.parking && 'Парковка',
+    property.utilitiesIncluded != null && (property.utilitiesIncluded ? 'Коммунальные включены' : 'Коммунальные оплачиваются отдельно'),
+    property.deposit != null && (property.deposit > 0 ? `Залог ${property.deposit.toLocaleString('ru-RU')} ₽` : 'Без залога'),
+  ].filter(Boolean) as string[];
   const hasImages = property.images && property.images.length > 0;
   const propertyImage = hasImages ? getImageUrl(property.images[0].url) : undefined;
 
@@ -256,6 +296,21 @@ const PropertyDetail = () => {
               </div>
             </div>
 
+            {/* Attributes */}
+            {attributes.length > 0 && (
+              <div className="mb-8">
+                <h2 className="text-2xl font-semibold mb-4">Условия</h2>
+                <div className="grid grid-cols-2 md:grid-cols-3 gap-3">
+                  {attributes.map((attribute) => (
+                    <div key={attribute} className="flex items-center space-x-2">
+                      <div className="w-2 h-2 bg-primary rounded-full"></div>
+                      <span className="text-foreground">{attribute}</span>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            )}
+
             {/* Amenities */}
             {amenities.length > 0 && (
               <div className="mb-8">
@@ -264,7 +319,7 @@ const PropertyDetail = () => {
                   {amenities.map((amenity, index) => (
                     <div key={index} className="flex items-center space-x-2">
                       <div className="w-2 h-2 bg-primary rounded-full"></div>
-                      <span className="text-foreground">{amenity.trim()}</span>
+                      <span className="text-foreground">{amenity}</span>
                     </div>
                   ))}
                 </div>
@@ -278,18 +333,28 @@ const PropertyDetail = () => {
             <div className="bg-card border border-border rounded-lg p-6 mb-6">
               <h3 className="text-lg font-semibold mb-4">Контактная информация</h3>
               <div className="space-y-3">
-                {property.phone && (
+                {phone && (
                   <div className="flex items-center space-x-3">
                     <Phone className="h-4 w-4 text-muted-foreground" />
-                    <span className="text-foreground">{property.phone}</span>
+                    <span className="text-foreground">{phone}</span>
                   </div>
                 )}
-                {property.email && (
+                {email && (
                   <div className="flex items-center space-x-3">
                     <Mail className="h-4 w-4 text-muted-foreground" />
-                    <span className="text-foreground">{property.email}</span>
+                    <span className="text-foreground">{email}</span>
                   </div>
                 )}
+                {canReveal && (
+                  <Button variant="outline" className="w-full" onClick={handleReveal} disabled={revealing}>
+                    <Phone className="h-4 w-4 mr-2" />
+                    {user ? 'Показать контакты' : 'Войти, чтобы увидеть контакты'}
+                  </Button>
+                )}
+                {!phone && !email && !canReveal && (
+                  <p className="text-sm text-muted-foreground">Владелец скрыл контакты — напишите ему в чате</p>
+                )}
+                {revealError && <p className="text-sm text-destructive">{revealError}</p>}
               </div>
             </div>
 
@@ -352,9 +417,9 @@ const PropertyDetail = () => {
                 </Button>
               )}
               
-              {property.phone && (
+              {phone && (
                 <Button variant="outline" className="w-full" size="lg" asChild>
-                  <a href={`tel:${property.phone}`}>
+                  <a href={`tel:${phone}`}>
                     <Phone className="h-4 w-4 mr-2" />
                     Позвонить
                   </a>